@@ -0,0 +1,114 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// AckIDSize is the length of the acknowledgement id that prefixes every
+// datagram sent by TransmitAndAwaitAcks.
+const AckIDSize = 8
+
+// TransmitAndAwaitAcks sends data to every address and waits up to timeout for
+// the peers to acknowledge it. Each datagram is prefixed with a AckIDSize byte
+// id unique to the destination, which the peer must send back using
+// Acknowledge. It reports the peers that acknowledged and those that did not,
+// both in the order of addrs. Any other datagram received while waiting is
+// discarded.
+func (u *UDPClient) TransmitAndAwaitAcks(addrs []*net.UDPAddr, data []byte, timeout time.Duration) (
+	acked []*net.UDPAddr,
+	missing []*net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to TransmitAndAwaitAcks due to uninitialized client")
+		return
+	}
+
+	if len(addrs) == 0 || len(data) == 0 || timeout <= 0 {
+		err = fmt.Errorf("parameter error in TransmitAndAwaitAcks")
+		return
+	}
+
+	var b [AckIDSize]byte
+	_, err = rand.Read(b[:])
+	if err != nil {
+		err = fmt.Errorf("failed to generate id in TransmitAndAwaitAcks - %w", err)
+		return
+	}
+	base := binary.BigEndian.Uint64(b[:])
+
+	deadline := time.Now().Add(timeout)
+	pending := make(map[uint64]int, len(addrs))
+	done := make([]bool, len(addrs))
+	msg := make([]byte, AckIDSize+len(data))
+	copy(msg[AckIDSize:], data)
+	for i, addr := range addrs {
+		id := base + uint64(i)
+		binary.BigEndian.PutUint64(msg, id)
+		if _, terr := u.Transmit(addr, msg); terr != nil {
+			continue
+		}
+		pending[id] = i
+	}
+
+	err = u.applyReadDeadline(deadline)
+	if err != nil {
+		err = fmt.Errorf("failed in setting read deadline in TransmitAndAwaitAcks - %w", err)
+		return
+	}
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+	rb := *bp
+	for len(pending) > 0 {
+		n, flags, from, rerr := u.read(rb)
+		if rerr != nil {
+			if !IsTimeout(rerr) {
+				err = fmt.Errorf("failed to read acknowledgement in TransmitAndAwaitAcks - %w", rerr)
+			}
+			break
+		}
+		n, rerr = u.process(rb, n, flags, from)
+		if rerr != nil || n < AckIDSize {
+			continue
+		}
+		id := binary.BigEndian.Uint64(rb)
+		if i, ok := pending[id]; ok {
+			done[i] = true
+			delete(pending, id)
+		}
+	}
+
+	for i, addr := range addrs {
+		if done[i] {
+			acked = append(acked, addr)
+		} else {
+			missing = append(missing, addr)
+		}
+	}
+
+	return
+}
+
+// Acknowledge sends back the id of a datagram received from
+// TransmitAndAwaitAcks to the peer at addr. The payload of such a datagram
+// starts after the first AckIDSize bytes.
+func (u *UDPClient) Acknowledge(addr *net.UDPAddr, datagram []byte) error {
+	if len(datagram) < AckIDSize {
+		return fmt.Errorf("parameter error in Acknowledge")
+	}
+
+	_, err := u.Transmit(addr, datagram[:AckIDSize])
+	if err != nil {
+		return fmt.Errorf("failed to send acknowledgement - %w", err)
+	}
+	return nil
+}
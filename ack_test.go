@@ -0,0 +1,97 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_TransmitAndAwaitAcks(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	message := "A journey of a thousand miles begins with a single step"
+
+	sender, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer sender.Close()
+
+	responder, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create responder -", err)
+	}
+	defer responder.Close()
+	responder.ReadDeadline = time.Second
+
+	silent, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create silent responder -", err)
+	}
+	defer silent.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		buf := make([]byte, maxBufferSize)
+		n, err := responder.Receive(buf)
+		if err != nil {
+			t.Error("failed to receive in responder -", err)
+			return
+		}
+		if got := string(buf[AckIDSize:n]); got != message {
+			t.Errorf("expected payload %q got %q", message, got)
+		}
+		err = responder.Acknowledge(responder.RemoteAddr.(*net.UDPAddr), buf[:n])
+		if err != nil {
+			t.Error("failed to acknowledge -", err)
+		}
+	}()
+
+	live := responder.LocalAddr().(*net.UDPAddr)
+	dead := silent.LocalAddr().(*net.UDPAddr)
+	acked, missing, err := sender.TransmitAndAwaitAcks(
+		[]*net.UDPAddr{dead, live}, []byte(message), 500*time.Millisecond)
+	wg.Wait()
+	if err != nil {
+		t.Fatal("failed to transmit and await acks -", err)
+	}
+
+	if len(acked) != 1 || acked[0] != live {
+		t.Errorf("expected acked [%v] got %v", live, acked)
+	}
+	if len(missing) != 1 || missing[0] != dead {
+		t.Errorf("expected missing [%v] got %v", dead, missing)
+	}
+}
+
+func TestUDPClient_TransmitAndAwaitAcks_Errors(t *testing.T) {
+	var u *UDPClient
+	_, _, err := u.TransmitAndAwaitAcks([]*net.UDPAddr{{Port: testingPort}}, []byte("testing"), time.Second)
+	if err == nil {
+		t.Error("expected Error got nil")
+	}
+
+	u, err = NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	_, _, err = u.TransmitAndAwaitAcks(nil, []byte("testing"), time.Second)
+	if err == nil {
+		t.Error("expected Error(missing addrs) got nil")
+	}
+	_, _, err = u.TransmitAndAwaitAcks([]*net.UDPAddr{{Port: testingPort}}, []byte("testing"), 0)
+	if err == nil {
+		t.Error("expected Error(missing timeout) got nil")
+	}
+	if err = u.Acknowledge(&net.UDPAddr{Port: testingPort}, []byte("short")); err == nil {
+		t.Error("expected Error(short datagram) got nil")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START).
+var listenFdsStart = 3
+
+// FromActivation wraps the sockets passed by systemd socket activation into
+// UDPClients configured by opts like FromConn, in the order they were
+// passed. The LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES variables are read
+// and then removed from the environment so child processes do not inherit
+// them. It returns no clients when the process was not socket activated.
+// Each descriptor is duplicated into the client and the original is closed.
+func FromActivation(opts ...Option) ([]*UDPClient, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	clients := make([]*UDPClient, 0, count)
+	for i := 0; i < count; i++ {
+		fd := listenFdsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		u, err := NewUDPClientFromFile(f, opts...)
+		f.Close()
+		if err != nil {
+			closeAll(clients)
+			return nil, fmt.Errorf("failed to adopt activated socket %q - %w", name, err)
+		}
+		clients = append(clients, u)
+	}
+
+	return clients, nil
+}
+
+// NewUDPClientFromFile adopts the UDP socket open as f, for instance one
+// inherited across a fork or exec, into a UDPClient configured by opts like
+// FromConn. The descriptor is duplicated: the client owns the duplicate
+// and closes it on Close, while f stays open and is still to be closed by
+// the caller, which may be done at once. The socket itself is shared by
+// both descriptors until both are closed, along with its bound address and
+// options.
+func NewUDPClientFromFile(f *os.File, opts ...Option) (*UDPClient, error) {
+	if f == nil {
+		return nil, fmt.Errorf("parameter error in NewUDPClientFromFile")
+	}
+
+	pc, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adopt socket in NewUDPClientFromFile - %w", err)
+	}
+
+	conn, ok := pc.(*net.UDPConn)
+	if !ok {
+		pc.Close()
+		return nil, fmt.Errorf("failed in NewUDPClientFromFile as %q is not a UDP socket", f.Name())
+	}
+	u, err := FromConn(conn, opts...)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to adopt socket in NewUDPClientFromFile - %w", err)
+	}
+	return u, nil
+}
+
+// closeAll closes every client of the list.
+func closeAll(clients []*UDPClient) {
+	for _, u := range clients {
+		u.Close()
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build unix
+
+package udp
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFromActivation(t *testing.T) {
+	t.Run("Not activated", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "1")
+		t.Setenv("LISTEN_FDS", "1")
+		clients, err := FromActivation()
+		if err != nil || clients != nil {
+			t.Errorf("expected no clients got %v, %v", clients, err)
+		}
+	})
+
+	t.Run("Activated", func(t *testing.T) {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to listen -", err)
+		}
+		defer conn.Close()
+		f, err := conn.File()
+		if err != nil {
+			t.Fatal("failed to get socket file -", err)
+		}
+		// A raw descriptor, not owned by any *os.File, stands in for fd 3
+		fd, err := syscall.Dup(int(f.Fd()))
+		f.Close()
+		if err != nil {
+			t.Fatal("failed to duplicate socket -", err)
+		}
+
+		start := listenFdsStart
+		listenFdsStart = fd
+		defer func() { listenFdsStart = start }()
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "1")
+		t.Setenv("LISTEN_FDNAMES", "echo")
+
+		clients, err := FromActivation(WithReadDeadline(time.Second))
+		if err != nil {
+			t.Fatal("failed to adopt activated socket -", err)
+		}
+		if len(clients) != 1 {
+			t.Fatalf("expected 1 client got %d", len(clients))
+		}
+		u := clients[0]
+		defer u.Close()
+
+		if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+			t.Error("expected activation environment to be cleared")
+		}
+		if u.ReadDeadline != time.Second {
+			t.Errorf("expected the options applied got ReadDeadline %v", u.ReadDeadline)
+		}
+		if u.LocalAddr().String() != conn.LocalAddr().String() {
+			t.Errorf("expected local address %v got %v", conn.LocalAddr(), u.LocalAddr())
+		}
+
+		if _, err = u.Transmit(conn.LocalAddr().(*net.UDPAddr), []byte("testing")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+			t.Error("failed to receive -", err)
+		}
+	})
+}
+
+func TestNewUDPClientFromFile(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to listen -", err)
+	}
+	f, err := conn.File()
+	conn.Close()
+	if err != nil {
+		t.Fatal("failed to get socket file -", err)
+	}
+
+	u, err := NewUDPClientFromFile(f)
+	// The client holds its own descriptor of the socket
+	f.Close()
+	if err != nil {
+		t.Fatal("failed to adopt socket -", err)
+	}
+	defer u.Close()
+	if u.ReadDeadline != ReadDeadline || u.WriteDeadline != WriteDeadline {
+		t.Errorf("expected default deadlines got %v and %v", u.ReadDeadline, u.WriteDeadline)
+	}
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	buf := make([]byte, maxBufferSize)
+	if _, err = peer.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("ping")); err != nil {
+		t.Fatal("failed to transmit to adopted socket -", err)
+	}
+	n, err := u.Receive(buf)
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Fatalf("expected %q got %q and %v", "ping", buf[:n], err)
+	}
+	if _, err = u.Transmit(peer.LocalAddr().(*net.UDPAddr), []byte("pong")); err != nil {
+		t.Fatal("failed to transmit from adopted socket -", err)
+	}
+	if n, err = peer.Receive(buf); err != nil || string(buf[:n]) != "pong" {
+		t.Errorf("expected %q got %q and %v", "pong", buf[:n], err)
+	}
+
+	if _, err = NewUDPClientFromFile(nil); err == nil {
+		t.Error("expected Error for nil file got nil")
+	}
+	tcp, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to listen on tcp -", err)
+	}
+	defer tcp.Close()
+	tf, err := tcp.File()
+	if err != nil {
+		t.Fatal("failed to get tcp socket file -", err)
+	}
+	defer tf.Close()
+	if _, err = NewUDPClientFromFile(tf); err == nil {
+		t.Error("expected Error for a TCP socket got nil")
+	}
+}
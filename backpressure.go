@@ -0,0 +1,102 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// ErrNoBufferSpace is returned by Transmit when the kernel kept reporting
+// ENOBUFS for longer than the WriteBackpressure budget.
+var ErrNoBufferSpace = errors.New("no buffer space available")
+
+// ErrSendBufferFull is returned by Transmit when the send buffer of the
+// socket stayed full (EAGAIN) until the write deadline. Sockets watched by
+// the runtime poller wait for room on their own and report a timeout
+// instead, so it comes from connections passing EAGAIN through, such as a
+// non-blocking descriptor the poller cannot watch.
+var ErrSendBufferFull = errors.New("send buffer full")
+
+// maxBackpressurePoll caps the interval between write retries on ENOBUFS,
+// and the wait for writability on EAGAIN.
+const maxBackpressurePoll = 10 * time.Millisecond
+
+// writeWithBackpressure performs write and, when WriteBackpressure is set,
+// retries it while the kernel reports ENOBUFS. Linux does not signal
+// writability for this condition so the retries are paced by an exponential
+// sleep up to maxBackpressurePoll, re-arming the write deadline each time
+// without going past bound, unless bound is zero. The write deadline
+// applied for the first attempt is deadline.
+func (u *UDPClient) writeWithBackpressure(bound, deadline time.Time, write func() (int, error)) (int, error) {
+	n, err := u.writeWhenWritable(deadline, write)
+	if u.WriteBackpressure <= 0 || !errors.Is(err, syscall.ENOBUFS) {
+		return n, err
+	}
+
+	u.logf("udp: no buffer space, retrying for up to %v", u.WriteBackpressure)
+	budget := time.Now().Add(u.WriteBackpressure)
+	poll := 100 * time.Microsecond
+	for errors.Is(err, syscall.ENOBUFS) {
+		wait := time.Until(budget)
+		if wait <= 0 {
+			return n, fmt.Errorf("%w after %v - %w", ErrNoBufferSpace, u.WriteBackpressure, err)
+		}
+		time.Sleep(min(poll, wait))
+		poll = min(2*poll, maxBackpressurePoll)
+
+		if deadline = u.boundedWriteDeadline(bound); !deadline.IsZero() {
+			if derr := u.applyWriteDeadline(deadline); derr != nil {
+				return n, derr
+			}
+		}
+		n, err = u.writeWhenWritable(deadline, write)
+	}
+	return n, err
+}
+
+// writeWhenWritable performs write and, while it fails with EAGAIN, waits
+// for the socket to become writable and retries, until deadline, the write
+// deadline applied to the socket, when it fails with ErrSendBufferFull. A
+// wait lasts maxBackpressurePoll at most so that a missed readiness
+// notification does not stall the retries.
+func (u *UDPClient) writeWhenWritable(deadline time.Time, write func() (int, error)) (int, error) {
+	n, err := write()
+	if !errors.Is(err, syscall.EAGAIN) && !errors.Is(err, syscall.EWOULDBLOCK) {
+		return n, err
+	}
+
+	rc, rcErr := u.conn.SyscallConn()
+	for errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EWOULDBLOCK) {
+		now := time.Now()
+		if !deadline.IsZero() && !now.Before(deadline) {
+			return n, fmt.Errorf("%w until the write deadline - %w", ErrSendBufferFull, err)
+		}
+		wait := now.Add(maxBackpressurePoll)
+		if !deadline.IsZero() && deadline.Before(wait) {
+			wait = deadline
+		}
+
+		if rcErr != nil {
+			time.Sleep(time.Until(wait))
+		} else if derr := u.conn.SetWriteDeadline(wait); derr == nil {
+			// Returning false once makes the runtime wait for writability,
+			// the timeout of the wait only ends it
+			waited := false
+			_ = rc.Write(func(uintptr) bool {
+				done := waited
+				waited = true
+				return done
+			})
+		}
+		if derr := u.conn.SetWriteDeadline(deadline); derr != nil {
+			return n, derr
+		}
+		n, err = write()
+	}
+	return n, err
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyWrite returns a write function failing with ENOBUFS for the first
+// failures calls.
+func flakyWrite(failures int, calls *int) func() (int, error) {
+	return func() (int, error) {
+		*calls++
+		if *calls <= failures {
+			return 0, syscall.ENOBUFS
+		}
+		return 7, nil
+	}
+}
+
+func TestUDPClient_WriteBackpressure(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	t.Run("Disabled", func(t *testing.T) {
+		u.WriteBackpressure = 0
+		var calls int
+		_, err := u.writeWithBackpressure(time.Time{}, time.Time{}, flakyWrite(1, &calls))
+		if !errors.Is(err, syscall.ENOBUFS) || calls != 1 {
+			t.Errorf("expected a single ENOBUFS attempt got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("Recovers within budget", func(t *testing.T) {
+		u.WriteBackpressure = time.Second
+		var calls int
+		start := time.Now()
+		n, err := u.writeWithBackpressure(time.Time{}, time.Time{}, flakyWrite(3, &calls))
+		if err != nil || n != 7 {
+			t.Fatalf("expected success got (%d, %v)", n, err)
+		}
+		if calls != 4 {
+			t.Errorf("expected 4 attempts got %d", calls)
+		}
+		if elapsed := time.Since(start); elapsed > u.WriteBackpressure {
+			t.Errorf("expected retries within %v took %v", u.WriteBackpressure, elapsed)
+		}
+	})
+
+	t.Run("Budget exhausted", func(t *testing.T) {
+		u.WriteBackpressure = 30 * time.Millisecond
+		var calls int
+		start := time.Now()
+		_, err := u.writeWithBackpressure(time.Time{}, time.Time{}, flakyWrite(1<<30, &calls))
+		if !errors.Is(err, ErrNoBufferSpace) || !errors.Is(err, syscall.ENOBUFS) {
+			t.Errorf("expected ErrNoBufferSpace wrapping ENOBUFS got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < u.WriteBackpressure {
+			t.Errorf("expected to wait at least %v waited %v", u.WriteBackpressure, elapsed)
+		}
+	})
+}
+
+// fullConn fails writes with EAGAIN while full is positive, counting down,
+// as a socket with a full send buffer that the runtime poller does not
+// watch would.
+type fullConn struct {
+	packetConn
+	full   atomic.Int32
+	writes atomic.Int32
+}
+
+func (f *fullConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	f.writes.Add(1)
+	if f.full.Add(-1) >= 0 {
+		return 0, syscall.EAGAIN
+	}
+	return f.packetConn.WriteTo(b, addr)
+}
+
+func TestUDPClient_SendBufferFull(t *testing.T) {
+	// Loopback hands datagrams over at once, so the send buffer of a real
+	// socket never stays full and the EAGAIN is simulated
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	conn := &fullConn{packetConn: u.conn}
+	u.conn = conn
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	t.Run("Retried once writable", func(t *testing.T) {
+		conn.full.Store(3)
+		conn.writes.Store(0)
+		if _, err := u.Transmit(dst, []byte("testing")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if writes := conn.writes.Load(); writes != 4 {
+			t.Errorf("expected 4 attempts got %d", writes)
+		}
+		n, err := u.Receive(make([]byte, maxBufferSize))
+		if err != nil || n != len("testing") {
+			t.Errorf("expected the datagram delivered got %d and %v", n, err)
+		}
+	})
+
+	t.Run("Full until deadline", func(t *testing.T) {
+		conn.full.Store(1 << 30)
+		u.WriteDeadline = 30 * time.Millisecond
+		start := time.Now()
+		_, err := u.Transmit(dst, []byte("testing"))
+		if !errors.Is(err, ErrSendBufferFull) || !errors.Is(err, syscall.EAGAIN) {
+			t.Errorf("expected ErrSendBufferFull wrapping EAGAIN got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < u.WriteDeadline {
+			t.Errorf("expected to retry for %v gave up after %v", u.WriteDeadline, elapsed)
+		}
+		if writes := conn.writes.Load(); writes < 3 {
+			t.Errorf("expected several attempts got %d", writes)
+		}
+	})
+
+	t.Run("Deadline applied kept", func(t *testing.T) {
+		// The retries end at the deadline the write started with, not at
+		// one computed anew from WriteDeadline
+		u.WriteDeadline = time.Hour
+		defer func() { u.WriteDeadline = 0 }()
+		deadline := time.Now().Add(30 * time.Millisecond)
+		done := make(chan error, 1)
+		go func() {
+			_, err := u.writeWhenWritable(deadline, func() (int, error) { return 0, syscall.EAGAIN })
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrSendBufferFull) {
+				t.Errorf("expected ErrSendBufferFull got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the retries to end at the deadline applied")
+		}
+	})
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// TransmitBatch sends every packet to addr as a datagram of its own, using as
+// few system calls as the platform allows: sendmmsg on Linux and a WriteTo
+// per packet elsewhere. Packets are framed like those of Transmit. While
+// PacingGap or a rate limit is set they are sent one at a time instead, each
+// waiting like a Transmit. It returns the number of packets sent, which
+// falls short of len(packets) on an error.
+func (u *UDPClient) TransmitBatch(addr *net.UDPAddr, packets [][]byte) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to TransmitBatch due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("TransmitBatch", err) }()
+
+	if addr == nil || len(packets) == 0 {
+		err = fmt.Errorf("parameter error in TransmitBatch")
+		return
+	}
+
+	wire := make([][]byte, len(packets))
+	for i, p := range packets {
+		if len(p) == 0 && !u.AllowEmptyDatagrams {
+			err = fmt.Errorf("parameter error in TransmitBatch - packet %d is empty", i)
+			return
+		}
+		wire[i] = u.encode(p)
+	}
+
+	if u.quiesced.Load() {
+		err = fmt.Errorf("failed to TransmitBatch - %w", ErrQuiesced)
+		return
+	}
+
+	err = u.checkFamily(addr)
+	if err != nil {
+		err = fmt.Errorf("failed to validate address in TransmitBatch - %w", err)
+		return
+	}
+
+	err = u.applyWriteDeadline(u.nextWriteDeadline())
+	if err != nil {
+		err = fmt.Errorf("failed in setting write deadline in TransmitBatch - %w", err)
+		return
+	}
+
+	if u.PacingGap > 0 || u.limiter != nil {
+		n, err = u.writePaced(addr, wire)
+	} else {
+		n, err = u.writeBatch(addr, wire)
+	}
+	for i := 0; i < n; i++ {
+		u.stats.sent(len(wire[i]))
+		u.logTraffic("transmitted", addr, len(wire[i]))
+		if u.TransmitHook != nil {
+			u.TransmitHook(len(packets[i]), addr)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to write packet %d in TransmitBatch - %w", n, packetTooLarge(err))
+	}
+
+	return
+}
+
+// writeEach sends the packets to addr one WriteTo at a time, returning the
+// number sent.
+func (u *UDPClient) writeEach(addr *net.UDPAddr, packets [][]byte) (int, error) {
+	for i, p := range packets {
+		_, err := retryEINTR(func() (int, error) {
+			return u.conn.WriteTo(p, addr)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(packets), nil
+}
+
+// writePaced sends the packets to addr one at a time, each waiting for the
+// rate limit and PacingGap and given a write deadline of its own, returning
+// the number sent.
+func (u *UDPClient) writePaced(addr *net.UDPAddr, packets [][]byte) (int, error) {
+	for i, p := range packets {
+		if err := u.throttle(); err != nil {
+			return i, err
+		}
+		if u.PacingGap > 0 {
+			u.pace()
+		}
+		if err := u.applyWriteDeadline(u.nextWriteDeadline()); err != nil {
+			return i, err
+		}
+		_, err := retryEINTR(func() (int, error) {
+			return u.conn.WriteTo(p, addr)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(packets), nil
+}
+
+// batchLinger is how long the ReceiveBatch fallback waits for a further
+// datagram once one arrived.
+const batchLinger = time.Millisecond
+
+// readEach reads up to len(bufs) datagrams one at a time, the first one
+// waiting for the read deadline already applied and the following ones up
+// to batchLinger each. Datagrams rejected by the source filter are skipped
+// by read.
+func (u *UDPClient) readEach(bufs [][]byte) ([]batchMsg, error) {
+	var read []batchMsg
+	for i, b := range bufs {
+		if i > 0 {
+			if err := u.applyReadDeadline(time.Now().Add(batchLinger)); err != nil {
+				break
+			}
+		}
+		n, flags, addr, err := u.read(b)
+		if err != nil {
+			if i > 0 {
+				break
+			}
+			return nil, err
+		}
+		read = append(read, batchMsg{n: n, flags: flags, addr: udpAddr(addr)})
+	}
+	return read, nil
+}
+
+// batchMsg is a datagram read by readBatch, not processed yet.
+type batchMsg struct {
+	n        int
+	flags    int
+	addr     *net.UDPAddr
+	rejected bool // by the source filter or the per source rate limit
+}
+
+// BatchResult describes a datagram read by ReceiveBatch.
+type BatchResult struct {
+	Buf  int // index of the buffer holding the datagram
+	N    int
+	Addr *net.UDPAddr
+}
+
+// ReceiveBatch reads up to len(bufs) datagrams, one per buffer, using as few
+// system calls as the platform allows. It waits for the first datagram until
+// the read deadline, then collects those already queued with recvmmsg on
+// Linux; elsewhere it keeps reading while datagrams arrive within a
+// millisecond of each other. It returns a result per datagram read, in the
+// order of arrival, the datagram being bufs[r.Buf][:r.N]; bufs themselves
+// are left as they are. Datagrams that are truncated or fail to decode are
+// dropped, leaving their buffers out of the results; an error is only
+// returned when none are left. Those rejected by the source filter are
+// dropped silently.
+func (u *UDPClient) ReceiveBatch(bufs [][]byte) (
+	results []BatchResult,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveBatch due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("ReceiveBatch", err) }()
+
+	if len(bufs) == 0 {
+		err = fmt.Errorf("parameter error in ReceiveBatch")
+		return
+	}
+	for _, b := range bufs {
+		if len(b) == 0 {
+			err = fmt.Errorf("parameter error in ReceiveBatch")
+			return
+		}
+	}
+
+	// Reading goes on while the source filter rejects all the datagrams,
+	// the fallback lingering changes the deadline so it is applied anew
+	deadline := u.nextReadDeadline()
+	for len(results) == 0 && err == nil {
+		err = u.applyReadDeadline(deadline)
+		if err != nil {
+			err = fmt.Errorf("failed in setting read deadline in ReceiveBatch - %w", err)
+			return
+		}
+
+		var msgs []batchMsg
+		msgs, err = u.readBatch(bufs)
+		if err != nil {
+			err = fmt.Errorf("failed to read data in ReceiveBatch - %w", err)
+			return
+		}
+
+		for i, m := range msgs {
+			if m.rejected {
+				continue
+			}
+			size, perr := u.process(bufs[i], m.n, m.flags, m.addr)
+			if perr != nil {
+				u.logf("udp: dropped datagram from %v in ReceiveBatch - %v", m.addr, perr)
+				err = perr
+				continue
+			}
+			results = append(results, BatchResult{Buf: i, N: size, Addr: m.addr})
+		}
+	}
+	if len(results) > 0 {
+		err = nil
+	} else {
+		err = fmt.Errorf("failed in ReceiveBatch - %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+
+	"golang.org/x/net/ipv4"
+)
+
+// writeBatch sends the packets to addr with sendmmsg, returning the number
+// sent. IPv4 destinations of an IPv6 socket need the address mapping done
+// by WriteTo, so they are sent one at a time, as are the packets of a
+// connection other than a socket.
+func (u *UDPClient) writeBatch(addr *net.UDPAddr, packets [][]byte) (int, error) {
+	if _, ok := u.conn.(*net.UDPConn); !ok {
+		return u.writeEach(addr, packets)
+	}
+	local, _ := u.conn.LocalAddr().(*net.UDPAddr)
+	if addr.IP.To4() != nil && (local == nil || local.IP.To4() == nil) {
+		return u.writeEach(addr, packets)
+	}
+
+	// The message type is shared by the ipv4 and ipv6 packages, and so is
+	// the system call, whatever the family of the socket
+	msgs := make([]ipv4.Message, len(packets))
+	for i, p := range packets {
+		msgs[i].Buffers = [][]byte{p}
+		msgs[i].Addr = addr
+	}
+
+	pc := ipv4.NewPacketConn(u.conn)
+	sent := 0
+	for sent < len(msgs) {
+		n, err := pc.WriteBatch(msgs[sent:], 0)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// readBatch reads up to len(bufs) datagrams with recvmmsg, waiting for the
+// first one only, and marks those rejected by the source filter. With
+// DropCounter the kernel drop count is taken from the last datagram
+// carrying it. A connection other than a socket is read one datagram at a
+// time.
+func (u *UDPClient) readBatch(bufs [][]byte) ([]batchMsg, error) {
+	if _, ok := u.conn.(*net.UDPConn); !ok {
+		return u.readEach(bufs)
+	}
+
+	if u.DropCounter {
+		u.dropsOnce.Do(func() {
+			u.dropsErr = enableDropCounter(u.conn)
+		})
+		if u.dropsErr != nil {
+			return nil, u.dropsErr
+		}
+	}
+
+	msgs := make([]ipv4.Message, len(bufs))
+	for i, b := range bufs {
+		msgs[i].Buffers = [][]byte{b}
+		if u.DropCounter {
+			msgs[i].OOB = make([]byte, dropCounterOOBSize)
+		}
+	}
+
+	n, err := ipv4.NewPacketConn(u.conn).ReadBatch(msgs, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	read := make([]batchMsg, n)
+	for i, m := range msgs[:n] {
+		if drops, ok := parseDropCount(m.OOB[:m.NN]); ok {
+			u.kernelDrops.Store(drops)
+		}
+		addr, _ := m.Addr.(*net.UDPAddr)
+		read[i] = batchMsg{n: m.N, flags: m.Flags, addr: addr, rejected: !u.accepts(addr)}
+	}
+	return read, nil
+}
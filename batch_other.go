@@ -0,0 +1,20 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import "net"
+
+// writeBatch sends the packets to addr one at a time, returning the number
+// sent.
+func (u *UDPClient) writeBatch(addr *net.UDPAddr, packets [][]byte) (int, error) {
+	return u.writeEach(addr, packets)
+}
+
+// readBatch reads up to len(bufs) datagrams one at a time.
+func (u *UDPClient) readBatch(bufs [][]byte) ([]batchMsg, error) {
+	return u.readEach(bufs)
+}
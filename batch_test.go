@@ -0,0 +1,178 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_TransmitBatch(t *testing.T) {
+	tx, rx := chunkPair(t)
+	dst := rx.LocalAddr().(*net.UDPAddr)
+
+	packets := make([][]byte, 32)
+	for i := range packets {
+		packets[i] = []byte(fmt.Sprint("packet ", i))
+	}
+	n, err := tx.TransmitBatch(dst, packets)
+	if err != nil {
+		t.Fatal("failed to transmit batch -", err)
+	}
+	if n != len(packets) {
+		t.Errorf("expected %d packets sent got %d", len(packets), n)
+	}
+	if got := tx.Stats().PacketsSent; got != uint64(len(packets)) {
+		t.Errorf("expected %d packets counted got %d", len(packets), got)
+	}
+
+	buf := make([]byte, maxBufferSize)
+	for i := range packets {
+		n, err := rx.Receive(buf)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if string(buf[:n]) != string(packets[i]) {
+			t.Errorf("expected %q got %q", packets[i], buf[:n])
+		}
+	}
+
+	if _, err = tx.TransmitBatch(dst, [][]byte{{1}, nil}); err == nil {
+		t.Errorf("expected error for empty packet")
+	}
+	if _, err = tx.TransmitBatch(nil, packets); err == nil {
+		t.Errorf("expected error for nil address")
+	}
+}
+
+func BenchmarkTransmitBatch(b *testing.B) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	u, err := NewUDPClient(loopback)
+	if err != nil {
+		b.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	sink, err := NewUDPClient(loopback)
+	if err != nil {
+		b.Fatal("failed to create sink -", err)
+	}
+	defer sink.Close()
+	dst := sink.LocalAddr().(*net.UDPAddr)
+
+	packets := make([][]byte, 64)
+	for i := range packets {
+		packets[i] = make([]byte, 64)
+	}
+
+	b.Run("batch", func(b *testing.B) {
+		b.SetBytes(int64(len(packets) * 64))
+		for i := 0; i < b.N; i++ {
+			if _, err := u.TransmitBatch(dst, packets); err != nil {
+				b.Fatal("failed to transmit batch -", err)
+			}
+		}
+	})
+	b.Run("loop", func(b *testing.B) {
+		b.SetBytes(int64(len(packets) * 64))
+		for i := 0; i < b.N; i++ {
+			for _, p := range packets {
+				if _, err := u.Transmit(dst, p); err != nil {
+					b.Fatal("failed to transmit -", err)
+				}
+			}
+		}
+	})
+}
+
+func TestUDPClient_ReceiveBatch(t *testing.T) {
+	tx, rx := chunkPair(t)
+	dst := rx.LocalAddr().(*net.UDPAddr)
+
+	const packets = 10
+	for i := 0; i < packets; i++ {
+		if _, err := tx.Transmit(dst, []byte(fmt.Sprint("packet ", i))); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+
+	bufs := make([][]byte, 16)
+	for i := range bufs {
+		bufs[i] = make([]byte, maxBufferSize)
+	}
+	results, err := rx.ReceiveBatch(bufs)
+	if err != nil {
+		t.Fatal("failed to receive batch -", err)
+	}
+	if len(results) != packets {
+		t.Fatalf("expected %d datagrams got %d", packets, len(results))
+	}
+	for i, r := range results {
+		if want := fmt.Sprint("packet ", i); r.Buf != i || string(bufs[r.Buf][:r.N]) != want {
+			t.Errorf("expected %q in buffer %d got %q in buffer %d", want, i, bufs[r.Buf][:r.N], r.Buf)
+		}
+		if r.Addr.String() != tx.LocalAddr().String() {
+			t.Errorf("expected sender %v got %v", tx.LocalAddr(), r.Addr)
+		}
+	}
+
+	// Truncated datagrams are dropped from the batch, leaving the buffers
+	// as they are for the next one
+	small := [][]byte{make([]byte, 16), make([]byte, 16), make([]byte, 16)}
+	for round := 0; round < 2; round++ {
+		for _, size := range []int{8, 64, 8} {
+			if _, err = tx.Transmit(dst, make([]byte, size)); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+		}
+		results, err = rx.ReceiveBatch(small)
+		if err != nil {
+			t.Fatal("failed to receive batch -", err)
+		}
+		if len(results) != 2 || results[0] != (BatchResult{Buf: 0, N: 8, Addr: results[0].Addr}) ||
+			results[1] != (BatchResult{Buf: 2, N: 8, Addr: results[1].Addr}) {
+			t.Errorf("expected 8 byte datagrams in buffers 0 and 2 got %+v", results)
+		}
+		for i, b := range small {
+			if len(b) != 16 {
+				t.Errorf("expected buffer %d to keep 16 bytes got %d", i, len(b))
+			}
+		}
+	}
+
+	rx.ReadDeadline = 10 * time.Millisecond
+	if _, err = rx.ReceiveBatch(bufs); !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+}
+
+func TestUDPClient_ReceiveBatchRateLimited(t *testing.T) {
+	// The mock is read one datagram at a time like on other platforms
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	u.sourceLimits = newSourceLimiter(1, 3)
+
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+	const packets = 5
+	for i := 0; i < packets; i++ {
+		m.Inject([]byte(fmt.Sprint("packet ", i)), peer)
+	}
+
+	bufs := make([][]byte, 8)
+	for i := range bufs {
+		bufs[i] = make([]byte, maxBufferSize)
+	}
+	results, err := u.ReceiveBatch(bufs)
+	if err != nil {
+		t.Fatal("failed to receive batch -", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("expected the burst of 3 datagrams got %d", len(results))
+	}
+	if got := u.Stats().RateLimited; got != packets-3 {
+		t.Errorf("expected %d rate limited got %d", packets-3, got)
+	}
+}
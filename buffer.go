@@ -0,0 +1,92 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// getBuffer returns a pooled receive buffer of MaxPacketSize bytes. Buffers
+// pooled before MaxPacketSize was raised are dropped.
+func (u *UDPClient) getBuffer() *[]byte {
+	size := u.maxPacketSize()
+	if bp, ok := u.bufPool.Get().(*[]byte); ok && cap(*bp) >= size {
+		*bp = (*bp)[:size]
+		return bp
+	}
+	b := make([]byte, size)
+	return &b
+}
+
+// putBuffer returns a buffer obtained from getBuffer to the pool.
+func (u *UDPClient) putBuffer(bp *[]byte) {
+	u.bufPool.Put(bp)
+}
+
+// handOut takes the buffer out of bp for a caller of the exported API and
+// keeps bp for PutBuffer, so that returning the buffer allocates nothing.
+func (u *UDPClient) handOut(bp *[]byte) []byte {
+	b := *bp
+	*bp = nil
+	u.bufHeaders.Put(bp)
+	return b
+}
+
+// GetBuffer returns a receive buffer of MaxPacketSize bytes from the pool of
+// the client, to be handed back with PutBuffer once its data is no longer
+// used. Recycling buffers spares a high rate receiver an allocation per
+// datagram.
+func (u *UDPClient) GetBuffer() []byte {
+	return u.handOut(u.getBuffer())
+}
+
+// PutBuffer returns a buffer obtained from GetBuffer or ReceiveMessage to
+// the pool. The buffer must not be used afterwards. Buffers smaller than
+// MaxPacketSize, such as the copies of ReceiveMessage, are left to the
+// garbage collector.
+func (u *UDPClient) PutBuffer(b []byte) {
+	if cap(b) < u.maxPacketSize() {
+		return
+	}
+	bp, ok := u.bufHeaders.Get().(*[]byte)
+	if !ok {
+		bp = new([]byte)
+	}
+	*bp = b[:cap(b)]
+	u.putBuffer(bp)
+}
+
+// ReceiveMessage reads one datagram like ReceiveFrom into a pooled buffer of
+// MaxPacketSize bytes and returns an exactly sized copy of its payload, so no
+// buffer has to be managed by the caller. A truncated datagram is returned
+// along with ErrTruncated.
+//
+// When CopyThreshold is positive, the datagrams larger than it are returned
+// in the pooled buffer itself, sparing the copy of large payloads, and must
+// be handed back with PutBuffer once used. Smaller ones are still copies
+// which may be kept. PutBuffer may be called on every message as it ignores
+// the copies.
+func (u *UDPClient) ReceiveMessage() ([]byte, *net.UDPAddr, error) {
+	if u == nil || u.conn == nil {
+		return nil, nil, fmt.Errorf("failed to ReceiveMessage due to uninitialized client")
+	}
+
+	bp := u.getBuffer()
+	n, from, err := u.receiveFrom(*bp, u.nextReadDeadline())
+	if from == nil && err != nil {
+		u.putBuffer(bp)
+		return nil, nil, err
+	}
+	addr := udpAddr(from)
+	if u.CopyThreshold > 0 && n > u.CopyThreshold {
+		return u.handOut(bp)[:n], addr, err
+	}
+	defer u.putBuffer(bp)
+
+	data := make([]byte, n)
+	copy(data, (*bp)[:n])
+	return data, addr, err
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestUDPClient_ReceiveMessage(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	for _, size := range []int{1, 100, 9000} {
+		sent := bytes.Repeat([]byte{'x'}, size)
+		if _, err = u.Transmit(dst, sent); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		data, addr, err := u.ReceiveMessage()
+		if err != nil {
+			t.Fatal("failed to receive message -", err)
+		}
+		if !bytes.Equal(data, sent) || cap(data) != size {
+			t.Errorf("expected exactly %d bytes got len %d cap %d", size, len(data), cap(data))
+		}
+		if addr.String() != dst.String() {
+			t.Errorf("expected sender %v got %v", dst, addr)
+		}
+	}
+
+	// Datagrams larger than MaxPacketSize are truncated
+	u.SetMaxPacketSize(512)
+	if _, err = u.Transmit(dst, make([]byte, 1024)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	data, _, err := u.ReceiveMessage()
+	if !errors.Is(err, ErrTruncated) || len(data) != 512 {
+		t.Errorf("expected 512 bytes with ErrTruncated got %d and %v", len(data), err)
+	}
+
+	if _, _, err = u.ReceiveMessage(); !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+	var nilClient *UDPClient
+	if _, _, err = nilClient.ReceiveMessage(); err == nil {
+		t.Error("expected Error on nil client got nil")
+	}
+}
+
+func TestUDPClient_GetBuffer(t *testing.T) {
+	u, err := NewUnbound()
+	if err != nil {
+		t.Fatal("failed to create unbound client -", err)
+	}
+	u.SetMaxPacketSize(1500)
+
+	b := u.GetBuffer()
+	if len(b) != 1500 {
+		t.Errorf("expected buffer of 1500 bytes got %d", len(b))
+	}
+	u.PutBuffer(b[:10])
+	if b = u.GetBuffer(); len(b) != 1500 {
+		t.Errorf("expected recycled buffer of 1500 bytes got %d", len(b))
+	}
+	u.PutBuffer(b)
+
+	// Recycling a buffer allocates nothing once the pool is warm
+	if !raceEnabled {
+		allocs := testing.AllocsPerRun(100, func() {
+			u.PutBuffer(u.GetBuffer())
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocation got %v", allocs)
+		}
+	}
+
+	// Buffers pooled before MaxPacketSize was raised are not reused
+	u.SetMaxPacketSize(9000)
+	if b = u.GetBuffer(); len(b) != 9000 {
+		t.Errorf("expected buffer of 9000 bytes got %d", len(b))
+	}
+	u.PutBuffer(nil)
+}
+
+func TestWithCopyThreshold(t *testing.T) {
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	u.CopyThreshold = 8
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+
+	small := []byte("tiny")
+	large := bytes.Repeat([]byte{'x'}, 100)
+	m.Inject(small, peer)
+	m.Inject(large, peer)
+	m.Inject(large, peer)
+
+	kept, _, err := u.ReceiveMessage()
+	if err != nil {
+		t.Fatal("failed to receive message -", err)
+	}
+	if !bytes.Equal(kept, small) || cap(kept) != len(small) {
+		t.Errorf("expected an owned copy of %q got %q of capacity %d", small, kept, cap(kept))
+	}
+	u.PutBuffer(kept)
+
+	for i := 0; i < 2; i++ {
+		data, _, err := u.ReceiveMessage()
+		if err != nil {
+			t.Fatal("failed to receive message -", err)
+		}
+		if !bytes.Equal(data, large) || cap(data) != u.maxPacketSize() {
+			t.Errorf("expected the pooled buffer got len %d cap %d", len(data), cap(data))
+		}
+		u.PutBuffer(data)
+	}
+	if !bytes.Equal(kept, small) {
+		t.Errorf("expected the copy kept intact got %q", kept)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithCopyThreshold(0)); err == nil {
+		t.Error("expected Error(zero threshold) got nil")
+	}
+}
+
+func BenchmarkReceivePooled(b *testing.B) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		b.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+	msg := make([]byte, 512)
+
+	receive := func(b *testing.B, get func() []byte, put func([]byte)) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := u.Transmit(dst, msg); err != nil {
+				b.Fatal("failed to transmit -", err)
+			}
+			buf := get()
+			if _, _, err := u.ReceiveFrom(buf); err != nil {
+				b.Fatal("failed to receive -", err)
+			}
+			put(buf)
+		}
+	}
+
+	b.Run("pooled", func(b *testing.B) {
+		receive(b, u.GetBuffer, u.PutBuffer)
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		receive(b, func() []byte { return make([]byte, u.maxPacketSize()) }, func([]byte) {})
+	})
+}
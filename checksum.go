@@ -0,0 +1,49 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// ChecksumSize is the length of the CRC32 appended to every datagram when
+// the checksum is enabled.
+const ChecksumSize = 4
+
+// ErrChecksumMismatch is returned by Receive when a datagram fails its
+// checksum, either because it was corrupted or because it is too short to
+// carry one.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// WithChecksum makes the client append a big endian CRC32 (IEEE) of the
+// payload to every datagram it transmits, and verify and strip it from every
+// datagram it receives. The framing is not negotiated, so it must be enabled
+// on both ends; a peer without it sees the checksum as part of the payload
+// and its datagrams fail with ErrChecksumMismatch.
+func WithChecksum(enable bool) Option {
+	return func(c *config) error {
+		c.checksum = enable
+		return nil
+	}
+}
+
+// appendChecksum returns a copy of data followed by its checksum.
+func appendChecksum(data []byte) []byte {
+	b := make([]byte, len(data), len(data)+ChecksumSize)
+	copy(b, data)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(data))
+}
+
+// verifyChecksum checks the checksum at the end of b, returning the length
+// of the payload before it.
+func verifyChecksum(b []byte) (int, error) {
+	n := len(b) - ChecksumSize
+	if n < 0 || crc32.ChecksumIEEE(b[:n]) != binary.BigEndian.Uint32(b[n:]) {
+		return 0, ErrChecksumMismatch
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestWithChecksum(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	u, err := NewUDPClientWithOptions(WithLocalAddr(loopback), WithChecksum(true))
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	// A peer without the checksum sends raw datagrams
+	raw, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer raw.Close()
+
+	message := []byte("A stitch in time saves nine")
+	framed := appendChecksum(message)
+	buf := make([]byte, maxBufferSize)
+
+	t.Run("Good packet", func(t *testing.T) {
+		n, err := u.Transmit(dst, message)
+		if err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if n != len(message) {
+			t.Errorf("expected %d bytes transmitted got %d", len(message), n)
+		}
+		if got := u.Stats().BytesSent; got != uint64(len(message)+ChecksumSize) {
+			t.Errorf("expected %d bytes on the wire got %d", len(message)+ChecksumSize, got)
+		}
+
+		n, err = u.Receive(buf)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if string(buf[:n]) != string(message) {
+			t.Errorf("expected %q got %q", message, buf[:n])
+		}
+	})
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"Corrupted payload", append([]byte("B"), framed[1:]...)},
+		{"Truncated packet", framed[:len(framed)-1]},
+		{"Shorter than checksum", framed[:2]},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := raw.Transmit(dst, tc.data); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			n, err := u.Receive(buf)
+			if !errors.Is(err, ErrChecksumMismatch) || n != 0 {
+				t.Errorf("expected ErrChecksumMismatch got %d, %v", n, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ChunkHeaderSize is the length of the header that prefixes every datagram
+// sent by TransmitAll, holding the big endian 16 bit sequence number of the
+// chunk followed by the total number of chunks.
+const ChunkHeaderSize = 4
+
+// maxChunks is the largest number of chunks a header can describe.
+const maxChunks = 1<<16 - 1
+
+// ErrIncomplete is returned by ReceiveAll when chunks of a payload were still
+// missing at the timeout.
+var ErrIncomplete = errors.New("incomplete payload")
+
+// TransmitAll sends data to addr split into datagrams carrying up to
+// chunkSize bytes each, prefixed with a ChunkHeaderSize byte header so that
+// ReceiveAll can reassemble it. A datagram must fit in MaxPacketSize and the
+// data in 65535 chunks. It returns the number of bytes of data sent, which is
+// short of len(data) when a chunk failed.
+func (u *UDPClient) TransmitAll(addr *net.UDPAddr, data []byte, chunkSize int) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to TransmitAll due to uninitialized client")
+		return
+	}
+
+	if len(data) == 0 || chunkSize <= 0 || ChunkHeaderSize+chunkSize > u.maxPacketSize() {
+		err = fmt.Errorf("parameter error in TransmitAll")
+		return
+	}
+
+	total := (len(data) + chunkSize - 1) / chunkSize
+	if total > maxChunks {
+		err = fmt.Errorf("parameter error in TransmitAll - %d chunks exceed %d", total, maxChunks)
+		return
+	}
+
+	msg := make([]byte, ChunkHeaderSize+chunkSize)
+	binary.BigEndian.PutUint16(msg[2:], uint16(total))
+	for seq := 0; seq < total; seq++ {
+		chunk := data[seq*chunkSize : min((seq+1)*chunkSize, len(data))]
+		binary.BigEndian.PutUint16(msg, uint16(seq))
+		copy(msg[ChunkHeaderSize:], chunk)
+		_, err = u.Transmit(addr, msg[:ChunkHeaderSize+len(chunk)])
+		if err != nil {
+			err = fmt.Errorf("failed to send chunk %d of %d in TransmitAll - %w", seq+1, total, err)
+			return
+		}
+		n += len(chunk)
+	}
+
+	return
+}
+
+// ReceiveAll reads the chunks of a payload sent by TransmitAll, in any order,
+// and returns the reassembled data along with its sender. The first chunk
+// received selects the sender, datagrams from others as well as duplicate
+// or malformed chunks are discarded. When chunks are still missing after
+// timeout it fails with ErrIncomplete.
+func (u *UDPClient) ReceiveAll(timeout time.Duration) (
+	data []byte,
+	from *net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveAll due to uninitialized client")
+		return
+	}
+
+	if timeout <= 0 {
+		err = fmt.Errorf("parameter error in ReceiveAll")
+		return
+	}
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+
+	var r reassembler
+	deadline := time.Now().Add(timeout)
+	for !r.complete() {
+		n, sender, rerr := u.receiveFrom(*bp, deadline)
+		addr := udpAddr(sender)
+		if IsTimeout(rerr) && r.total > 0 {
+			err = fmt.Errorf("failed to receive %d of %d chunks in ReceiveAll - %w",
+				r.total-r.have, r.total, ErrIncomplete)
+			return
+		}
+		if rerr != nil {
+			err = fmt.Errorf("failed to receive chunk in ReceiveAll - %w", rerr)
+			return
+		}
+		if from != nil && !sameUDPAddr(from, addr) {
+			continue
+		}
+		if r.add((*bp)[:n]) && from == nil {
+			from = addr
+		}
+	}
+
+	data = r.bytes()
+	return
+}
+
+// reassembler collects the chunks of a payload sent by TransmitAll.
+type reassembler struct {
+	total  int
+	have   int
+	size   int
+	chunks [][]byte
+}
+
+// add stores a copy of the chunk in datagram, reporting whether it was
+// accepted. Chunks that are malformed, duplicated or disagree with the total
+// of the earlier ones are rejected.
+func (r *reassembler) add(datagram []byte) bool {
+	if len(datagram) < ChunkHeaderSize {
+		return false
+	}
+	seq := int(binary.BigEndian.Uint16(datagram))
+	total := int(binary.BigEndian.Uint16(datagram[2:]))
+	if total == 0 || seq >= total || (r.total > 0 && total != r.total) {
+		return false
+	}
+
+	if r.total == 0 {
+		r.total = total
+		r.chunks = make([][]byte, total)
+	}
+	if r.chunks[seq] != nil {
+		return false
+	}
+
+	chunk := make([]byte, len(datagram)-ChunkHeaderSize)
+	copy(chunk, datagram[ChunkHeaderSize:])
+	r.chunks[seq] = chunk
+	r.have++
+	r.size += len(chunk)
+	return true
+}
+
+// complete reports whether all chunks arrived.
+func (r *reassembler) complete() bool {
+	return r.total > 0 && r.have == r.total
+}
+
+// bytes returns the chunks joined in sequence.
+func (r *reassembler) bytes() []byte {
+	data := make([]byte, 0, r.size)
+	for _, chunk := range r.chunks {
+		data = append(data, chunk...)
+	}
+	return data
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// chunkPair returns a sender and a receiver on the loopback.
+func chunkPair(t *testing.T) (tx, rx *UDPClient) {
+	t.Helper()
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	tx, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create sender -", err)
+	}
+	t.Cleanup(func() { tx.Close() })
+	rx, err = NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	t.Cleanup(func() { rx.Close() })
+	return tx, rx
+}
+
+// chunk returns the datagram TransmitAll sends for chunk seq of total.
+func chunk(seq, total int, data []byte) []byte {
+	b := make([]byte, ChunkHeaderSize, ChunkHeaderSize+len(data))
+	binary.BigEndian.PutUint16(b, uint16(seq))
+	binary.BigEndian.PutUint16(b[2:], uint16(total))
+	return append(b, data...)
+}
+
+func TestUDPClient_TransmitAll(t *testing.T) {
+	tx, rx := chunkPair(t)
+
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+	n, err := tx.TransmitAll(rx.LocalAddr().(*net.UDPAddr), payload, 64)
+	if err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if n != len(payload) {
+		t.Errorf("expected %d bytes sent got %d", len(payload), n)
+	}
+
+	data, from, err := rx.ReceiveAll(time.Second)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Errorf("expected the payload reassembled got %d bytes", len(data))
+	}
+	if from.String() != tx.LocalAddr().String() {
+		t.Errorf("expected sender %v got %v", tx.LocalAddr(), from)
+	}
+
+	if _, err = tx.TransmitAll(rx.LocalAddr().(*net.UDPAddr), payload, 0); err == nil {
+		t.Errorf("expected error for zero chunk size")
+	}
+	if _, err = tx.TransmitAll(rx.LocalAddr().(*net.UDPAddr), payload, MaxDatagramSize); err == nil {
+		t.Errorf("expected error for chunks larger than MaxPacketSize")
+	}
+}
+
+func TestUDPClient_ReceiveAll(t *testing.T) {
+	t.Run("Out of order", func(t *testing.T) {
+		tx, rx := chunkPair(t)
+		dst := rx.LocalAddr().(*net.UDPAddr)
+
+		parts := []string{"The early ", "bird catches ", "the worm"}
+		for _, seq := range []int{2, 0, 0, 1} {
+			if _, err := tx.Transmit(dst, chunk(seq, len(parts), []byte(parts[seq]))); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+		}
+
+		data, _, err := rx.ReceiveAll(time.Second)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if string(data) != "The early bird catches the worm" {
+			t.Errorf("expected the parts in sequence got %q", data)
+		}
+	})
+
+	t.Run("Dropped chunk", func(t *testing.T) {
+		tx, rx := chunkPair(t)
+		dst := rx.LocalAddr().(*net.UDPAddr)
+
+		for _, seq := range []int{0, 2} {
+			if _, err := tx.Transmit(dst, chunk(seq, 3, []byte("part"))); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+		}
+
+		_, _, err := rx.ReceiveAll(50 * time.Millisecond)
+		if !errors.Is(err, ErrIncomplete) {
+			t.Errorf("expected ErrIncomplete got %v", err)
+		}
+	})
+
+	t.Run("Nothing received", func(t *testing.T) {
+		_, rx := chunkPair(t)
+		_, _, err := rx.ReceiveAll(10 * time.Millisecond)
+		if !IsTimeout(err) || errors.Is(err, ErrIncomplete) {
+			t.Errorf("expected a plain timeout got %v", err)
+		}
+	})
+}
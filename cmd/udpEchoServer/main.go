@@ -8,13 +8,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
 	"path"
 	"regexp"
-	"strings"
 	"sync"
 
 	"github.com/boseji/udp"
@@ -23,48 +23,38 @@ import (
 // Synchronization
 var wg sync.WaitGroup
 
-func logIt(addr net.Addr, format string, params ...interface{}) {
-	s := fmt.Sprintf("%s - ", addr.String())
-	log.Printf(s+format, params...)
+// newLogger creates the application logger writing to w in the requested
+// format, either "text" or "json". It includes the debug records of the
+// datagrams sent and received by the client.
+func newLogger(format string, w io.Writer) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
 }
 
-func server(ctx context.Context, u *udp.UDPClient) {
+// server echoes the datagrams received by u, which logs its traffic and
+// errors to logger given by udp.WithSlog.
+func server(ctx context.Context, u *udp.UDPClient, logger *slog.Logger) {
 	defer wg.Done()
 
-	log.Println("Server Started on", u.LocalAddr().String())
-	buf := make([]byte, 2048)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			n, err := u.Receive(buf)
-			if err != nil {
-				// Timeouts are expected
-				if strings.Contains(err.Error(), "i/o timeout") {
-					continue
-				}
-				log.Println("Got error in receive - ", err)
-				return
-			}
-			logIt(u.RemoteAddr, "Received %d bytes - %q", n, string(buf[:n]))
-			addr, err := net.ResolveUDPAddr("udp", u.RemoteAddr.String())
-			if err != nil {
-				log.Println("Got error in coverting remote address for UDP -", err)
-				return
-			}
-			n, err = u.Transmit(addr, buf[:n])
-			if err != nil {
-				log.Println("Got error in transmit - ", err)
-				return
-			}
-			logIt(u.RemoteAddr, "Transmitted %d bytes", n)
-		}
+	logger.Info("server started", "local_addr", u.LocalAddr().String())
+	err := u.Serve(ctx, func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+		logger.Info("echo", "remote_addr", addr.String(), "data", string(data))
+		return data, nil
+	})
+	if err != nil {
+		logger.Error("receive failed", "err", err)
 	}
 }
 
 func main() {
 	var port int
+	var logFormat string
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "\nUsage of %s: \n", os.Args[0])
 		progName := path.Base(os.Args[0])
@@ -87,15 +77,26 @@ func main() {
 		fmt.Fprint(os.Stderr, "\n\n")
 	}
 	flag.IntVar(&port, "p", udp.LocalUDPport, "UDP Local Port range from 1024 to 65535")
+	flag.StringVar(&logFormat, "log-format", "text", "Log output format either text or json")
 	flag.Parse()
 
-	u, err := udp.NewUDPClient(&net.UDPAddr{Port: port})
+	logger, err := newLogger(logFormat, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid parameter -", err)
+		os.Exit(2)
+	}
+
+	u, err := udp.NewUDPClientWithOptions(
+		udp.WithLocalAddr(&net.UDPAddr{Port: port}),
+		udp.WithSlog(logger),
+	)
 	if err != nil {
-		log.Fatalln("Failed to open Client -", err)
+		logger.Error("failed to open client", "err", err)
+		os.Exit(1)
 	}
 	defer func() {
 		u.Close()
-		log.Println("UDP Server closed")
+		logger.Info("server closed")
 	}()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -106,7 +107,7 @@ func main() {
 
 	wg.Add(1)
 	// Server
-	go server(ctx, u)
+	go server(ctx, u, logger)
 
 	// Ctrl+C handler
 	go func() {
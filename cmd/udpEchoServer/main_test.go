@@ -0,0 +1,119 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boseji/udp"
+)
+
+// syncBuffer guards a bytes.Buffer shared between the server and the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Lines() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n"))
+}
+
+func TestNewLogger(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		if _, err := newLogger(format, &bytes.Buffer{}); err != nil {
+			t.Errorf("expected no error for %q got %v", format, err)
+		}
+	}
+	if _, err := newLogger("xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected Error for unknown format got nil")
+	}
+}
+
+func TestServer_JSONLog(t *testing.T) {
+	var out syncBuffer
+	logger, err := newLogger("json", &out)
+	if err != nil {
+		t.Fatal("failed to create logger -", err)
+	}
+
+	svr, err := udp.NewUDPClientWithOptions(
+		udp.WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		udp.WithSlog(logger),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer svr.Close()
+
+	client, err := udp.NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer client.Close()
+	client.ReadDeadline = time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(1)
+	go server(ctx, svr, logger)
+
+	message := "Knowledge speaks, wisdom listens"
+	_, err = client.Transmit(svr.LocalAddr().(*net.UDPAddr), []byte(message))
+	if err != nil {
+		t.Error("failed to transmit -", err)
+	}
+	buf := make([]byte, 2048)
+	_, err = client.Receive(buf)
+	if err != nil {
+		t.Error("failed to receive echo -", err)
+	}
+	cancel()
+	wg.Wait()
+
+	// The client logs the traffic, the server the echoed data
+	found := make(map[string]bool)
+	for _, line := range out.Lines() {
+		var event map[string]interface{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Errorf("invalid JSON log line %q - %v", line, err)
+			continue
+		}
+		msg, _ := event["msg"].(string)
+		switch msg {
+		case "received", "transmitted":
+			if event["bytes"] != float64(len(message)) {
+				t.Errorf("expected bytes %d in %s got %v", len(message), msg, event["bytes"])
+			}
+		case "echo":
+			if event["data"] != message {
+				t.Errorf("expected data %q got %v", message, event["data"])
+			}
+		default:
+			continue
+		}
+		found[msg] = true
+		if event["remote_addr"] != client.LocalAddr().String() {
+			t.Errorf("expected remote_addr %v in %s got %v", client.LocalAddr(), msg, event["remote_addr"])
+		}
+	}
+	for _, msg := range []string{"received", "transmitted", "echo"} {
+		if !found[msg] {
+			t.Errorf("expected a %s event in the log", msg)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrTooLarge is returned by TransmitGob when the encoded value does not
+// fit in a datagram of MaxPacketSize bytes.
+var ErrTooLarge = errors.New("encoded value too large for a datagram")
+
+// TransmitString sends s to addr as a single datagram, like Transmit.
+func (u *UDPClient) TransmitString(addr *net.UDPAddr, s string) (int, error) {
+	return u.Transmit(addr, []byte(s))
+}
+
+// ReceiveString reads a datagram of up to max bytes like ReceiveFrom and
+// returns it as a string along with its sender. A longer datagram is cut to
+// max bytes and fails with ErrTruncated.
+func (u *UDPClient) ReceiveString(max int) (string, *net.UDPAddr, error) {
+	if max <= 0 {
+		return "", nil, fmt.Errorf("parameter error in ReceiveString")
+	}
+
+	rb := make([]byte, max)
+	n, addr, err := u.ReceiveFrom(rb)
+	return string(rb[:n]), addr, err
+}
+
+// TransmitJSON sends the JSON encoding of v to addr as a single datagram.
+func (u *UDPClient) TransmitJSON(addr *net.UDPAddr, v any) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode JSON in TransmitJSON - %w", err)
+	}
+	return u.Transmit(addr, data)
+}
+
+// ReceiveJSON reads a datagram of up to max bytes like ReceiveFrom, decodes
+// it as JSON into v and returns its sender. A longer datagram fails with
+// ErrTruncated and one that is not valid JSON for v with the error of the
+// decoder.
+func (u *UDPClient) ReceiveJSON(v any, max int) (*net.UDPAddr, error) {
+	if v == nil || max <= 0 {
+		return nil, fmt.Errorf("parameter error in ReceiveJSON")
+	}
+
+	rb := make([]byte, max)
+	n, addr, err := u.ReceiveFrom(rb)
+	if errors.Is(err, ErrTruncated) {
+		return addr, fmt.Errorf("failed in ReceiveJSON - datagram from %v exceeds %d bytes - %w", addr, max, err)
+	}
+	if err != nil {
+		return addr, fmt.Errorf("failed to receive in ReceiveJSON - %w", err)
+	}
+
+	if err = json.Unmarshal(rb[:n], v); err != nil {
+		return addr, fmt.Errorf("failed to decode JSON of %d bytes from %v in ReceiveJSON - %w", n, addr, err)
+	}
+	return addr, nil
+}
+
+// TransmitGob sends the gob encoding of v to addr as a single datagram. Each
+// datagram carries its own type information so it can be decoded on its
+// own, which limits the encoded value, type included, to MaxPacketSize
+// bytes; a larger one fails with ErrTooLarge.
+func (u *UDPClient) TransmitGob(addr *net.UDPAddr, v any) (int, error) {
+	if u == nil || u.conn == nil {
+		return 0, fmt.Errorf("failed to TransmitGob due to uninitialized client")
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return 0, fmt.Errorf("failed to encode gob in TransmitGob - %w", err)
+	}
+	if buf.Len() > u.maxPacketSize() {
+		return 0, fmt.Errorf("failed in TransmitGob - %d bytes exceed %d - %w", buf.Len(), u.maxPacketSize(), ErrTooLarge)
+	}
+	return u.Transmit(addr, buf.Bytes())
+}
+
+// ReceiveGob reads a datagram sent by TransmitGob like ReceiveFrom, decodes
+// it into v and returns its sender.
+func (u *UDPClient) ReceiveGob(v any) (*net.UDPAddr, error) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to ReceiveGob due to uninitialized client")
+	}
+
+	if v == nil {
+		return nil, fmt.Errorf("parameter error in ReceiveGob")
+	}
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+
+	n, addr, err := u.ReceiveFrom(*bp)
+	if err != nil {
+		return addr, fmt.Errorf("failed to receive in ReceiveGob - %w", err)
+	}
+
+	if err = gob.NewDecoder(bytes.NewReader((*bp)[:n])).Decode(v); err != nil {
+		return addr, fmt.Errorf("failed to decode gob of %d bytes from %v in ReceiveGob - %w", n, addr, err)
+	}
+	return addr, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestUDPClient_TransmitString(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	const message = "Grüße, 世界 ✓"
+	n, err := u.TransmitString(dst, message)
+	if err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if n != len(message) {
+		t.Errorf("expected %d bytes sent got %d", len(message), n)
+	}
+
+	s, from, err := u.ReceiveString(maxBufferSize)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if s != message || from.String() != dst.String() {
+		t.Errorf("expected %q from %v got %q from %v", message, dst, s, from)
+	}
+
+	if _, err = u.TransmitString(dst, message); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, _, err = u.ReceiveString(4); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated got %v", err)
+	}
+	if _, _, err = u.ReceiveString(0); err == nil {
+		t.Errorf("expected error for zero max")
+	}
+}
+
+// reading is a message of the codec tests.
+type reading struct {
+	Sensor string            `json:"sensor"`
+	Values []float64         `json:"values"`
+	Tags   map[string]string `json:"tags"`
+	Origin struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	} `json:"origin"`
+}
+
+func TestUDPClient_TransmitJSON(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	want := reading{Sensor: "temp", Values: []float64{21.5, 22}, Tags: map[string]string{"room": "lab"}}
+	want.Origin.Host, want.Origin.Port = "probe-1", 9000
+	if _, err = u.TransmitJSON(dst, want); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	var got reading
+	from, err := u.ReceiveJSON(&got, maxBufferSize)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if !reflect.DeepEqual(got, want) || from.String() != dst.String() {
+		t.Errorf("expected %+v from %v got %+v from %v", want, dst, got, from)
+	}
+
+	if _, err = u.TransmitString(dst, `{"sensor": "temp",`); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	var syntaxErr *json.SyntaxError
+	if _, err = u.ReceiveJSON(&got, maxBufferSize); !errors.As(err, &syntaxErr) {
+		t.Errorf("expected a JSON syntax error got %v", err)
+	}
+
+	if _, err = u.TransmitJSON(dst, want); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, err = u.ReceiveJSON(&got, 8); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated got %v", err)
+	}
+
+	if _, err = u.TransmitJSON(dst, make(chan int)); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
+
+func TestUDPClient_TransmitGob(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	want := reading{Sensor: "humidity", Values: []float64{40, 41.25}, Tags: map[string]string{"floor": "2"}}
+	want.Origin.Host, want.Origin.Port = "probe-2", 9001
+	for i := 0; i < 2; i++ {
+		// Every datagram decodes on its own
+		if _, err = u.TransmitGob(dst, want); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		var got reading
+		from, err := u.ReceiveGob(&got)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if !reflect.DeepEqual(got, want) || from.String() != dst.String() {
+			t.Errorf("expected %+v from %v got %+v from %v", want, dst, got, from)
+		}
+	}
+
+	u.SetMaxPacketSize(64)
+	if _, err = u.TransmitGob(dst, want); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge got %v", err)
+	}
+}
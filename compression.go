@@ -0,0 +1,115 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression selects how the payload of datagrams is compressed.
+type Compression byte
+
+const (
+	// CompressionNone sends payloads as they are, without a marker.
+	CompressionNone Compression = iota
+
+	// CompressionGzip compresses payloads with gzip.
+	CompressionGzip
+)
+
+// DefaultCompressionThreshold is the payload size above which datagrams are
+// compressed unless WithCompressionThreshold sets another one.
+const DefaultCompressionThreshold = 128
+
+// WithCompression makes the client compress the payload of every datagram
+// larger than the compression threshold, prefixing each datagram with a
+// marker byte telling the receiver whether it is compressed. Smaller
+// payloads, and those that would not shrink, are sent raw. Like the
+// checksum it must be enabled on both ends.
+func WithCompression(c Compression) Option {
+	return func(cfg *config) error {
+		if c != CompressionNone && c != CompressionGzip {
+			return fmt.Errorf("parameter error in WithCompression")
+		}
+		cfg.compression = c
+		return nil
+	}
+}
+
+// WithCompressionThreshold sets the payload size in bytes above which
+// datagrams are compressed.
+func WithCompressionThreshold(n int) Option {
+	return func(c *config) error {
+		if n < 0 {
+			return fmt.Errorf("parameter error in WithCompressionThreshold")
+		}
+		c.compressionThreshold = n
+		return nil
+	}
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// compress returns data prefixed with the compression marker, compressed
+// when it is larger than threshold and that makes it smaller.
+func compress(c Compression, threshold int, data []byte) []byte {
+	if len(data) > threshold {
+		var buf bytes.Buffer
+		buf.WriteByte(byte(c))
+		zw := gzipWriters.Get().(*gzip.Writer)
+		zw.Reset(&buf)
+		_, err := zw.Write(data)
+		if err == nil {
+			err = zw.Close()
+		}
+		gzipWriters.Put(zw)
+		if err == nil && buf.Len() < 1+len(data) {
+			return buf.Bytes()
+		}
+	}
+
+	b := make([]byte, 1+len(data))
+	b[0] = byte(CompressionNone)
+	copy(b[1:], data)
+	return b
+}
+
+// decompress replaces the datagram held in the first n bytes of b by its
+// payload, returning the payload length. A payload that does not fit in b
+// fills it and fails with ErrTruncated.
+func decompress(b []byte, n int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("missing compression marker")
+	}
+
+	switch Compression(b[0]) {
+	case CompressionNone:
+		return copy(b, b[1:n]), nil
+	case CompressionGzip:
+	default:
+		return 0, fmt.Errorf("unknown compression marker %d", b[0])
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Clone(b[1:n])))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decompress - %w", err)
+	}
+	// Reading one byte past b tells a payload that does not fit
+	data, err := io.ReadAll(io.LimitReader(zr, int64(len(b))+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decompress - %w", err)
+	}
+	n = copy(b, data)
+	if n < len(data) {
+		return n, ErrTruncated
+	}
+	return n, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestWithCompression(t *testing.T) {
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithCompression(CompressionGzip),
+		WithChecksum(true),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+	buf := make([]byte, MaxDatagramSize)
+
+	cases := []struct {
+		name    string
+		payload []byte
+		shrinks bool
+	}{
+		{"Large repetitive", bytes.Repeat([]byte("All work and no play. "), 200), true},
+		{"Below threshold", []byte("Small is beautiful"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := u.Stats().BytesSent
+			if _, err := u.Transmit(dst, tc.payload); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			wire := int(u.Stats().BytesSent - before)
+			if tc.shrinks && wire >= len(tc.payload) {
+				t.Errorf("expected fewer than %d bytes on the wire got %d", len(tc.payload), wire)
+			}
+			if !tc.shrinks && wire != 1+len(tc.payload)+ChecksumSize {
+				t.Errorf("expected raw payload on the wire got %d bytes", wire)
+			}
+
+			n, err := u.Receive(buf)
+			if err != nil {
+				t.Fatal("failed to receive -", err)
+			}
+			if !bytes.Equal(buf[:n], tc.payload) {
+				t.Errorf("expected payload of %d bytes round trip got %d", len(tc.payload), n)
+			}
+		})
+	}
+
+	t.Run("Small buffer", func(t *testing.T) {
+		payload := bytes.Repeat([]byte{'x'}, 1000)
+		if _, err := u.Transmit(dst, payload); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err := u.Receive(make([]byte, 500)); !errors.Is(err, ErrTruncated) {
+			t.Errorf("expected ErrTruncated got %v", err)
+		}
+	})
+
+	if _, err = NewUDPClientWithOptions(WithCompression(Compression(7))); err == nil {
+		t.Errorf("expected error for unknown compression")
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"syscall"
+)
+
+// packetConn is the part of *net.UDPConn a client depends on, so that its
+// socket can be replaced by a MockConn in tests.
+type packetConn interface {
+	net.Conn
+	net.PacketConn
+	syscall.Conn
+
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error)
+	WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error)
+	SetReadBuffer(bytes int) error
+	SetWriteBuffer(bytes int) error
+}
+
+var _ packetConn = (*net.UDPConn)(nil)
@@ -0,0 +1,128 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net"
+	"sync"
+	"time"
+)
+
+// MaxDatagramSize is the largest payload a UDP datagram can carry.
+const MaxDatagramSize = 65535
+
+// Datagram is a single received UDP message along with its sender.
+type Datagram struct {
+	Data []byte
+	Addr *net.UDPAddr
+}
+
+// Datagrams returns an iterator over the datagrams received by the client.
+// Reads block without a deadline until the context is done or the client is
+// closed, both of which end the iteration without an error. A read error is
+// yielded once and also ends the iteration, while datagrams that are empty
+// or fail to decode are dropped. Each Datagram owns its Data.
+//
+//	for dg, err := range client.Datagrams(ctx) {
+//		...
+//	}
+func (u *UDPClient) Datagrams(ctx context.Context) iter.Seq2[Datagram, error] {
+	return func(yield func(Datagram, error) bool) {
+		if u == nil || u.conn == nil {
+			yield(Datagram{}, fmt.Errorf("failed to iterate Datagrams due to uninitialized client"))
+			return
+		}
+
+		err := u.applyReadDeadline(time.Time{})
+		if err != nil {
+			yield(Datagram{}, fmt.Errorf("failed in clearing read deadline in Datagrams - %w", err))
+			return
+		}
+
+		// Unblock the pending read once the context is done, the callback
+		// may still run after the iteration ended so it keeps its own conn.
+		conn := u.conn
+		stop := context.AfterFunc(ctx, func() {
+			t := time.Now()
+			if conn.SetReadDeadline(t) == nil {
+				u.readDeadline.Store(t.UnixNano())
+			}
+		})
+		defer stop()
+
+		rb := make([]byte, MaxDatagramSize)
+		for ctx.Err() == nil {
+			n, flags, addr, err := u.read(rb)
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+					return
+				}
+				yield(Datagram{}, fmt.Errorf("failed to read data in Datagrams - %w", err))
+				return
+			}
+			if n, err = u.process(rb, n, flags, addr); err != nil {
+				continue
+			}
+
+			data := make([]byte, n)
+			copy(data, rb[:n])
+			if !yield(Datagram{Data: data, Addr: udpAddr(addr)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReceiveChan delivers the datagrams received by the client on a channel
+// holding up to bufferSize of them, for use in select statements. Readers
+// goroutines read them like Datagrams until the context is done, the client
+// is closed or a read fails, in which case the error is sent on the error
+// channel and the other goroutines stop. Both channels are closed when the
+// goroutines end, so draining the datagram channel tells when they are gone.
+// With several goroutines the datagrams may be delivered out of order.
+func (u *UDPClient) ReceiveChan(ctx context.Context, bufferSize int) (<-chan Datagram, <-chan error) {
+	dgs := make(chan Datagram, max(bufferSize, 0))
+	errc := make(chan error, 1)
+
+	readers := 1
+	if u != nil {
+		readers = max(u.Readers, 1)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dg, err := range u.Datagrams(ctx) {
+				if err != nil {
+					select {
+					case errc <- err:
+					default:
+					}
+					cancel()
+					return
+				}
+				select {
+				case dgs <- dg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(dgs)
+		close(errc)
+	}()
+
+	return dgs, errc
+}
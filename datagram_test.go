@@ -0,0 +1,198 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_Datagrams(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	const count = 3
+	for i := 0; i < count; i++ {
+		if _, err = u.Transmit(dst, []byte(fmt.Sprint("message ", i))); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := 0
+	for dg, err := range u.Datagrams(ctx) {
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if want := fmt.Sprint("message ", received); string(dg.Data) != want {
+			t.Errorf("expected %q got %q", want, dg.Data)
+		}
+		if dg.Addr.Port != dst.Port {
+			t.Errorf("expected sender %v got %v", dst, dg.Addr)
+		}
+		received++
+		if received == count {
+			// The loop must end on its own once cancelled
+			cancel()
+		}
+	}
+	if received != count {
+		t.Errorf("expected %d datagrams got %d", count, received)
+	}
+	if ctx.Err() != context.Canceled {
+		t.Errorf("expected the loop to end by cancellation got %v", ctx.Err())
+	}
+}
+
+func TestUDPClient_Datagrams_Close(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, err := range u.Datagrams(context.Background()) {
+			if err != nil {
+				t.Error("unexpected error -", err)
+			}
+		}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	u.conn.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("expected the loop to end when the client closes")
+	}
+}
+
+func TestUDPClient_ReceiveChan(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dgs, errc := u.ReceiveChan(ctx, 4)
+
+	const count = 10
+	for i := 0; i < count; i++ {
+		if _, err = u.Transmit(dst, []byte(fmt.Sprint("message ", i))); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		select {
+		case dg := <-dgs:
+			if want := fmt.Sprint("message ", i); string(dg.Data) != want {
+				t.Errorf("expected %q got %q", want, dg.Data)
+			}
+		case err := <-errc:
+			t.Fatal("failed to receive -", err)
+		case <-ctx.Done():
+			t.Fatal("timed out after", i, "datagrams")
+		}
+	}
+
+	// Both channels are closed once the goroutine ends
+	cancel()
+	for range dgs {
+	}
+	if err, ok := <-errc; ok {
+		t.Errorf("expected the error channel closed got %v", err)
+	}
+
+	dgs, errc = (*UDPClient)(nil).ReceiveChan(context.Background(), 0)
+	if err := <-errc; err == nil {
+		t.Errorf("expected error for nil client")
+	}
+	if _, ok := <-dgs; ok {
+		t.Errorf("expected the datagram channel closed for nil client")
+	}
+}
+
+// slowReads takes delay for every read, as a costly receive path would.
+type slowReads struct {
+	packetConn
+	delay time.Duration
+}
+
+func (c *slowReads) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	time.Sleep(c.delay)
+	return c.packetConn.ReadMsgUDP(b, oob)
+}
+
+func (c *slowReads) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	time.Sleep(c.delay)
+	return c.packetConn.ReadFromUDP(b)
+}
+
+func TestWithReaders(t *testing.T) {
+	const count = 40
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+
+	// drain receives a burst with readers goroutines, returning how long it
+	// took
+	drain := func(readers int) time.Duration {
+		u, m := NewMockUDPClient()
+		defer u.Close()
+		u.conn = &slowReads{packetConn: m, delay: 2 * time.Millisecond}
+		u.Readers = readers
+		for i := 0; i < count; i++ {
+			m.Inject([]byte(fmt.Sprint(i)), peer)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		dgs, errc := u.ReceiveChan(ctx, count)
+		seen := make(map[string]bool)
+		for len(seen) < count {
+			select {
+			case dg := <-dgs:
+				seen[string(dg.Data)] = true
+			case err := <-errc:
+				t.Fatal("failed to receive -", err)
+			case <-ctx.Done():
+				t.Fatal("timed out after", len(seen), "datagrams")
+			}
+		}
+		elapsed := time.Since(start)
+
+		cancel()
+		for range dgs {
+		}
+		if err, ok := <-errc; ok {
+			t.Errorf("expected the error channel closed got %v", err)
+		}
+		return elapsed
+	}
+
+	one := drain(1)
+	four := drain(4)
+	if four >= one/2 {
+		t.Errorf("expected 4 readers to drain faster than one got %v and %v", four, one)
+	}
+
+	if _, err := NewUDPClientWithOptions(WithReaders(0)); err == nil {
+		t.Error("expected Error(no readers) got nil")
+	}
+}
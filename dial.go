@@ -0,0 +1,111 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// DialUDPClient creates a client connected to the remote address raddr from
+// an ephemeral local port. The kernel only delivers datagrams from raddr to
+// a connected client, use Send and Recv to talk to it. Transmit to an
+// arbitrary address fails on a connected client with net.ErrWriteToConnected.
+func DialUDPClient(raddr *net.UDPAddr) (*UDPClient, error) {
+	if raddr == nil {
+		return nil, fmt.Errorf("parameter error in DialUDPClient")
+	}
+
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial UDP in DialUDPClient - %w", err)
+	}
+
+	u, err := FromConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to adopt connection in DialUDPClient - %w", err)
+	}
+	u.RemoteAddr = u.dialed
+	return u, nil
+}
+
+// redial connects a new socket from laddr to raddr, for Reconnect to restore
+// a client created by DialUDPClient.
+func (u *UDPClient) redial(laddr, raddr *net.UDPAddr) (packetConn, error) {
+	network := u.network
+	if network == "" {
+		network = "udp"
+	}
+
+	conn, err := net.DialUDP(network, laddr, raddr)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Send transmits data to the remote address of a client created with
+// DialUDPClient.
+func (u *UDPClient) Send(data []byte) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to Send due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("Send", err) }()
+
+	if u.dialed == nil || (len(data) == 0 && !u.AllowEmptyDatagrams) {
+		err = fmt.Errorf("parameter error in Send")
+		return
+	}
+
+	if u.quiesced.Load() {
+		err = fmt.Errorf("failed to Send - %w", ErrQuiesced)
+		return
+	}
+
+	return u.writeDatagram("Send", u.dialed, data, time.Time{}, func(wire []byte) (int, error) {
+		return u.conn.Write(wire)
+	})
+}
+
+// Recv reads a datagram from the remote address of a client created with
+// DialUDPClient, datagrams from other senders are filtered by the kernel. A
+// datagram larger than rb fills it and fails with ErrTruncated.
+func (u *UDPClient) Recv(rb []byte) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to Recv due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("Recv", err) }()
+
+	if u.dialed == nil || len(rb) == 0 {
+		err = fmt.Errorf("parameter error in Recv")
+		return
+	}
+
+	err = u.applyReadDeadline(u.nextReadDeadline())
+	if err != nil {
+		err = fmt.Errorf("failed in setting read deadline in Recv - %w", err)
+		return
+	}
+
+	n, flags, from, err := u.read(rb)
+	if err != nil {
+		n = 0
+		err = fmt.Errorf("failed to read data in Recv - %w", err)
+		return
+	}
+
+	n, err = u.process(rb, n, flags, from)
+	return
+}
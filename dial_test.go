@@ -0,0 +1,136 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestDialUDPClient(t *testing.T) {
+	if _, err := DialUDPClient(nil); err == nil {
+		t.Error("expected Error(nil address) got nil")
+	}
+
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+
+	stranger, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create stranger -", err)
+	}
+	defer stranger.Close()
+
+	u, err := DialUDPClient(server.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal("failed to dial udp client -", err)
+	}
+	defer u.Close()
+
+	message := "Well begun is half done"
+	if _, err = u.Send([]byte(message)); err != nil {
+		t.Fatal("failed to send -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, err := server.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	// Datagrams from other senders are filtered out
+	if _, err = stranger.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("intruder")); err != nil {
+		t.Fatal("failed to transmit from stranger -", err)
+	}
+	if _, err = server.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Fatal("failed to transmit reply -", err)
+	}
+	n, err = u.Recv(buf)
+	if err != nil {
+		t.Fatal("failed to recv -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	// A reply larger than the buffer is reported like by Receive
+	if _, err = server.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Fatal("failed to transmit reply -", err)
+	}
+	if n, err = u.Recv(buf[:4]); n != 4 || !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected 4 bytes and ErrTruncated got %d and %v", n, err)
+	}
+
+	_, err = u.Transmit(stranger.LocalAddr().(*net.UDPAddr), []byte(message))
+	if !errors.Is(err, net.ErrWriteToConnected) {
+		t.Errorf("expected net.ErrWriteToConnected got %v", err)
+	}
+}
+
+func TestDialUDPClient_Reconnect(t *testing.T) {
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+
+	stranger, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create stranger -", err)
+	}
+	defer stranger.Close()
+
+	u, err := DialUDPClient(server.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal("failed to dial udp client -", err)
+	}
+	defer u.Close()
+
+	laddr := u.LocalAddr().String()
+	if err = u.Reconnect(); err != nil {
+		t.Fatal("failed to reconnect -", err)
+	}
+	if u.LocalAddr().String() != laddr {
+		t.Errorf("expected local address %v got %v", laddr, u.LocalAddr())
+	}
+	if u.RemoteAddr.String() != server.LocalAddr().String() {
+		t.Errorf("expected remote address %v got %v", server.LocalAddr(), u.RemoteAddr)
+	}
+
+	message := "Well begun is half done"
+	if _, err = u.Send([]byte(message)); err != nil {
+		t.Fatal("failed to send -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, err := server.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	// The socket is still connected, datagrams from other senders are
+	// filtered out
+	if _, err = stranger.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("intruder")); err != nil {
+		t.Fatal("failed to transmit from stranger -", err)
+	}
+	if _, err = server.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Fatal("failed to transmit reply -", err)
+	}
+	n, err = u.Recv(buf)
+	if err != nil {
+		t.Fatal("failed to recv -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// ErrPacketTooLarge is returned by Transmit when the datagram exceeds the
+// MTU known for the path while the don't fragment bit is set.
+var ErrPacketTooLarge = errors.New("packet too large for the path MTU")
+
+// SetDontFragment sets or clears the don't fragment bit of all following
+// datagrams. With the bit set, datagrams larger than the MTU of the path are
+// refused with ErrPacketTooLarge instead of being fragmented, as wanted for
+// MTU probing or real-time media where a lost fragment loses the whole
+// datagram. With it cleared, datagrams are fragmented as needed. The x/net
+// packages do not cover the option, so it is set directly on the socket,
+// for both families on an IPv6 socket. It is supported on Linux only.
+func (u *UDPClient) SetDontFragment(on bool) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetDontFragment due to uninitialized client")
+	}
+
+	rc, err := u.conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to access socket in SetDontFragment - %w", err)
+	}
+
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		serr = setDontFragment(fd, u.isIPv6(), on)
+	})
+	if err == nil {
+		err = serr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set don't fragment in SetDontFragment - %w", err)
+	}
+
+	return nil
+}
+
+// packetTooLarge marks the EMSGSIZE of a write as ErrPacketTooLarge.
+func packetTooLarge(err error) error {
+	if errors.Is(err, syscall.EMSGSIZE) {
+		return fmt.Errorf("%w - %w", ErrPacketTooLarge, err)
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import "syscall"
+
+// setDontFragment selects the path MTU discovery mode of fd, DO setting the
+// don't fragment bit and DONT clearing it. The IPv4 option also applies to
+// the IPv4 destinations of an IPv6 socket.
+func setDontFragment(fd uintptr, ipv6, on bool) error {
+	mode := syscall.IP_PMTUDISC_DONT
+	if on {
+		mode = syscall.IP_PMTUDISC_DO
+	}
+
+	if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, mode); err != nil {
+		return err
+	}
+	if ipv6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, mode)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestUDPClient_SetDontFragment(t *testing.T) {
+	u, err := NewUDPClientWithOptions(WithNetwork("udp4"), WithLocalAddr(&net.UDPAddr{IP: net.IPv4zero}))
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	// The loopback MTU exceeds any datagram, so the packet has to leave
+	// through a real interface. Nothing listens on the discard port of
+	// this documentation address.
+	dst := &net.UDPAddr{IP: net.IPv4(198, 51, 100, 1), Port: 9}
+	mtu, err := routeMTU(dst)
+	if err != nil || mtu >= 65535 {
+		t.Skip("no route with a limited MTU -", err)
+	}
+	oversized := make([]byte, mtu)
+
+	if err = u.SetDontFragment(true); err != nil {
+		t.Fatal("failed to set don't fragment -", err)
+	}
+	if _, err = u.Transmit(dst, oversized); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("expected ErrPacketTooLarge got %v", err)
+	}
+	if _, err = u.Transmit(dst, oversized[:mtu-28]); err != nil {
+		t.Error("failed to transmit a datagram fitting the MTU -", err)
+	}
+
+	// Cleared, the datagram is fragmented
+	if err = u.SetDontFragment(false); err != nil {
+		t.Fatal("failed to clear don't fragment -", err)
+	}
+	if _, err = u.Transmit(dst, oversized); err != nil {
+		t.Error("failed to transmit a fragmented datagram -", err)
+	}
+
+	// Both families of an IPv6 socket are covered
+	dual, err := NewUDPClient(&net.UDPAddr{IP: net.IPv6unspecified})
+	if err != nil {
+		t.Fatal("failed to create dual stack client -", err)
+	}
+	defer dual.Close()
+	if err = dual.SetDontFragment(true); err != nil {
+		t.Fatal("failed to set don't fragment on IPv6 socket -", err)
+	}
+	if _, err = dual.Transmit(dst, oversized); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("expected ErrPacketTooLarge for IPv4 destination got %v", err)
+	}
+
+	var nilClient *UDPClient
+	if err = nilClient.SetDontFragment(true); err == nil {
+		t.Errorf("expected error for nil client")
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import "fmt"
+
+// setDontFragment reports that the don't fragment bit is unsupported.
+func setDontFragment(fd uintptr, ipv6, on bool) error {
+	return fmt.Errorf("don't fragment is only supported on linux")
+}
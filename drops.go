@@ -0,0 +1,16 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+// KernelDrops returns the number of datagrams the kernel dropped on the
+// socket because its receive buffer was full, as last reported with a
+// received datagram (SO_RXQ_OVFL). It stays zero unless DropCounter is
+// enabled, and on platforms other than Linux.
+func (u *UDPClient) KernelDrops() uint32 {
+	if u == nil {
+		return 0
+	}
+	return u.kernelDrops.Load()
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// dropCounterOOBSize is the control message buffer needed for SO_RXQ_OVFL.
+var dropCounterOOBSize = syscall.CmsgSpace(4)
+
+// enableDropCounter asks the kernel to attach the receive queue overflow
+// count to every received datagram.
+func enableDropCounter(conn packetConn) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RXQ_OVFL, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return serr
+}
+
+// parseDropCount extracts the SO_RXQ_OVFL count from control messages.
+func parseDropCount(oob []byte) (uint32, bool) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0, false
+	}
+	for _, m := range msgs {
+		if m.Header.Level == syscall.SOL_SOCKET && m.Header.Type == syscall.SO_RXQ_OVFL &&
+			len(m.Data) >= 4 {
+			return binary.NativeEndian.Uint32(m.Data), true
+		}
+	}
+	return 0, false
+}
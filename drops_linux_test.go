@@ -0,0 +1,74 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestUDPClient_KernelDrops(t *testing.T) {
+	receivers := map[string]func(u *UDPClient, buf []byte) error{
+		"Receive": func(u *UDPClient, buf []byte) error {
+			_, err := u.Receive(buf)
+			return err
+		},
+		"ReceiveBatch": func(u *UDPClient, buf []byte) error {
+			_, err := u.ReceiveBatch([][]byte{buf})
+			return err
+		},
+	}
+	for name, receive := range receivers {
+		t.Run(name, func(t *testing.T) {
+			u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			if err != nil {
+				t.Fatal("failed to create udp client -", err)
+			}
+			defer u.Close()
+			u.DropCounter = true
+			if err = u.conn.SetReadBuffer(1); err != nil {
+				t.Fatal("failed to shrink receive buffer -", err)
+			}
+
+			sender, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			if err != nil {
+				t.Fatal("failed to create sender -", err)
+			}
+			defer sender.Close()
+			dst := u.LocalAddr().(*net.UDPAddr)
+
+			// Enable the counter before the flood
+			buf := make([]byte, maxBufferSize)
+			_ = receive(u, buf)
+
+			payload := make([]byte, 512)
+			for i := 0; i < 200; i++ {
+				if _, err = sender.Transmit(dst, payload); err != nil {
+					t.Fatal("failed to flood -", err)
+				}
+			}
+			for {
+				if err = receive(u, buf); err != nil {
+					break
+				}
+			}
+
+			// The next datagram carries the total count
+			if _, err = sender.Transmit(dst, payload); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			if err = receive(u, buf); err != nil {
+				t.Fatal("failed to receive -", err)
+			}
+			if u.KernelDrops() == 0 {
+				t.Error("expected kernel drops after flooding a small buffer")
+			}
+			if got := u.Stats().KernelDrops; got != uint64(u.KernelDrops()) {
+				t.Errorf("expected %d kernel drops in Stats got %d", u.KernelDrops(), got)
+			}
+			t.Log("kernel drops:", u.KernelDrops())
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import "fmt"
+
+// dropCounterOOBSize is zero as there are no drop control messages.
+var dropCounterOOBSize = 0
+
+// enableDropCounter reports that kernel drop counting is unsupported.
+func enableDropCounter(conn packetConn) error {
+	return fmt.Errorf("kernel drop counter is only supported on linux")
+}
+
+// parseDropCount never finds a drop count.
+func parseDropCount(oob []byte) (uint32, bool) {
+	return 0, false
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// SetDSCP marks all following datagrams with the Differentiated Services
+// code point dscp, from 0 to 63, for instance 46 (Expedited Forwarding) for
+// real-time audio. It sets the upper six bits of the IP ToS field, or of the
+// traffic class for IPv6 sockets, and clears the ECN bits.
+//
+// No privileges are needed on Linux and the BSDs, although routers along the
+// path may ignore or rewrite the marking. Windows does not support it and
+// returns an error.
+func (u *UDPClient) SetDSCP(dscp int) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetDSCP due to uninitialized client")
+	}
+
+	if dscp < 0 || dscp > 63 {
+		return fmt.Errorf("parameter error in SetDSCP - %d not within 0 to 63", dscp)
+	}
+
+	var err error
+	if u.isIPv6() {
+		err = ipv6.NewPacketConn(u.conn).SetTrafficClass(dscp << 2)
+	} else {
+		err = ipv4.NewPacketConn(u.conn).SetTOS(dscp << 2)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set DSCP in SetDSCP - %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func TestUDPClient_SetDSCP(t *testing.T) {
+	for _, laddr := range []*net.UDPAddr{
+		{IP: net.IPv4(127, 0, 0, 1)},
+		{IP: net.IPv6loopback},
+	} {
+		t.Run(laddr.String(), func(t *testing.T) {
+			u, err := NewUDPClient(laddr)
+			if err != nil {
+				t.Skip("loopback unavailable -", err)
+			}
+			defer u.Close()
+
+			const ef = 46
+			if err = u.SetDSCP(ef); err != nil {
+				t.Skip("DSCP unsupported -", err)
+			}
+			var tos int
+			if u.isIPv6() {
+				tos, err = ipv6.NewPacketConn(u.conn).TrafficClass()
+			} else {
+				tos, err = ipv4.NewPacketConn(u.conn).TOS()
+			}
+			if err != nil {
+				t.Fatal("failed to read back ToS -", err)
+			}
+			if tos != ef<<2 {
+				t.Errorf("expected ToS %#x got %#x", ef<<2, tos)
+			}
+
+			if _, err = u.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("marked")); err != nil {
+				t.Error("failed to transmit marked datagram -", err)
+			}
+
+			for _, dscp := range []int{-1, 64} {
+				if err = u.SetDSCP(dscp); err == nil {
+					t.Errorf("expected Error for DSCP %d got nil", dscp)
+				}
+			}
+		})
+	}
+}
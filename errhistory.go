@@ -0,0 +1,77 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"sync"
+	"time"
+)
+
+// TimestampedError is an error recorded by the client along with when it
+// happened and the operation that produced it.
+type TimestampedError struct {
+	Time time.Time
+	Op   string
+	Err  error
+}
+
+// errorRing keeps the most recent errors in a fixed size ring buffer.
+type errorRing struct {
+	mu      sync.Mutex
+	entries []TimestampedError
+	next    int
+	full    bool
+}
+
+// record stores err, overwriting the oldest entry once size is reached.
+// The size is fixed by the first recorded error.
+func (r *errorRing) record(size int, op string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries == nil {
+		r.entries = make([]TimestampedError, size)
+	}
+	r.entries[r.next] = TimestampedError{Time: time.Now(), Op: op, Err: err}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the recorded errors from the oldest to the newest.
+func (r *errorRing) list() []TimestampedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]TimestampedError(nil), r.entries[:r.next]...)
+	}
+	out := make([]TimestampedError, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// recordError counts err for Stats and keeps it in the error history when
+// enabled.
+func (u *UDPClient) recordError(op string, err error) {
+	if err == nil {
+		return
+	}
+	u.stats.errors.Add(1)
+	u.logError(op, err)
+	if u.ErrorHistory > 0 {
+		u.errHistory.record(u.ErrorHistory, op, err)
+	}
+}
+
+// RecentErrors returns up to ErrorHistory of the most recent errors returned
+// by Transmit and Receive, from the oldest to the newest.
+func (u *UDPClient) RecentErrors() []TimestampedError {
+	if u == nil {
+		return nil
+	}
+	return u.errHistory.list()
+}
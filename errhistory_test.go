@@ -0,0 +1,52 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestUDPClient_RecentErrors(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	if errs := u.RecentErrors(); len(errs) != 0 {
+		t.Errorf("expected no errors got %v", errs)
+	}
+
+	u.ErrorHistory = 3
+	dst := u.LocalAddr().(*net.UDPAddr)
+	_, _ = u.Transmit(nil, []byte("testing"))                                // 1
+	_, _ = u.Receive(nil)                                                    // 2
+	_, _ = u.Transmit(&net.UDPAddr{IP: net.IPv6loopback}, []byte("testing")) // 3
+	u.Quiesce()
+	_, _ = u.Transmit(dst, []byte("testing")) // 4
+	u.Unquiesce()
+	_, _ = u.Receive(make([]byte, maxBufferSize)) // 5, timeout
+
+	errs := u.RecentErrors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors got %d", len(errs))
+	}
+	if errs[0].Op != "Transmit" || !errors.Is(errs[0].Err, ErrAddressFamilyMismatch) {
+		t.Errorf("expected the 3rd error first got %v %v", errs[0].Op, errs[0].Err)
+	}
+	if errs[1].Op != "Transmit" || !errors.Is(errs[1].Err, ErrQuiesced) {
+		t.Errorf("expected the 4th error second got %v %v", errs[1].Op, errs[1].Err)
+	}
+	if errs[2].Op != "Receive" {
+		t.Errorf("expected the 5th error last got %v %v", errs[2].Op, errs[2].Err)
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i].Time.Before(errs[i-1].Time) {
+			t.Error("expected errors in chronological order")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ReceiveExact reads one datagram into rb like Receive and also reports
+// whether it was truncated, which a datagram of exactly len(rb) bytes
+// otherwise leaves ambiguous. The kernel MSG_TRUNC flag is used where the
+// platform provides it, elsewhere the datagram is read into a scratch buffer
+// one byte larger than rb. It returns the sender without altering RemoteAddr.
+// A truncated datagram is returned as read, without removing the framing
+// added by WithChecksum or WithCompression, which cannot be verified.
+func (u *UDPClient) ReceiveExact(rb []byte) (
+	n int,
+	truncated bool,
+	addr *net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveExact due to uninitialized client")
+		return
+	}
+
+	if len(rb) == 0 {
+		err = fmt.Errorf("parameter error in ReceiveExact")
+		return
+	}
+
+	err = u.applyReadDeadline(u.nextReadDeadline())
+	if err != nil {
+		err = fmt.Errorf("failed in setting read deadline in ReceiveExact - %w", err)
+		return
+	}
+
+	buf := rb
+	if msgTrunc == 0 {
+		buf = make([]byte, len(rb)+1)
+	}
+
+	var flags int
+	var from net.Addr
+	n, flags, from, err = u.read(buf)
+	if err != nil {
+		err = fmt.Errorf("failed to read data in ReceiveExact - %w", err)
+		return
+	}
+	addr = udpAddr(from)
+
+	n, err = u.process(buf, n, flags, from)
+	if msgTrunc == 0 {
+		truncated = n > len(rb)
+		n = copy(rb, buf[:n])
+	}
+	if errors.Is(err, ErrTruncated) {
+		truncated, err = true, nil
+	}
+
+	return
+}
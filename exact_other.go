@@ -0,0 +1,10 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package udp
+
+// msgTrunc is zero where truncation is not reported by the platform.
+const msgTrunc = 0
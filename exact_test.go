@@ -0,0 +1,56 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestUDPClient_ReceiveExact(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	const size = 16
+	for _, tc := range []struct {
+		name      string
+		length    int
+		truncated bool
+	}{
+		{"Shorter", size - 1, false},
+		{"Exact fit", size, false},
+		{"One byte over", size + 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'x'}, tc.length)
+			if _, err := u.Transmit(dst, data); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			rb := make([]byte, size)
+			n, truncated, addr, err := u.ReceiveExact(rb)
+			if err != nil {
+				t.Fatal("failed to receive -", err)
+			}
+			if truncated != tc.truncated {
+				t.Errorf("expected truncated %v got %v", tc.truncated, truncated)
+			}
+			if want := min(tc.length, size); n != want {
+				t.Errorf("expected %d bytes got %d", want, n)
+			}
+			if addr.Port != dst.Port {
+				t.Errorf("expected sender %v got %v", dst, addr)
+			}
+		})
+	}
+
+	if _, _, _, err = u.ReceiveExact(nil); err == nil {
+		t.Error("expected Error(empty buffer) got nil")
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package udp
+
+import "syscall"
+
+// msgTrunc is the receive flag marking a truncated datagram.
+const msgTrunc = syscall.MSG_TRUNC
@@ -0,0 +1,49 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrNoPeerAvailable is returned by TransmitFailover when none of the
+// addresses accepted the data.
+var ErrNoPeerAvailable = errors.New("no peer available")
+
+// TransmitFailover sends data to the first address of addrs, moving on to the
+// next one whenever the transmission fails. If verify is not nil it is called
+// after each successful transmission, for example to wait for an
+// acknowledgement, and a false result also moves on to the next address.
+// It returns the address that succeeded.
+func (u *UDPClient) TransmitFailover(addrs []*net.UDPAddr, data []byte, verify func() bool) (
+	*net.UDPAddr,
+	error,
+) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to TransmitFailover due to uninitialized client")
+	}
+
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("parameter error in TransmitFailover")
+	}
+
+	errs := []error{ErrNoPeerAvailable}
+	for _, addr := range addrs {
+		_, err := u.Transmit(addr, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if verify != nil && !verify() {
+			errs = append(errs, fmt.Errorf("verification failed for %v", addr))
+			continue
+		}
+		return addr, nil
+	}
+
+	return nil, fmt.Errorf("failed in TransmitFailover - %w", errors.Join(errs...))
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_TransmitFailover(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+	// A closed client leaves behind an address nobody listens on
+	dead, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	deadAddr := dead.LocalAddr().(*net.UDPAddr)
+	dead.Close()
+
+	peer, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+	peer.ReadDeadline = time.Second
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		n, err := peer.Receive(buf)
+		if err != nil {
+			t.Error("failed to receive in peer -", err)
+			return
+		}
+		_, err = peer.Transmit(peer.RemoteAddr.(*net.UDPAddr), buf[:n])
+		if err != nil {
+			t.Error("failed to reply from peer -", err)
+		}
+	}()
+
+	u, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.ReadDeadline = 200 * time.Millisecond
+
+	verified := 0
+	verify := func() bool {
+		verified++
+		_, err := u.Receive(make([]byte, maxBufferSize))
+		return err == nil
+	}
+
+	got, err := u.TransmitFailover([]*net.UDPAddr{deadAddr, peer.LocalAddr().(*net.UDPAddr)},
+		[]byte("testing"), verify)
+	if err != nil {
+		t.Fatal("failed to transmit with failover -", err)
+	}
+	if got != peer.LocalAddr().(*net.UDPAddr) {
+		t.Errorf("expected %v got %v", peer.LocalAddr(), got)
+	}
+	if verified != 2 {
+		t.Errorf("expected 2 verifications got %d", verified)
+	}
+
+	_, err = u.TransmitFailover([]*net.UDPAddr{deadAddr}, []byte("testing"), func() bool { return false })
+	if !errors.Is(err, ErrNoPeerAvailable) {
+		t.Errorf("expected ErrNoPeerAvailable got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+// Feature identifies an optional socket level capability whose availability
+// depends on the platform the client runs on.
+type Feature int
+
+const (
+	// FeatureRecvTimestamp represents kernel receive timestamps on datagrams.
+	FeatureRecvTimestamp Feature = iota + 1
+
+	// FeaturePacketInfo represents delivery of the destination address and
+	// interface of received datagrams (IP_PKTINFO / IPV6_RECVPKTINFO).
+	FeaturePacketInfo
+
+	// FeatureRecvErr represents reception of extended ICMP errors on the
+	// socket error queue (IP_RECVERR / IPV6_RECVERR).
+	FeatureRecvErr
+)
+
+// Supports reports if the given feature is available on the socket of the
+// client. The capabilities are probed once while the client is created.
+// It returns false for unknown features, unsupported platforms and clients
+// that are not active.
+func (u *UDPClient) Supports(feature Feature) bool {
+	if u == nil || u.conn == nil {
+		return false
+	}
+	return u.features[feature]
+}
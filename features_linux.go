@@ -0,0 +1,49 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"syscall"
+)
+
+// sockOpt describes a socket option by its level and name.
+type sockOpt struct {
+	level int
+	name  int
+}
+
+// probeFeatures checks each known feature by reading the relevant socket
+// option and writing the same value back, leaving the socket unchanged.
+func probeFeatures(conn packetConn) map[Feature]bool {
+	features := make(map[Feature]bool)
+
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return features
+	}
+
+	opts := map[Feature]sockOpt{
+		FeatureRecvTimestamp: {syscall.SOL_SOCKET, syscall.SO_TIMESTAMPNS},
+		FeaturePacketInfo:    {syscall.IPPROTO_IP, syscall.IP_PKTINFO},
+		FeatureRecvErr:       {syscall.IPPROTO_IP, syscall.IP_RECVERR},
+	}
+	if local, ok := conn.LocalAddr().(*net.UDPAddr); ok && local.IP.To4() == nil {
+		opts[FeaturePacketInfo] = sockOpt{syscall.IPPROTO_IPV6, syscall.IPV6_RECVPKTINFO}
+		opts[FeatureRecvErr] = sockOpt{syscall.IPPROTO_IPV6, syscall.IPV6_RECVERR}
+	}
+
+	_ = rc.Control(func(fd uintptr) {
+		for f, o := range opts {
+			v, err := syscall.GetsockoptInt(int(fd), o.level, o.name)
+			if err != nil {
+				continue
+			}
+			features[f] = syscall.SetsockoptInt(int(fd), o.level, o.name, v) == nil
+		}
+	})
+
+	return features
+}
@@ -0,0 +1,13 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+// probeFeatures reports no optional features on platforms where probing is
+// not implemented.
+func probeFeatures(conn packetConn) map[Feature]bool {
+	return make(map[Feature]bool)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestUDPClient_Supports(t *testing.T) {
+	t.Run("Inactive UDPClient", func(t *testing.T) {
+		var u *UDPClient
+		if u.Supports(FeatureRecvTimestamp) {
+			t.Error("expected false for nil client")
+		}
+		if (&UDPClient{}).Supports(FeatureRecvTimestamp) {
+			t.Error("expected false for uninitialized client")
+		}
+	})
+
+	for _, laddr := range []*net.UDPAddr{
+		{IP: net.IPv4(127, 0, 0, 1)},
+		{IP: net.IPv6loopback},
+	} {
+		t.Run(laddr.String(), func(t *testing.T) {
+			u, err := NewUDPClient(laddr)
+			if err != nil {
+				t.Skip("loopback unavailable -", err)
+			}
+			defer u.Close()
+
+			if u.Supports(Feature(0)) {
+				t.Error("expected false for unknown feature")
+			}
+			for _, f := range []Feature{FeatureRecvTimestamp, FeaturePacketInfo, FeatureRecvErr} {
+				got := u.Supports(f)
+				t.Logf("feature %d supported: %v", f, got)
+				if runtime.GOOS == "linux" && !got {
+					t.Errorf("expected feature %d to be supported on linux", f)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+// encode returns data framed for the wire as configured by the options of
+// the client. The payload is compressed first so that the checksum covers
+// the datagram as sent.
+func (u *UDPClient) encode(data []byte) []byte {
+	if u.compression != CompressionNone {
+		data = compress(u.compression, u.compressionThreshold, data)
+	}
+	if u.checksum {
+		data = appendChecksum(data)
+	}
+	return data
+}
+
+// decode replaces the datagram held in the first n bytes of b by its
+// payload, undoing the framing added by encode, and returns the length of
+// the payload.
+func (u *UDPClient) decode(b []byte, n int) (int, error) {
+	var err error
+	if u.checksum {
+		n, err = verifyChecksum(b[:n])
+		if err != nil {
+			return 0, err
+		}
+	}
+	if u.compression != CompressionNone {
+		n, err = decompress(b, n)
+	}
+	return n, err
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// framedPair creates two loopback clients sharing the framing options opts.
+func framedPair(t *testing.T, opts ...Option) (a, b *UDPClient) {
+	t.Helper()
+	opts = append([]Option{WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})}, opts...)
+	a, err := NewUDPClientWithOptions(opts...)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err = NewUDPClientWithOptions(opts...)
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return a, b
+}
+
+// receivePayload receives a datagram on u and compares it to want.
+func receivePayload(t *testing.T, u *UDPClient, want []byte) {
+	t.Helper()
+	buf := make([]byte, MaxDatagramSize)
+	n, err := u.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if !bytes.Equal(buf[:n], want) {
+		t.Errorf("expected %q got %q", want, buf[:n])
+	}
+}
+
+// TestFraming checks that every transmit and receive API applies the framing
+// configured by the options, so that payloads round trip unchanged.
+func TestFraming(t *testing.T) {
+	payload := bytes.Repeat([]byte("Every cloud has a silver lining. "), 8)
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Checksum", []Option{WithChecksum(true)}},
+		{"Compression", []Option{WithCompression(CompressionGzip), WithCompressionThreshold(0)}},
+		{"Checksum and compression", []Option{WithChecksum(true), WithCompression(CompressionGzip)}},
+	} {
+		t.Run(tc.name+"/TransmitMultiConcurrent", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			dst := peer.LocalAddr().(*net.UDPAddr)
+			results, err := u.TransmitMultiConcurrent([]*net.UDPAddr{dst}, payload, 1, time.Second)
+			if err != nil || results[0].Err != nil {
+				t.Fatal("failed to transmit -", err, results)
+			}
+			if results[0].N != len(payload) {
+				t.Errorf("expected %d bytes transmitted got %d", len(payload), results[0].N)
+			}
+			receivePayload(t, peer, payload)
+		})
+
+		t.Run(tc.name+"/TransmitWithTTL", func(t *testing.T) {
+			if runtime.GOOS != "linux" {
+				t.Skip("per datagram TTL is only supported on linux")
+			}
+			u, peer := framedPair(t, tc.opts...)
+			n, err := u.TransmitWithTTL(peer.LocalAddr().(*net.UDPAddr), payload, 8)
+			if err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			if n != len(payload) {
+				t.Errorf("expected %d bytes transmitted got %d", len(payload), n)
+			}
+			receivePayload(t, peer, payload)
+		})
+
+		t.Run(tc.name+"/Datagrams", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			if _, err := u.Transmit(peer.LocalAddr().(*net.UDPAddr), payload); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			for dg, err := range peer.Datagrams(ctx) {
+				if err != nil {
+					t.Fatal("failed to receive -", err)
+				}
+				if !bytes.Equal(dg.Data, payload) {
+					t.Errorf("expected %q got %q", payload, dg.Data)
+				}
+				break
+			}
+		})
+
+		t.Run(tc.name+"/ReceiveChan", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			dgs, errc := peer.ReceiveChan(ctx, 1)
+			if _, err := u.Transmit(peer.LocalAddr().(*net.UDPAddr), payload); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			select {
+			case dg := <-dgs:
+				if !bytes.Equal(dg.Data, payload) {
+					t.Errorf("expected %q got %q", payload, dg.Data)
+				}
+			case err := <-errc:
+				t.Fatal("failed to receive -", err)
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for the datagram")
+			}
+		})
+
+		t.Run(tc.name+"/ReceiveExact", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			if _, err := u.Transmit(peer.LocalAddr().(*net.UDPAddr), payload); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			rb := make([]byte, maxBufferSize)
+			n, truncated, _, err := peer.ReceiveExact(rb)
+			if err != nil || truncated {
+				t.Fatal("failed to receive -", err, truncated)
+			}
+			if !bytes.Equal(rb[:n], payload) {
+				t.Errorf("expected %q got %q", payload, rb[:n])
+			}
+		})
+
+		t.Run(tc.name+"/TransmitAndAwaitAcks", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			peer.ReadDeadline = time.Second
+			go func() {
+				buf := make([]byte, maxBufferSize)
+				n, err := peer.Receive(buf)
+				if err == nil {
+					_ = peer.Acknowledge(peer.RemoteAddr.(*net.UDPAddr), buf[:n])
+				}
+			}()
+			dst := peer.LocalAddr().(*net.UDPAddr)
+			acked, _, err := u.TransmitAndAwaitAcks([]*net.UDPAddr{dst}, payload, time.Second)
+			if err != nil {
+				t.Fatal("failed to transmit and await acks -", err)
+			}
+			if len(acked) != 1 {
+				t.Errorf("expected %v acked got %v", dst, acked)
+			}
+		})
+
+		t.Run(tc.name+"/PingN", func(t *testing.T) {
+			u, peer := framedPair(t, tc.opts...)
+			peer.ReadDeadline = time.Second
+			go func() {
+				buf := make([]byte, maxBufferSize)
+				for i := 0; i < 2; i++ {
+					n, addr, err := peer.ReceiveFrom(buf)
+					if err != nil {
+						return
+					}
+					_, _ = peer.Transmit(addr, buf[:n])
+				}
+			}()
+			stats, err := u.PingN(context.Background(), peer.LocalAddr().(*net.UDPAddr),
+				payload, 2, 200*time.Millisecond)
+			if err != nil {
+				t.Fatal("failed to ping -", err)
+			}
+			if stats.Received != 2 {
+				t.Errorf("expected 2 replies got %d", stats.Received)
+			}
+		})
+	}
+}
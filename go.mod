@@ -1,3 +1,9 @@
 module github.com/boseji/udp
 
-go 1.16
+go 1.23.0
+
+require (
+	golang.org/x/net v0.43.0
+	golang.org/x/sys v0.35.0
+	golang.org/x/time v0.12.0
+)
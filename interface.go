@@ -0,0 +1,90 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// BoundInterface returns the network interface the socket is bound to, nil
+// when it is not bound to a single interface. On Linux the SO_BINDTODEVICE
+// setting is reported, elsewhere the interface owning the local address.
+func (u *UDPClient) BoundInterface() (*net.Interface, error) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to get BoundInterface due to uninitialized client")
+	}
+
+	name, err := boundDevice(u.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bound device in BoundInterface - %w", err)
+	}
+	if name != "" {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up interface in BoundInterface - %w", err)
+		}
+		return ifi, nil
+	}
+
+	local, ok := u.conn.LocalAddr().(*net.UDPAddr)
+	if !ok || local.IP == nil || local.IP.IsUnspecified() {
+		return nil, nil
+	}
+	ifi, err := interfaceByIP(local.IP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up interface in BoundInterface - %w", err)
+	}
+	return ifi, nil
+}
+
+// interfaceByIP returns the interface having the address ip.
+func interfaceByIP(ip net.IP) (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface with address %v", ip)
+}
+
+// interfaceAddr replaces an unspecified local address laddr by an address
+// of ifi suiting network, keeping the port.
+func interfaceAddr(ifi *net.Interface, network string, laddr *net.UDPAddr) (*net.UDPAddr, error) {
+	if laddr.IP != nil && !laddr.IP.IsUnspecified() {
+		return laddr, nil
+	}
+
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		v4 := ipnet.IP.To4() != nil
+		if (network == "udp4" && !v4) || (network == "udp6" && v4) {
+			continue
+		}
+		zone := ""
+		if ipnet.IP.IsLinkLocalUnicast() {
+			zone = ifi.Name
+		}
+		return &net.UDPAddr{IP: ipnet.IP, Port: laddr.Port, Zone: zone}, nil
+	}
+	return nil, fmt.Errorf("no %s address on interface %s", network, ifi.Name)
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import "golang.org/x/sys/unix"
+
+// bindToDeviceSupported reports whether sockets can be bound to a device.
+const bindToDeviceSupported = true
+
+// bindToDevice binds the socket to the interface name with SO_BINDTODEVICE.
+func bindToDevice(fd uintptr, name string) error {
+	return unix.BindToDevice(int(fd), name)
+}
+
+// boundDevice returns the SO_BINDTODEVICE interface of conn, empty when the
+// socket is not bound to one.
+func boundDevice(conn packetConn) (string, error) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+
+	var name string
+	var gerr error
+	err = rc.Control(func(fd uintptr) {
+		name, gerr = unix.GetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE)
+	})
+	if err != nil {
+		return "", err
+	}
+	return name, gerr
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestUDPClient_BoundInterface(t *testing.T) {
+	lo, err := loopbackInterface()
+	if err != nil {
+		t.Skip("no loopback interface -", err)
+	}
+
+	u, err := NewUDPClientWithOptions(
+		WithNetwork("udp4"),
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4zero}),
+		WithInterface(lo),
+	)
+	if err != nil {
+		t.Skip("SO_BINDTODEVICE not permitted -", err)
+	}
+	defer u.Close()
+
+	ifi, err := u.BoundInterface()
+	if err != nil {
+		t.Fatal("failed to get bound interface -", err)
+	}
+	if ifi == nil || ifi.Name != lo.Name {
+		t.Errorf("expected interface %s got %v", lo.Name, ifi)
+	}
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+	if _, err = u.Transmit(peer.LocalAddr().(*net.UDPAddr), []byte("via lo")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	if _, err = peer.Receive(buf); err != nil {
+		t.Error("failed to receive -", err)
+	}
+
+	// Without SO_BINDTODEVICE the interface follows the local address
+	ifi, err = peer.BoundInterface()
+	if err != nil {
+		t.Fatal("failed to get bound interface -", err)
+	}
+	if ifi == nil || ifi.Name != lo.Name {
+		t.Errorf("expected interface %s got %v", lo.Name, ifi)
+	}
+
+	wildcard, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer wildcard.Close()
+	if ifi, err = wildcard.BoundInterface(); err != nil || ifi != nil {
+		t.Errorf("expected no interface got %v and %v", ifi, err)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithInterface(nil)); err == nil {
+		t.Error("expected Error(nil interface) got nil")
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import "errors"
+
+// bindToDeviceSupported reports whether sockets can be bound to a device.
+const bindToDeviceSupported = false
+
+// bindToDevice fails as SO_BINDTODEVICE is Linux only.
+func bindToDevice(fd uintptr, name string) error {
+	return errors.New("SO_BINDTODEVICE is not supported on this platform")
+}
+
+// boundDevice returns no device as SO_BINDTODEVICE is Linux only.
+func boundDevice(conn packetConn) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"sync"
+	"time"
+)
+
+// jitterEstimator computes the interarrival jitter of RFC 3550 section 6.4.1
+// from arrival timestamps only. Without sender timestamps the difference in
+// transit time D is taken as the change between consecutive inter-arrival
+// intervals, which assumes the sender transmits at a steady cadence.
+type jitterEstimator struct {
+	mu       sync.Mutex
+	last     time.Time
+	interval time.Duration
+	samples  int
+	jitter   float64 // nanoseconds
+}
+
+// observe records the arrival time of a datagram.
+func (j *jitterEstimator) observe(t time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.samples > 0 {
+		interval := t.Sub(j.last)
+		if j.samples > 1 {
+			d := float64(interval - j.interval)
+			if d < 0 {
+				d = -d
+			}
+			j.jitter += (d - j.jitter) / 16
+		}
+		j.interval = interval
+	}
+	j.last = t
+	j.samples++
+}
+
+// value returns the current jitter estimate.
+func (j *jitterEstimator) value() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return time.Duration(j.jitter)
+}
+
+// Jitter returns the inter-arrival jitter estimated over the datagrams
+// received so far. It stays zero unless JitterEstimate is enabled.
+func (u *UDPClient) Jitter() time.Duration {
+	if u == nil {
+		return 0
+	}
+	return u.jitter.value()
+}
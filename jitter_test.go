@@ -0,0 +1,75 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestJitterEstimator(t *testing.T) {
+	var j jitterEstimator
+	now := time.Now()
+
+	// Steady cadence has no jitter
+	for i := 0; i < 10; i++ {
+		now = now.Add(20 * time.Millisecond)
+		j.observe(now)
+	}
+	if v := j.value(); v != 0 {
+		t.Errorf("expected zero jitter for steady arrivals got %v", v)
+	}
+
+	// Alternating intervals raise the estimate
+	prev := j.value()
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			now = now.Add(10 * time.Millisecond)
+		} else {
+			now = now.Add(30 * time.Millisecond)
+		}
+		j.observe(now)
+		if v := j.value(); v < prev {
+			t.Errorf("expected jitter to grow got %v after %v", v, prev)
+		}
+		prev = j.value()
+	}
+	if prev == 0 || prev > 20*time.Millisecond {
+		t.Errorf("expected jitter between 0 and 20ms got %v", prev)
+	}
+
+	// Back to a steady cadence the estimate decays
+	for i := 0; i < 10; i++ {
+		now = now.Add(20 * time.Millisecond)
+		j.observe(now)
+	}
+	if v := j.value(); v >= prev {
+		t.Errorf("expected jitter to decay below %v got %v", prev, v)
+	}
+}
+
+func TestUDPClient_Jitter(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.JitterEstimate = true
+
+	buf := make([]byte, maxBufferSize)
+	for _, gap := range []time.Duration{0, 5 * time.Millisecond, 30 * time.Millisecond} {
+		time.Sleep(gap)
+		if _, err = u.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("testing")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err = u.Receive(buf); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+	}
+	if u.Jitter() <= 0 {
+		t.Errorf("expected positive jitter got %v", u.Jitter())
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// StartKeepalive transmits payload to addr every interval from a goroutine,
+// to keep a NAT mapping open or to signal liveness to the peer, until the
+// returned stop is called or the client is closed. stop may be called any
+// number of times and returns once the goroutine is gone. Failed
+// transmissions are kept in RecentErrors and do not end the keepalive.
+// Invalid parameters are recorded the same way and start nothing.
+func (u *UDPClient) StartKeepalive(addr *net.UDPAddr, interval time.Duration, payload []byte) (stop func()) {
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	stop = sync.OnceFunc(func() {
+		close(quit)
+		<-exited
+	})
+
+	if u == nil || u.conn == nil {
+		close(exited)
+		return
+	}
+	if addr == nil || interval <= 0 {
+		close(exited)
+		u.recordError("StartKeepalive", fmt.Errorf("parameter error in StartKeepalive"))
+		return
+	}
+
+	data := append([]byte(nil), payload...)
+	done := u.Done()
+	started := u.goBackground(func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				// Transmit keeps its own errors in RecentErrors, the socket
+				// is not swapped by the AutoReconnect of Serve meanwhile
+				u.swapMu.RLock()
+				_, _ = u.Transmit(addr, data)
+				u.swapMu.RUnlock()
+			}
+		}
+	})
+	if !started {
+		close(exited)
+	}
+	return
+}
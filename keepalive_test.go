@@ -0,0 +1,58 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_StartKeepalive(t *testing.T) {
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+
+	stop := u.StartKeepalive(peer, 10*time.Millisecond, []byte("ping"))
+	time.Sleep(105 * time.Millisecond)
+	stop()
+	stop()
+
+	sent := m.Sent()
+	// Loaded machines may skip ticks, never add any
+	if len(sent) < 5 || len(sent) > 10 {
+		t.Errorf("expected about 10 keepalives got %d", len(sent))
+	}
+	for _, dg := range sent {
+		if string(dg.Data) != "ping" || dg.Addr != peer {
+			t.Fatalf("expected %q to %v got %q to %v", "ping", peer, dg.Data, dg.Addr)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if after := len(m.Sent()); after != len(sent) {
+		t.Errorf("expected no keepalive after stop got %d more", after-len(sent))
+	}
+
+	// Closing the client ends the keepalive as well
+	c, cm := NewMockUDPClient()
+	c.StartKeepalive(peer, 5*time.Millisecond, []byte("ping"))
+	time.Sleep(20 * time.Millisecond)
+	c.Close()
+	before := len(cm.Sent())
+	time.Sleep(20 * time.Millisecond)
+	if after := len(cm.Sent()); after != before {
+		t.Errorf("expected no keepalive after close got %d more", after-before)
+	}
+
+	// Invalid parameters start nothing but still give a usable stop
+	u.ErrorHistory = 1
+	u.StartKeepalive(nil, time.Millisecond, []byte("ping"))()
+	if errs := u.RecentErrors(); len(errs) != 1 || errs[0].Op != "StartKeepalive" {
+		t.Errorf("expected the parameter error recorded got %v", errs)
+	}
+	var nilClient *UDPClient
+	nilClient.StartKeepalive(peer, time.Millisecond, []byte("ping"))()
+}
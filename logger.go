@@ -0,0 +1,65 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"log/slog"
+	"net"
+)
+
+// Logger receives the internal events of a client worth reporting, such as
+// failed deadline resets, truncated datagrams and retries. The standard
+// *log.Logger satisfies it and adapters for other loggers are one method.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// WithLogger sets the Logger of the client.
+func WithLogger(l Logger) Option {
+	return func(c *config) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// logf reports an event to the Logger if any.
+func (u *UDPClient) logf(format string, v ...any) {
+	if u.Logger != nil {
+		u.Logger.Printf(format, v...)
+	}
+}
+
+// WithSlog sets the Slog logger of the client.
+func WithSlog(l *slog.Logger) Option {
+	return func(c *config) error {
+		c.slog = l
+		return nil
+	}
+}
+
+// logTraffic reports a datagram of n bytes sent to or received from addr
+// at debug level, skipping all work when the level is disabled. Unnamed
+// unixgram peers have no address and are logged with an empty one.
+func (u *UDPClient) logTraffic(msg string, addr net.Addr, n int) {
+	if u.Slog == nil || !u.Slog.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	remote := ""
+	if addr != nil {
+		remote = addr.String()
+	}
+	u.Slog.LogAttrs(context.Background(), slog.LevelDebug, msg,
+		slog.String("remote_addr", remote), slog.Int("bytes", n))
+}
+
+// logError reports the failure of op at error level.
+func (u *UDPClient) logError(op string, err error) {
+	if u.Slog == nil || !u.Slog.Enabled(context.Background(), slog.LevelError) {
+		return
+	}
+	u.Slog.LogAttrs(context.Background(), slog.LevelError, "udp operation failed",
+		slog.String("op", op), slog.Any("err", err))
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureLogger keeps the messages it receives.
+type captureLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *captureLogger) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+// contains reports whether a message containing s was logged.
+func (l *captureLogger) contains(s string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range l.msgs {
+		if strings.Contains(m, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWithLogger(t *testing.T) {
+	var logger captureLogger
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithLogger(&logger),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	if _, err = u.Transmit(dst, make([]byte, 64)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	u.Receive(make([]byte, 16))
+	if !logger.contains("truncated datagram from " + dst.String() + " to 16 bytes") {
+		t.Errorf("expected the truncation to be logged got %q", logger.msgs)
+	}
+
+	silent, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer silent.Close()
+	u.QueryRetries = 1
+	u.Query(silent.LocalAddr().(*net.UDPAddr), []byte("ping"), make([]byte, 16), 10*time.Millisecond)
+	if !logger.contains("retrying (1/1)") {
+		t.Errorf("expected the retry to be logged got %q", logger.msgs)
+	}
+
+	// Closing the socket underneath makes the deadline reset fail
+	u.conn.Close()
+	u.Receive(make([]byte, 16))
+	if !logger.contains("failed to reset read deadline") {
+		t.Errorf("expected the deadline failure to be logged got %q", logger.msgs)
+	}
+}
+
+func TestWithSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithSlog(logger),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	if _, err = u.Transmit(dst, make([]byte, 10)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	u.Transmit(nil, nil)
+
+	var records []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r map[string]any
+		if err = dec.Decode(&r); err != nil {
+			t.Fatal("failed to decode record -", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records got %v", records)
+	}
+	for i, msg := range []string{"transmitted", "received"} {
+		r := records[i]
+		if r["level"] != "DEBUG" || r["msg"] != msg || r["remote_addr"] != dst.String() || r["bytes"] != 10.0 {
+			t.Errorf("expected debug %s record of 10 bytes with %v got %v", msg, dst, r)
+		}
+	}
+	if r := records[2]; r["level"] != "ERROR" || r["op"] != "Transmit" || r["err"] != "parameter error in Transmit" {
+		t.Errorf("expected error record for Transmit got %v", r)
+	}
+
+	// Debug events are dropped when the level is raised
+	buf.Reset()
+	u.Slog = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	if _, err = u.Transmit(dst, make([]byte, 10)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no records at error level got %q", buf.String())
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// LossyOptions configures the impairments of a LossyConn.
+type LossyOptions struct {
+	// LossRate is the probability in [0, 1] of a datagram being dropped.
+	LossRate float64
+
+	// DuplicateRate is the probability in [0, 1] of a datagram being sent
+	// twice.
+	DuplicateRate float64
+
+	// ReorderWindow is the number of datagrams held back and released in
+	// random order, values below 2 keep the order.
+	ReorderWindow int
+
+	// FixedDelay postpones every datagram by this long.
+	FixedDelay time.Duration
+
+	// Seed seeds the random generator, so that the same sequence of
+	// datagrams is impaired the same way on every run.
+	Seed uint64
+
+	// Conn is the connection impaired, a *net.UDPConn or a *MockConn. A new
+	// MockConn is used when nil.
+	Conn net.PacketConn
+}
+
+// LossyConn wraps the connection of a client to drop, duplicate, reorder
+// and delay the datagrams it transmits, deterministically for a given seed,
+// to exercise the handling of an unreliable network in tests. Received
+// datagrams pass unchanged. Transmissions always appear to succeed.
+type LossyConn struct {
+	packetConn
+
+	opts LossyOptions
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	held    []func()
+	dropped int
+}
+
+// NewLossyUDPClient creates a client transmitting over a LossyConn
+// configured by opts, reachable through its Lossy method. It panics when
+// opts.Conn is neither a *net.UDPConn nor a *MockConn.
+func NewLossyUDPClient(opts LossyOptions) *UDPClient {
+	var inner packetConn
+	switch c := opts.Conn.(type) {
+	case nil:
+		_, inner = NewMockUDPClient()
+	case packetConn:
+		inner = c
+	default:
+		panic("udp: LossyOptions.Conn must be a *net.UDPConn or a *MockConn")
+	}
+
+	l := &LossyConn{
+		packetConn: inner,
+		opts:       opts,
+		rng:        rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+	}
+	return &UDPClient{
+		conn:          l,
+		ReadDeadline:  ReadDeadline,
+		WriteDeadline: WriteDeadline,
+		features:      probeFeatures(l),
+	}
+}
+
+// Lossy returns the LossyConn of a client created by NewLossyUDPClient, nil
+// for other clients.
+func (u *UDPClient) Lossy() *LossyConn {
+	if u == nil {
+		return nil
+	}
+	l, _ := u.conn.(*LossyConn)
+	return l
+}
+
+// Mock returns the MockConn underneath, nil when a socket is impaired.
+func (l *LossyConn) Mock() *MockConn {
+	m, _ := l.packetConn.(*MockConn)
+	return m
+}
+
+// Dropped returns the number of datagrams dropped so far.
+func (l *LossyConn) Dropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// impair passes the datagram sent by write through the impairments.
+func (l *LossyConn) impair(b []byte, write func([]byte)) {
+	data := append([]byte(nil), b...)
+	send := func() { write(data) }
+	if l.opts.FixedDelay > 0 {
+		send = func() { time.AfterFunc(l.opts.FixedDelay, func() { write(data) }) }
+	}
+
+	l.mu.Lock()
+	var release []func()
+	switch {
+	case l.rng.Float64() < l.opts.LossRate:
+		l.dropped++
+	case l.rng.Float64() < l.opts.DuplicateRate:
+		release = l.hold(send, send)
+	default:
+		release = l.hold(send)
+	}
+	l.mu.Unlock()
+
+	for _, f := range release {
+		f()
+	}
+}
+
+// hold adds sends to the reorder window, returning those to release now.
+func (l *LossyConn) hold(sends ...func()) []func() {
+	if l.opts.ReorderWindow < 2 {
+		return sends
+	}
+
+	var release []func()
+	for _, send := range sends {
+		l.held = append(l.held, send)
+		if len(l.held) >= l.opts.ReorderWindow {
+			i := l.rng.IntN(len(l.held))
+			release = append(release, l.held[i])
+			l.held = append(l.held[:i], l.held[i+1:]...)
+		}
+	}
+	return release
+}
+
+// Flush releases the datagrams held back by the reorder window.
+func (l *LossyConn) Flush() {
+	l.mu.Lock()
+	held := l.held
+	l.held = nil
+	l.mu.Unlock()
+
+	for _, send := range held {
+		send()
+	}
+}
+
+// WriteTo impairs b on its way to addr.
+func (l *LossyConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	l.impair(b, func(data []byte) { _, _ = l.packetConn.WriteTo(data, addr) })
+	return len(b), nil
+}
+
+// WriteMsgUDP impairs b on its way to addr, along with oob.
+func (l *LossyConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	oob = append([]byte(nil), oob...)
+	l.impair(b, func(data []byte) { _, _, _ = l.packetConn.WriteMsgUDP(data, oob, addr) })
+	return len(b), len(oob), nil
+}
+
+// Write impairs b on its way to the remote address of a connected socket.
+func (l *LossyConn) Write(b []byte) (int, error) {
+	l.impair(b, func(data []byte) { _, _ = l.packetConn.Write(data) })
+	return len(b), nil
+}
+
+// Close flushes the reorder window and closes the connection. Datagrams
+// still delayed are lost.
+func (l *LossyConn) Close() error {
+	l.Flush()
+	return l.packetConn.Close()
+}
+
+// SetDeadline sets the read deadline, see SetWriteDeadline.
+func (l *LossyConn) SetDeadline(t time.Time) error {
+	return l.packetConn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline does nothing as writes never block, while the delivery
+// of a delayed or reordered datagram comes after any deadline of the write.
+func (l *LossyConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// transmitNumbered transmits count datagrams carrying their index from u.
+func transmitNumbered(t *testing.T, u *UDPClient, count int) {
+	t.Helper()
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+	for i := 0; i < count; i++ {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(i))
+		if _, err := u.Transmit(peer, b[:]); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+}
+
+func TestLossyConn_LossRate(t *testing.T) {
+	const count = 10000
+	for _, rate := range []float64{0, 0.1, 0.5} {
+		u := NewLossyUDPClient(LossyOptions{LossRate: rate, Seed: 1})
+		transmitNumbered(t, u, count)
+
+		l := u.Lossy()
+		sent := len(l.Mock().Sent())
+		if sent+l.Dropped() != count {
+			t.Errorf("expected %d datagrams accounted for got %d sent and %d dropped", count, sent, l.Dropped())
+		}
+		observed := float64(count-sent) / count
+		if math.Abs(observed-rate) > 0.02 {
+			t.Errorf("expected loss rate %v got %v", rate, observed)
+		}
+		u.Close()
+	}
+}
+
+func TestLossyConn_Deterministic(t *testing.T) {
+	opts := LossyOptions{LossRate: 0.2, DuplicateRate: 0.1, ReorderWindow: 4, Seed: 42}
+	var runs [2][]Datagram
+	for i := range runs {
+		u := NewLossyUDPClient(opts)
+		transmitNumbered(t, u, 1000)
+		u.Lossy().Flush()
+		runs[i] = u.Lossy().Mock().Sent()
+		u.Close()
+	}
+
+	if len(runs[0]) != len(runs[1]) {
+		t.Fatalf("expected identical runs got %d and %d datagrams", len(runs[0]), len(runs[1]))
+	}
+	for i := range runs[0] {
+		if string(runs[0][i].Data) != string(runs[1][i].Data) {
+			t.Fatalf("expected identical runs, datagram %d differs", i)
+		}
+	}
+}
+
+func TestLossyConn_DuplicateReorder(t *testing.T) {
+	const count = 10000
+	u := NewLossyUDPClient(LossyOptions{DuplicateRate: 0.2, ReorderWindow: 8, Seed: 7})
+	defer u.Close()
+	transmitNumbered(t, u, count)
+	u.Lossy().Flush()
+
+	sent := u.Lossy().Mock().Sent()
+	seen := make(map[uint32]int)
+	reordered := 0
+	last := -1
+	for _, dg := range sent {
+		i := binary.BigEndian.Uint32(dg.Data)
+		seen[i]++
+		if int(i) < last {
+			reordered++
+		}
+		last = int(i)
+	}
+	if len(seen) != count {
+		t.Errorf("expected all %d datagrams delivered got %d", count, len(seen))
+	}
+	observed := float64(len(sent)-count) / count
+	if math.Abs(observed-0.2) > 0.02 {
+		t.Errorf("expected duplicate rate 0.2 got %v", observed)
+	}
+	if reordered == 0 {
+		t.Errorf("expected reordered datagrams")
+	}
+}
+
+func TestLossyConn_FixedDelay(t *testing.T) {
+	u := NewLossyUDPClient(LossyOptions{FixedDelay: 50 * time.Millisecond})
+	defer u.Close()
+	transmitNumbered(t, u, 1)
+
+	m := u.Lossy().Mock()
+	if len(m.Sent()) != 0 {
+		t.Errorf("expected the datagram delayed")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(m.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(m.Sent()) != 1 {
+		t.Errorf("expected the datagram after the delay")
+	}
+}
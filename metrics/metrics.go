@@ -0,0 +1,96 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package metrics exports the statistics of a udp.UDPClient to Prometheus.
+// It is a module of its own so that the udp module does not depend on
+// Prometheus.
+package metrics
+
+import (
+	"time"
+
+	"github.com/boseji/udp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace prefixes the names of all metrics.
+const namespace = "udp"
+
+// collector reads the Stats and the effective deadlines of a client on every
+// scrape, so the traffic itself carries no Prometheus overhead. Both are
+// safe to read while the client is in use.
+type collector struct {
+	u *udp.UDPClient
+
+	bytesSent       *prometheus.Desc
+	bytesReceived   *prometheus.Desc
+	packetsSent     *prometheus.Desc
+	packetsReceived *prometheus.Desc
+	errors          *prometheus.Desc
+	rateLimited     *prometheus.Desc
+	kernelDrops     *prometheus.Desc
+	readDeadline    *prometheus.Desc
+	writeDeadline   *prometheus.Desc
+}
+
+// Register registers the metrics of u with reg, labelled with client="name"
+// so that several clients can be registered. Like
+// prometheus.Registerer.MustRegister it panics when the registration fails,
+// for instance when name is already registered.
+func Register(reg prometheus.Registerer, u *udp.UDPClient, name string) {
+	labels := prometheus.Labels{"client": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metric), help, nil, labels)
+	}
+
+	reg.MustRegister(&collector{
+		u:               u,
+		bytesSent:       desc("bytes_sent_total", "Bytes of the datagrams sent, framing included."),
+		bytesReceived:   desc("bytes_received_total", "Bytes of the datagrams received, framing included."),
+		packetsSent:     desc("packets_sent_total", "Datagrams sent."),
+		packetsReceived: desc("packets_received_total", "Datagrams received."),
+		errors:          desc("errors_total", "Errors of the client."),
+		rateLimited:     desc("packets_rate_limited_total", "Datagrams dropped by the per source rate limit."),
+		kernelDrops:     desc("packets_kernel_dropped_total", "Datagrams dropped by the kernel on a full receive buffer."),
+		readDeadline:    desc("read_deadline_timestamp_seconds", "Read deadline applied to the socket as a Unix time, zero when none."),
+		writeDeadline:   desc("write_deadline_timestamp_seconds", "Write deadline applied to the socket as a Unix time, zero when none."),
+	})
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesSent
+	ch <- c.bytesReceived
+	ch <- c.packetsSent
+	ch <- c.packetsReceived
+	ch <- c.errors
+	ch <- c.rateLimited
+	ch <- c.kernelDrops
+	ch <- c.readDeadline
+	ch <- c.writeDeadline
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.u.Stats()
+	ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, float64(s.BytesSent))
+	ch <- prometheus.MustNewConstMetric(c.bytesReceived, prometheus.CounterValue, float64(s.BytesReceived))
+	ch <- prometheus.MustNewConstMetric(c.packetsSent, prometheus.CounterValue, float64(s.PacketsSent))
+	ch <- prometheus.MustNewConstMetric(c.packetsReceived, prometheus.CounterValue, float64(s.PacketsReceived))
+	ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.Errors))
+	ch <- prometheus.MustNewConstMetric(c.rateLimited, prometheus.CounterValue, float64(s.RateLimited))
+	ch <- prometheus.MustNewConstMetric(c.kernelDrops, prometheus.CounterValue, float64(s.KernelDrops))
+	read, _ := c.u.EffectiveReadDeadline()
+	ch <- prometheus.MustNewConstMetric(c.readDeadline, prometheus.GaugeValue, unixSeconds(read))
+	write, _ := c.u.EffectiveWriteDeadline()
+	ch <- prometheus.MustNewConstMetric(c.writeDeadline, prometheus.GaugeValue, unixSeconds(write))
+}
+
+// unixSeconds returns t as a Unix time in seconds, zero for the zero time.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boseji/udp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegister(t *testing.T) {
+	u, err := udp.NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	Register(reg, u, "test")
+
+	dst := u.LocalAddr().(*net.UDPAddr)
+	if _, err = u.Transmit(dst, []byte("metrics")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, 1024)
+	if _, err = u.Receive(buf); err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	u.Receive(buf)
+
+	expected := `
+# HELP udp_bytes_received_total Bytes of the datagrams received, framing included.
+# TYPE udp_bytes_received_total counter
+udp_bytes_received_total{client="test"} 7
+# HELP udp_bytes_sent_total Bytes of the datagrams sent, framing included.
+# TYPE udp_bytes_sent_total counter
+udp_bytes_sent_total{client="test"} 7
+# HELP udp_errors_total Errors of the client.
+# TYPE udp_errors_total counter
+udp_errors_total{client="test"} 1
+# HELP udp_packets_received_total Datagrams received.
+# TYPE udp_packets_received_total counter
+udp_packets_received_total{client="test"} 1
+# HELP udp_packets_sent_total Datagrams sent.
+# TYPE udp_packets_sent_total counter
+udp_packets_sent_total{client="test"} 1
+# HELP udp_packets_rate_limited_total Datagrams dropped by the per source rate limit.
+# TYPE udp_packets_rate_limited_total counter
+udp_packets_rate_limited_total{client="test"} 0
+# HELP udp_packets_kernel_dropped_total Datagrams dropped by the kernel on a full receive buffer.
+# TYPE udp_packets_kernel_dropped_total counter
+udp_packets_kernel_dropped_total{client="test"} 0
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"udp_bytes_received_total", "udp_bytes_sent_total", "udp_errors_total",
+		"udp_packets_received_total", "udp_packets_sent_total", "udp_packets_rate_limited_total",
+		"udp_packets_kernel_dropped_total")
+	if err != nil {
+		t.Error("unexpected metrics -", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("failed to gather -", err)
+	}
+	deadlines := map[string]float64{}
+	for _, f := range families {
+		if strings.HasSuffix(f.GetName(), "_deadline_timestamp_seconds") {
+			deadlines[f.GetName()] = f.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	for name, get := range map[string]func() (time.Time, bool){
+		"udp_read_deadline_timestamp_seconds":  u.EffectiveReadDeadline,
+		"udp_write_deadline_timestamp_seconds": u.EffectiveWriteDeadline,
+	} {
+		d, _ := get()
+		if v := deadlines[name]; d.IsZero() || v != float64(d.UnixNano())/1e9 {
+			t.Errorf("expected %s %v got %v", name, d, v)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic registering the same client name twice")
+		}
+	}()
+	Register(reg, u, "test")
+}
@@ -0,0 +1,299 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// mockQueueSize is the number of received datagrams a MockConn holds before
+// Inject blocks.
+const mockQueueSize = 1024
+
+// errNoSocket is returned by the socket level operations of a MockConn.
+var errNoSocket = errors.New("mock connection has no socket")
+
+// MockConn is an in-memory socket for a UDPClient created with
+// NewMockUDPClient, so that code using the client can be tested without
+// the network. Datagrams passed to Inject are received by the client, and
+// those it transmits are recorded for Sent. Deadlines behave as on a real
+// socket, while socket options fail. It is safe for concurrent use.
+type MockConn struct {
+	// Respond if set is called for every datagram transmitted to addr, a
+	// non-nil result is received by the client as the reply of addr. It
+	// must be set before the client is used.
+	Respond func(addr *net.UDPAddr, data []byte) []byte
+
+	local     *net.UDPAddr
+	inbox     chan Datagram
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu            sync.Mutex
+	sent          []Datagram
+	readDeadline  time.Time
+	readChanged   chan struct{} // closed when the read deadline changes
+	writeDeadline time.Time
+}
+
+var _ packetConn = (*MockConn)(nil)
+
+// NewMockUDPClient creates a client on top of a new MockConn, bound to the
+// unspecified IPv6 address so that it accepts destinations of both families.
+func NewMockUDPClient() (*UDPClient, *MockConn) {
+	m := newMockConn(&net.UDPAddr{IP: net.IPv6unspecified})
+	u := &UDPClient{
+		conn:          m,
+		ReadDeadline:  ReadDeadline,
+		WriteDeadline: WriteDeadline,
+		features:      probeFeatures(m),
+	}
+	return u, m
+}
+
+// newMockConn creates a MockConn pretending to be bound to local.
+func newMockConn(local *net.UDPAddr) *MockConn {
+	return &MockConn{
+		local:       local,
+		inbox:       make(chan Datagram, mockQueueSize),
+		closed:      make(chan struct{}),
+		readChanged: make(chan struct{}),
+	}
+}
+
+// Inject queues a copy of data to be received by the client as sent from
+// addr. It blocks while the queue is full and drops the datagram once the
+// connection is closed.
+func (m *MockConn) Inject(data []byte, addr *net.UDPAddr) {
+	dg := Datagram{Data: append([]byte(nil), data...), Addr: addr}
+	select {
+	case m.inbox <- dg:
+	case <-m.closed:
+	}
+}
+
+// offer queues dg to be received by the client unless the queue is full or
+// the connection closed, as a socket drops datagrams it has no room for.
+func (m *MockConn) offer(dg Datagram) bool {
+	select {
+	case <-m.closed:
+		return false
+	default:
+	}
+	select {
+	case m.inbox <- dg:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sent returns the datagrams transmitted by the client so far, in order.
+func (m *MockConn) Sent() []Datagram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Datagram(nil), m.sent...)
+}
+
+// receive waits for an injected datagram until the read deadline, which may
+// change meanwhile.
+func (m *MockConn) receive() (Datagram, error) {
+	for {
+		m.mu.Lock()
+		deadline, changed := m.readDeadline, m.readChanged
+		m.mu.Unlock()
+
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if !deadline.IsZero() {
+			wait := time.Until(deadline)
+			if wait <= 0 {
+				return Datagram{}, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+
+		dg, done, err := m.await(expired, changed)
+		if timer != nil {
+			timer.Stop()
+		}
+		if done {
+			return dg, err
+		}
+	}
+}
+
+// await waits for an injected datagram, the closing of the connection, the
+// expiry of the deadline or its change, reporting done unless the latter.
+func (m *MockConn) await(expired <-chan time.Time, changed <-chan struct{}) (Datagram, bool, error) {
+	// A closed connection wins over queued datagrams
+	select {
+	case <-m.closed:
+		return Datagram{}, true, net.ErrClosed
+	default:
+	}
+
+	select {
+	case dg := <-m.inbox:
+		return dg, true, nil
+	case <-m.closed:
+		return Datagram{}, true, net.ErrClosed
+	case <-expired:
+		return Datagram{}, true, os.ErrDeadlineExceeded
+	case <-changed:
+		return Datagram{}, false, nil
+	}
+}
+
+// transmit records a datagram for addr and injects the response if any.
+func (m *MockConn) transmit(b []byte, addr *net.UDPAddr) (int, error) {
+	select {
+	case <-m.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	m.mu.Lock()
+	deadline := m.writeDeadline
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		m.mu.Unlock()
+		return 0, os.ErrDeadlineExceeded
+	}
+	data := append([]byte(nil), b...)
+	m.sent = append(m.sent, Datagram{Data: data, Addr: addr})
+	m.mu.Unlock()
+
+	if m.Respond != nil {
+		if reply := m.Respond(addr, data); reply != nil {
+			m.Inject(reply, addr)
+		}
+	}
+	return len(b), nil
+}
+
+// ReadFromUDP receives an injected datagram into b.
+func (m *MockConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	n, _, _, addr, err := m.ReadMsgUDP(b, nil)
+	return n, addr, err
+}
+
+// ReadMsgUDP receives an injected datagram into b, flagging it with
+// MSG_TRUNC where available when it does not fit. There are no control
+// messages.
+func (m *MockConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	dg, err := m.receive()
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	n = copy(b, dg.Data)
+	if n < len(dg.Data) {
+		flags = msgTrunc
+	}
+	return n, 0, flags, dg.Addr, nil
+}
+
+// ReadFrom receives an injected datagram into b.
+func (m *MockConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := m.ReadFromUDP(b)
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// Read receives an injected datagram into b.
+func (m *MockConn) Read(b []byte) (int, error) {
+	n, _, err := m.ReadFromUDP(b)
+	return n, err
+}
+
+// WriteTo records b as transmitted to addr.
+func (m *MockConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	a, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, syscall.EINVAL
+	}
+	return m.transmit(b, a)
+}
+
+// WriteMsgUDP records b as transmitted to addr, oob is ignored.
+func (m *MockConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	n, err = m.transmit(b, addr)
+	return n, 0, err
+}
+
+// Write fails as a MockConn is never connected.
+func (m *MockConn) Write(b []byte) (int, error) {
+	return 0, syscall.ENOTCONN
+}
+
+// Close closes the connection, unblocking pending reads.
+func (m *MockConn) Close() error {
+	err := net.ErrClosed
+	m.closeOnce.Do(func() {
+		close(m.closed)
+		err = nil
+	})
+	return err
+}
+
+// LocalAddr returns the address the connection pretends to be bound to.
+func (m *MockConn) LocalAddr() net.Addr {
+	return m.local
+}
+
+// RemoteAddr returns nil as a MockConn is never connected.
+func (m *MockConn) RemoteAddr() net.Addr {
+	return nil
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (m *MockConn) SetDeadline(t time.Time) error {
+	if err := m.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return m.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline, which also applies to pending
+// reads.
+func (m *MockConn) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readDeadline = t
+	close(m.readChanged)
+	m.readChanged = make(chan struct{})
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline.
+func (m *MockConn) SetWriteDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeDeadline = t
+	return nil
+}
+
+// SetReadBuffer fails as there is no socket behind a MockConn, its queue
+// has a fixed size.
+func (m *MockConn) SetReadBuffer(bytes int) error {
+	return errNoSocket
+}
+
+// SetWriteBuffer fails as there is no socket behind a MockConn.
+func (m *MockConn) SetWriteBuffer(bytes int) error {
+	return errNoSocket
+}
+
+// SyscallConn fails as there is no socket behind a MockConn.
+func (m *MockConn) SyscallConn() (syscall.RawConn, error) {
+	return nil, errNoSocket
+}
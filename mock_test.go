@@ -0,0 +1,98 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMockConn(t *testing.T) {
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+
+	// Both families are accepted
+	peers := []*net.UDPAddr{peer, {IP: net.ParseIP("2001:db8::1"), Port: 5000}}
+	for _, p := range peers {
+		if _, err := u.Transmit(p, []byte("hello")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+	sent := m.Sent()
+	if len(sent) != 2 || string(sent[0].Data) != "hello" || sent[1].Addr != peers[1] {
+		t.Errorf("expected both transmissions recorded got %v", sent)
+	}
+
+	m.Inject([]byte("world"), peer)
+	buf := make([]byte, maxBufferSize)
+	n, err := u.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != "world" || u.RemoteAddr != peer {
+		t.Errorf("expected %q from %v got %q from %v", "world", peer, buf[:n], u.RemoteAddr)
+	}
+
+	m.Inject(bytes.Repeat([]byte{'x'}, 32), peer)
+	if _, err = u.Receive(make([]byte, 16)); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated got %v", err)
+	}
+
+	u.ReadDeadline = 10 * time.Millisecond
+	if _, err = u.Receive(buf); !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+
+	// A pending read follows deadline changes, here by the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err = u.ReceiveContext(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline got %v", err)
+	}
+
+	if _, err = u.SocketOptions(); err == nil {
+		t.Errorf("expected socket options to fail on the mock")
+	}
+	if err = m.SetReadBuffer(1 << 20); !errors.Is(err, errNoSocket) {
+		t.Errorf("expected errNoSocket got %v", err)
+	}
+	if err = m.SetWriteBuffer(1 << 20); !errors.Is(err, errNoSocket) {
+		t.Errorf("expected errNoSocket got %v", err)
+	}
+
+	u.Close()
+	if _, err = u.Transmit(peer, []byte("late")); err == nil {
+		t.Errorf("expected error after Close")
+	}
+}
+
+func ExampleNewMockUDPClient() {
+	u, m := NewMockUDPClient()
+	defer u.Close()
+
+	// The mock answers every request like an upper case echo server would
+	m.Respond = func(addr *net.UDPAddr, data []byte) []byte {
+		return bytes.ToUpper(data)
+	}
+
+	server := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 7}
+	resp := make([]byte, 64)
+	n, from, err := u.Query(server, []byte("hello"), resp, time.Second)
+	if err != nil {
+		fmt.Println("query failed:", err)
+		return
+	}
+	fmt.Printf("%s from %v\n", resp[:n], from)
+	fmt.Println(len(m.Sent()), "datagram sent")
+	// Output:
+	// HELLO from 192.0.2.1:7
+	// 1 datagram sent
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// pathMTUCandidates are the MTUs probed by DiscoverPathMTU, in decreasing
+// order: the IP maximum, jumbo frames, FDDI, Ethernet, PPPoE, IPv4 in IPv6
+// tunnels, the IPv6 minimum and the IPv4 minimum.
+var pathMTUCandidates = []int{65535, 9000, 4352, 1500, 1492, 1480, 1280, 576}
+
+// DiscoverPathMTU returns the MTU of the path to addr, the largest IP packet
+// that reaches it without fragmentation. The payload of a datagram may use
+// all of it but the IP and UDP headers, 28 bytes for IPv4 and 48 bytes for
+// IPv6 destinations.
+//
+// The don't fragment bit is set for the duration of the discovery, and
+// probes of decreasing size are transmitted to addr until the kernel accepts
+// one. Zero filled probes reach the peer, which must tolerate them. When the
+// kernel refuses a probe with EMSGSIZE, the search continues from the MTU of
+// its route to addr, which includes what ICMP "fragmentation needed"
+// messages taught it. A probe lost on the way is not detected, so it is
+// worth calling again once the peer has been reached to pick up such
+// updates. It is supported on Linux only.
+func (u *UDPClient) DiscoverPathMTU(addr *net.UDPAddr) (
+	mtu int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to DiscoverPathMTU due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("DiscoverPathMTU", err) }()
+
+	if addr == nil {
+		err = fmt.Errorf("parameter error in DiscoverPathMTU")
+		return
+	}
+
+	err = u.checkFamily(addr)
+	if err != nil {
+		err = fmt.Errorf("failed to validate address in DiscoverPathMTU - %w", err)
+		return
+	}
+
+	mtu, err = u.discoverPathMTU(addr)
+	if err != nil {
+		mtu = 0
+		err = fmt.Errorf("failed to discover path MTU in DiscoverPathMTU - %w", err)
+	}
+	return
+}
+
+// headerOverhead returns the size of the IP and UDP headers of datagrams
+// sent to addr.
+func headerOverhead(addr *net.UDPAddr) int {
+	if addr.IP.To4() != nil {
+		return 20 + 8
+	}
+	return 40 + 8
+}
+
+// probeMTU transmits a zero filled datagram making up an IP packet of mtu
+// bytes to addr.
+func (u *UDPClient) probeMTU(addr *net.UDPAddr, mtu int) error {
+	if err := u.applyWriteDeadline(u.nextWriteDeadline()); err != nil {
+		return err
+	}
+	probe := make([]byte, mtu-headerOverhead(addr))
+	_, err := retryEINTR(func() (int, error) {
+		return u.conn.WriteTo(probe, addr)
+	})
+	return err
+}
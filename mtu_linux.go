@@ -0,0 +1,108 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// discoverPathMTU probes addr with the don't fragment bit set, restoring
+// the previous path MTU discovery mode of the socket afterwards.
+func (u *UDPClient) discoverPathMTU(addr *net.UDPAddr) (mtu int, err error) {
+	level, name := mtuDiscoverOption(addr)
+	rc, err := u.conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var prev int
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		if prev, serr = syscall.GetsockoptInt(int(fd), level, name); serr == nil {
+			serr = syscall.SetsockoptInt(int(fd), level, name, syscall.IP_PMTUDISC_DO)
+		}
+	})
+	if err == nil {
+		err = serr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to set don't fragment - %w", err)
+	}
+	defer func() {
+		_ = rc.Control(func(fd uintptr) {
+			_ = syscall.SetsockoptInt(int(fd), level, name, prev)
+		})
+	}()
+
+	mtu = pathMTUCandidates[0]
+	for {
+		err = u.probeMTU(addr, mtu)
+		if err == nil {
+			return mtu, nil
+		}
+		if !errors.Is(err, syscall.EMSGSIZE) {
+			return 0, err
+		}
+
+		// The kernel knows the MTU it refused the probe for
+		next := 0
+		if route, rerr := routeMTU(addr); rerr == nil && route < mtu {
+			next = route
+		} else {
+			for _, c := range pathMTUCandidates {
+				if c < mtu {
+					next = c
+					break
+				}
+			}
+		}
+		if next <= headerOverhead(addr) {
+			return 0, fmt.Errorf("no probe accepted down to %d bytes - %w", mtu, err)
+		}
+		mtu = next
+	}
+}
+
+// mtuDiscoverOption returns the socket option selecting the path MTU
+// discovery mode for datagrams sent to addr. The constants of both families
+// share their values.
+func mtuDiscoverOption(addr *net.UDPAddr) (level, name int) {
+	if addr.IP.To4() != nil {
+		return syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER
+	}
+	return syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER
+}
+
+// routeMTU returns the MTU the kernel holds for the route to addr, read
+// from a socket connected to it as IP_MTU only works on connected sockets.
+func routeMTU(addr *net.UDPAddr) (int, error) {
+	c, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	level, name := syscall.IPPROTO_IP, syscall.IP_MTU
+	if addr.IP.To4() == nil {
+		level, name = syscall.IPPROTO_IPV6, syscall.IPV6_MTU
+	}
+
+	var mtu int
+	var gerr error
+	err = rc.Control(func(fd uintptr) {
+		mtu, gerr = syscall.GetsockoptInt(int(fd), level, name)
+	})
+	if err == nil {
+		err = gerr
+	}
+	return mtu, err
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestUDPClient_DiscoverPathMTU(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	u, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	peer, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	mode := func() int {
+		rc, err := u.conn.SyscallConn()
+		if err != nil {
+			t.Fatal("failed to access socket -", err)
+		}
+		var v int
+		rc.Control(func(fd uintptr) {
+			v, err = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER)
+		})
+		if err != nil {
+			t.Fatal("failed to read discovery mode -", err)
+		}
+		return v
+	}
+	before := mode()
+
+	mtu, err := u.DiscoverPathMTU(peer.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal("failed to discover path MTU -", err)
+	}
+	// The loopback MTU is 65536 on current kernels, less on older ones
+	if mtu < 1280 || mtu > 65535 {
+		t.Errorf("expected a sensible MTU got %d", mtu)
+	}
+
+	// The probe of the discovered size made it through
+	buf := make([]byte, MaxDatagramSize)
+	n, err := peer.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive probe -", err)
+	}
+	if n != mtu-28 {
+		t.Errorf("expected a probe of %d bytes got %d", mtu-28, n)
+	}
+
+	if after := mode(); after != before {
+		t.Errorf("expected discovery mode %d restored got %d", before, after)
+	}
+
+	var nilClient *UDPClient
+	if _, err = nilClient.DiscoverPathMTU(loopback); err == nil {
+		t.Errorf("expected error for nil client")
+	}
+	if _, err = u.DiscoverPathMTU(nil); err == nil {
+		t.Errorf("expected error for nil address")
+	}
+}
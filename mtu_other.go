@@ -0,0 +1,17 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// discoverPathMTU reports that path MTU discovery is unsupported.
+func (u *UDPClient) discoverPathMTU(addr *net.UDPAddr) (int, error) {
+	return 0, fmt.Errorf("path MTU discovery is only supported on linux")
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// TransmitResult reports the outcome of sending to one destination.
+type TransmitResult struct {
+	Addr *net.UDPAddr
+	N    int
+	Err  error
+}
+
+// TransmitMultiConcurrent sends data to every address using up to
+// concurrency goroutines writing to the shared socket. All sends must finish
+// within timeout, destinations not reached in time report
+// os.ErrDeadlineExceeded. Every destination is sent to like a Transmit,
+// waiting for the rate limit and PacingGap, and with a write deadline no
+// later than the end of the timeout. The results are in the order of
+// addrs. When set, TransmitHook is called concurrently and must be safe for
+// that.
+func (u *UDPClient) TransmitMultiConcurrent(addrs []*net.UDPAddr, data []byte, concurrency int,
+	timeout time.Duration) ([]TransmitResult, error) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to TransmitMultiConcurrent due to uninitialized client")
+	}
+
+	if len(addrs) == 0 || (len(data) == 0 && !u.AllowEmptyDatagrams) ||
+		concurrency < 1 || timeout <= 0 {
+		return nil, fmt.Errorf("parameter error in TransmitMultiConcurrent")
+	}
+
+	if u.quiesced.Load() {
+		return nil, fmt.Errorf("failed to TransmitMultiConcurrent - %w", ErrQuiesced)
+	}
+
+	deadline := time.Now().Add(timeout)
+	results := make([]TransmitResult, len(addrs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(addrs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = u.transmitOne(addrs[i], data, deadline)
+			}
+		}()
+	}
+	for i := range addrs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// transmitOne sends data to addr for TransmitMultiConcurrent along the
+// write path of Transmit, without going past deadline.
+func (u *UDPClient) transmitOne(addr *net.UDPAddr, data []byte, deadline time.Time) (r TransmitResult) {
+	r.Addr = addr
+	defer func() { u.recordError("TransmitMultiConcurrent", r.Err) }()
+
+	if addr == nil {
+		r.Err = fmt.Errorf("parameter error in TransmitMultiConcurrent")
+		return
+	}
+
+	r.N, r.Err = u.writeDatagram("TransmitMultiConcurrent", addr, data, deadline,
+		func(wire []byte) (int, error) {
+			return u.conn.WriteTo(wire, addr)
+		})
+	return
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_TransmitMultiConcurrent(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+	port := peer.LocalAddr().(*net.UDPAddr).Port
+
+	// Many destinations, one of them unreachable from an IPv4 socket
+	addrs := make([]*net.UDPAddr, 20)
+	for i := range addrs {
+		addrs[i] = &net.UDPAddr{IP: net.IPv4(127, 0, 0, byte(i+1)), Port: port}
+	}
+	addrs[7] = &net.UDPAddr{IP: net.IPv6loopback, Port: port}
+
+	// A slow hook stands in for per send latency
+	const latency = 5 * time.Millisecond
+	u.TransmitHook = func(n int, addr net.Addr) { time.Sleep(latency) }
+	data := []byte("testing")
+
+	start := time.Now()
+	for _, addr := range addrs {
+		_, _ = u.Transmit(addr, data)
+	}
+	sequential := time.Since(start)
+
+	start = time.Now()
+	results, err := u.TransmitMultiConcurrent(addrs, data, 10, time.Second)
+	concurrent := time.Since(start)
+	if err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if concurrent >= sequential {
+		t.Errorf("expected concurrent sends (%v) to beat sequential ones (%v)", concurrent, sequential)
+	}
+
+	if len(results) != len(addrs) {
+		t.Fatalf("expected %d results got %d", len(addrs), len(results))
+	}
+	for i, r := range results {
+		if r.Addr != addrs[i] {
+			t.Errorf("result %d expected address %v got %v", i, addrs[i], r.Addr)
+		}
+		if i == 7 {
+			if !errors.Is(r.Err, ErrAddressFamilyMismatch) {
+				t.Errorf("result %d expected ErrAddressFamilyMismatch got %v", i, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.N != len(data) {
+			t.Errorf("result %d expected (%d, nil) got (%d, %v)", i, len(data), r.N, r.Err)
+		}
+	}
+}
+
+func TestUDPClient_TransmitMultiConcurrent_Deadline(t *testing.T) {
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithRateLimit(1),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	// Only the first destination fits in the rate limit before the timeout
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}
+	start := time.Now()
+	results, err := u.TransmitMultiConcurrent([]*net.UDPAddr{dst, dst, dst}, []byte("testing"), 2,
+		100*time.Millisecond)
+	if err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the rate limit wait to end with the timeout took %v", elapsed)
+	}
+
+	var expired int
+	for _, r := range results {
+		if errors.Is(r.Err, os.ErrDeadlineExceeded) {
+			expired++
+		}
+	}
+	if expired != 2 {
+		t.Errorf("expected 2 destinations past the deadline got %d", expired)
+	}
+	if s := u.Stats(); s.PacketsSent != 1 || s.Errors != 2 {
+		t.Errorf("expected 1 datagram sent and 2 errors got %+v", s)
+	}
+}
+
+func TestUDPClient_TransmitMultiConcurrent_Errors(t *testing.T) {
+	var u *UDPClient
+	addrs := []*net.UDPAddr{{Port: testingPort}}
+	if _, err := u.TransmitMultiConcurrent(addrs, []byte("testing"), 1, time.Second); err == nil {
+		t.Error("expected Error got nil")
+	}
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	if _, err = u.TransmitMultiConcurrent(addrs, []byte("testing"), 0, time.Second); err == nil {
+		t.Error("expected Error(concurrency) got nil")
+	}
+	u.Quiesce()
+	if _, err = u.TransmitMultiConcurrent(addrs, []byte("testing"), 1, time.Second); !errors.Is(err, ErrQuiesced) {
+		t.Errorf("expected ErrQuiesced got %v", err)
+	}
+}
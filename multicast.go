@@ -0,0 +1,63 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// JoinMulticast joins the multicast group on the interface ifi, or on the
+// interface chosen by the system when ifi is nil. Afterwards Receive also
+// delivers the datagrams sent to the group and to the port the client is
+// bound to, which should be bound to the unspecified address to see them.
+func (u *UDPClient) JoinMulticast(group *net.UDPAddr, ifi *net.Interface) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to JoinMulticast due to uninitialized client")
+	}
+
+	if group == nil || !group.IP.IsMulticast() {
+		return fmt.Errorf("parameter error in JoinMulticast")
+	}
+
+	var err error
+	if ip4 := group.IP.To4(); ip4 != nil {
+		err = ipv4.NewPacketConn(u.conn).JoinGroup(ifi, &net.UDPAddr{IP: ip4})
+	} else {
+		err = ipv6.NewPacketConn(u.conn).JoinGroup(ifi, &net.UDPAddr{IP: group.IP, Zone: group.Zone})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to join group %v in JoinMulticast - %w", group.IP, err)
+	}
+
+	return nil
+}
+
+// LeaveMulticast leaves a multicast group joined with JoinMulticast on the
+// same interface, which stops the delivery of its datagrams.
+func (u *UDPClient) LeaveMulticast(group *net.UDPAddr, ifi *net.Interface) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to LeaveMulticast due to uninitialized client")
+	}
+
+	if group == nil || !group.IP.IsMulticast() {
+		return fmt.Errorf("parameter error in LeaveMulticast")
+	}
+
+	var err error
+	if ip4 := group.IP.To4(); ip4 != nil {
+		err = ipv4.NewPacketConn(u.conn).LeaveGroup(ifi, &net.UDPAddr{IP: ip4})
+	} else {
+		err = ipv6.NewPacketConn(u.conn).LeaveGroup(ifi, &net.UDPAddr{IP: group.IP, Zone: group.Zone})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to leave group %v in LeaveMulticast - %w", group.IP, err)
+	}
+
+	return nil
+}
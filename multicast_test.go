@@ -0,0 +1,83 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/ipv4"
+)
+
+func TestUDPClient_Multicast(t *testing.T) {
+	lo, err := loopbackInterface()
+	if err != nil {
+		t.Skip("no loopback interface -", err)
+	}
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	group := &net.UDPAddr{IP: net.IPv4(239, 0, 0, 1), Port: u.LocalAddr().(*net.UDPAddr).Port}
+	if err = u.JoinMulticast(group, lo); err != nil {
+		t.Skip("multicast unavailable -", err)
+	}
+
+	sender, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create sender -", err)
+	}
+	defer sender.Close()
+	if err = ipv4.NewPacketConn(sender.conn).SetMulticastInterface(lo); err != nil {
+		t.Fatal("failed to set multicast interface -", err)
+	}
+
+	message := "One for all, all for one"
+	if _, err = sender.Transmit(group, []byte(message)); err != nil {
+		t.Fatal("failed to transmit to group -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, err := u.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive from group -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	if err = u.LeaveMulticast(group, lo); err != nil {
+		t.Fatal("failed to leave group -", err)
+	}
+	if _, err = sender.Transmit(group, []byte(message)); err != nil {
+		t.Fatal("failed to transmit to group -", err)
+	}
+	if _, err = u.Receive(buf); !IsTimeout(err) {
+		t.Errorf("expected no delivery after leaving got %v", err)
+	}
+
+	if err = u.JoinMulticast(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, lo); err == nil {
+		t.Error("expected Error(unicast group) got nil")
+	}
+	if err = (&UDPClient{}).LeaveMulticast(group, lo); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+}
+
+// loopbackInterface returns the loopback network interface of the host.
+func loopbackInterface() (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 && ifaces[i].Flags&net.FlagUp != 0 {
+			return &ifaces[i], nil
+		}
+	}
+	return nil, net.UnknownNetworkError("loopback")
+}
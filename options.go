@@ -0,0 +1,398 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// config collects the settings applied by the options of
+// NewUDPClientWithOptions.
+type config struct {
+	network         string
+	laddr           *net.UDPAddr
+	unixAddr        *net.UnixAddr
+	readDeadline    time.Duration
+	writeDeadline   time.Duration
+	readBuffer      int
+	writeBuffer     int
+	reusePort       bool
+	ifi             *net.Interface
+	rateLimit       int
+	rateNonBlocking bool
+	logger          Logger
+	slog            *slog.Logger
+	checksum        bool
+	compression     Compression
+	autoReconnect   bool
+	orderedDispatch bool
+	readBudget      int
+	receiveRetry    func(error) bool
+	readers         int
+	resolveCache    *ResolverCache
+	copyThreshold   int
+	jitterEstimate  bool
+	transmitHook    func(n int, addr net.Addr)
+	allowEmpty      bool
+	dropCounter     bool
+	remoteChange    func(old, new net.Addr)
+	pacingGap       time.Duration
+	errorHistory    int
+	sourceRate      int
+	sourceBurst     int
+
+	compressionThreshold int
+}
+
+// Option configures a client created by NewUDPClientWithOptions. An
+// invalid option fails the construction with its error.
+type Option func(*config) error
+
+// WithNetwork selects the network to listen on, "udp4" for IPv4 only,
+// "udp6" for IPv6 only or "udp" for both which is the default. With
+// "unixgram" the client uses a Unix datagram socket bound to the address
+// given to WithLocalUnixAddr, whose path is removed on Close. Its peers have
+// a *net.UnixAddr, see UnixgramAddr, which WriteTo transmits to and which
+// Receive stores in RemoteAddr, ReadFrom returns and Serve replies to. The
+// methods dealing in *net.UDPAddr report a nil sender and fail to transmit,
+// and the socket options specific to IP fail.
+func WithNetwork(network string) Option {
+	return func(c *config) error {
+		switch network {
+		case "udp", "udp4", "udp6", "unixgram":
+			c.network = network
+			return nil
+		}
+		return fmt.Errorf("parameter error in WithNetwork - unknown network %q", network)
+	}
+}
+
+// WithLocalAddr sets the local address to listen on, LocalUDPport on all
+// interfaces when not given.
+func WithLocalAddr(laddr *net.UDPAddr) Option {
+	return func(c *config) error {
+		c.laddr = laddr
+		return nil
+	}
+}
+
+// WithLocalUnixAddr sets the address of the Unix datagram socket of a client
+// created with WithNetwork("unixgram").
+func WithLocalUnixAddr(laddr *net.UnixAddr) Option {
+	return func(c *config) error {
+		if laddr == nil || laddr.Name == "" {
+			return fmt.Errorf("parameter error in WithLocalUnixAddr - no socket path")
+		}
+		c.unixAddr = laddr
+		return nil
+	}
+}
+
+// WithReadDeadline sets the ReadDeadline of the client, zero disables it.
+func WithReadDeadline(d time.Duration) Option {
+	return func(c *config) error {
+		if d < 0 {
+			return fmt.Errorf("parameter error in WithReadDeadline - negative duration %v", d)
+		}
+		c.readDeadline = d
+		return nil
+	}
+}
+
+// WithWriteDeadline sets the WriteDeadline of the client, zero disables it.
+func WithWriteDeadline(d time.Duration) Option {
+	return func(c *config) error {
+		if d < 0 {
+			return fmt.Errorf("parameter error in WithWriteDeadline - negative duration %v", d)
+		}
+		c.writeDeadline = d
+		return nil
+	}
+}
+
+// WithBufferSize sets the size in bytes of both the kernel receive and send
+// buffers of the socket.
+func WithBufferSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("parameter error in WithBufferSize - invalid size %d", size)
+		}
+		c.readBuffer = size
+		c.writeBuffer = size
+		return nil
+	}
+}
+
+// WithReadBufferSize sets the size in bytes of the kernel receive buffer of
+// the socket, which holds the datagrams not read yet. A larger one absorbs
+// bursts that would otherwise be dropped.
+func WithReadBufferSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("parameter error in WithReadBufferSize - invalid size %d", size)
+		}
+		c.readBuffer = size
+		return nil
+	}
+}
+
+// WithWriteBufferSize sets the size in bytes of the kernel send buffer of
+// the socket.
+func WithWriteBufferSize(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("parameter error in WithWriteBufferSize - invalid size %d", size)
+		}
+		c.writeBuffer = size
+		return nil
+	}
+}
+
+// WithReusePort sets ReusePort so that several clients can bind the same
+// port to distribute the load.
+func WithReusePort(reuse bool) Option {
+	return func(c *config) error {
+		c.reusePort = reuse
+		return nil
+	}
+}
+
+// WithInterface sets Interface to bind the socket to the network interface
+// ifi.
+func WithInterface(ifi *net.Interface) Option {
+	return func(c *config) error {
+		if ifi == nil {
+			return fmt.Errorf("parameter error in WithInterface")
+		}
+		c.ifi = ifi
+		return nil
+	}
+}
+
+// WithAutoReconnect sets AutoReconnect so that Serve rebinds the socket
+// after a fatal receive error instead of returning it.
+func WithAutoReconnect(on bool) Option {
+	return func(c *config) error {
+		c.autoReconnect = on
+		return nil
+	}
+}
+
+// WithOrderedDispatch sets OrderedDispatch so that ServeConcurrent handles
+// the datagrams of every sender in order.
+func WithOrderedDispatch() Option {
+	return func(c *config) error {
+		c.orderedDispatch = true
+		return nil
+	}
+}
+
+// WithReadBudget sets ReadBudget so that the Serve loops yield after
+// maxPerTick datagrams.
+func WithReadBudget(maxPerTick int) Option {
+	return func(c *config) error {
+		if maxPerTick <= 0 {
+			return fmt.Errorf("parameter error in WithReadBudget - invalid budget %d", maxPerTick)
+		}
+		c.readBudget = maxPerTick
+		return nil
+	}
+}
+
+// WithReceiveRetry sets ReceiveRetry so that ReceiveContext reads again
+// after the errors transient reports.
+func WithReceiveRetry(transient func(error) bool) Option {
+	return func(c *config) error {
+		if transient == nil {
+			return fmt.Errorf("parameter error in WithReceiveRetry")
+		}
+		c.receiveRetry = transient
+		return nil
+	}
+}
+
+// WithReaders sets Readers so that ReceiveChan reads with n goroutines.
+func WithReaders(n int) Option {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("parameter error in WithReaders - invalid count %d", n)
+		}
+		c.readers = n
+		return nil
+	}
+}
+
+// WithSharedResolveCache sets SharedResolveCache so that the clients given
+// the same cache share the hostnames resolved by TransmitTo.
+func WithSharedResolveCache(c *ResolverCache) Option {
+	return func(cfg *config) error {
+		if c == nil {
+			return fmt.Errorf("parameter error in WithSharedResolveCache")
+		}
+		cfg.resolveCache = c
+		return nil
+	}
+}
+
+// WithCopyThreshold sets CopyThreshold so that ReceiveMessage copies the
+// datagrams of up to n bytes only.
+func WithCopyThreshold(n int) Option {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("parameter error in WithCopyThreshold - invalid threshold %d", n)
+		}
+		c.copyThreshold = n
+		return nil
+	}
+}
+
+// WithJitterEstimate sets JitterEstimate so that Jitter reports the
+// inter-arrival jitter of the received datagrams.
+func WithJitterEstimate() Option {
+	return func(c *config) error {
+		c.jitterEstimate = true
+		return nil
+	}
+}
+
+// WithOnTransmit sets the TransmitHook called after every successful
+// transmission.
+func WithOnTransmit(hook func(n int, addr net.Addr)) Option {
+	return func(c *config) error {
+		if hook == nil {
+			return fmt.Errorf("parameter error in WithOnTransmit")
+		}
+		c.transmitHook = hook
+		return nil
+	}
+}
+
+// WithAllowEmptyDatagrams sets AllowEmptyDatagrams so that zero-length
+// datagrams can be transmitted and received.
+func WithAllowEmptyDatagrams() Option {
+	return func(c *config) error {
+		c.allowEmpty = true
+		return nil
+	}
+}
+
+// WithDropCounter sets DropCounter so that KernelDrops and Stats report the
+// datagrams dropped by the kernel, on Linux only.
+func WithDropCounter() Option {
+	return func(c *config) error {
+		c.dropCounter = true
+		return nil
+	}
+}
+
+// WithRemoteChangeHook sets the RemoteChangeHook called when a datagram
+// arrives from another sender than the previous one.
+func WithRemoteChangeHook(hook func(old, new net.Addr)) Option {
+	return func(c *config) error {
+		if hook == nil {
+			return fmt.Errorf("parameter error in WithRemoteChangeHook")
+		}
+		c.remoteChange = hook
+		return nil
+	}
+}
+
+// WithPacing sets PacingGap so that consecutive transmissions start at
+// least gap apart.
+func WithPacing(gap time.Duration) Option {
+	return func(c *config) error {
+		if gap <= 0 {
+			return fmt.Errorf("parameter error in WithPacing - invalid gap %v", gap)
+		}
+		c.pacingGap = gap
+		return nil
+	}
+}
+
+// WithErrorHistory sets ErrorHistory so that RecentErrors returns up to the
+// last size errors.
+func WithErrorHistory(size int) Option {
+	return func(c *config) error {
+		if size <= 0 {
+			return fmt.Errorf("parameter error in WithErrorHistory - invalid size %d", size)
+		}
+		c.errorHistory = size
+		return nil
+	}
+}
+
+// NewUDPClientWithOptions creates a local UDP client configured by opts,
+// unset options keep the defaults of NewUDPClient.
+func NewUDPClientWithOptions(opts ...Option) (*UDPClient, error) {
+	c, err := newConfig("NewUDPClientWithOptions", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &UDPClient{}
+	c.apply(u)
+	if err = u.Bind(c.laddr); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// newConfig returns the configuration opts make of the defaults, naming the
+// constructor op in the error of an invalid option.
+func newConfig(op string, opts []Option) (*config, error) {
+	c := &config{
+		readDeadline:         ReadDeadline,
+		writeDeadline:        WriteDeadline,
+		compressionThreshold: DefaultCompressionThreshold,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("failed to apply option in %s - %w", op, err)
+		}
+	}
+	return c, nil
+}
+
+// apply configures u as c describes, except for the local UDP address. The
+// buffer sizes are only recorded, to be applied to the socket by Bind or by
+// the constructor adopting one.
+func (c *config) apply(u *UDPClient) {
+	u.network = c.network
+	u.unixAddr = c.unixAddr
+	u.ReusePort = c.reusePort
+	u.Interface = c.ifi
+	u.Logger = c.logger
+	u.Slog = c.slog
+	u.checksum = c.checksum
+	u.compression = c.compression
+	u.compressionThreshold = c.compressionThreshold
+	u.AutoReconnect = c.autoReconnect
+	u.OrderedDispatch = c.orderedDispatch
+	u.ReadBudget = c.readBudget
+	u.ReceiveRetry = c.receiveRetry
+	u.Readers = c.readers
+	u.SharedResolveCache = c.resolveCache
+	u.CopyThreshold = c.copyThreshold
+	u.JitterEstimate = c.jitterEstimate
+	u.TransmitHook = c.transmitHook
+	u.AllowEmptyDatagrams = c.allowEmpty
+	u.DropCounter = c.dropCounter
+	u.RemoteChangeHook = c.remoteChange
+	u.PacingGap = c.pacingGap
+	u.ErrorHistory = c.errorHistory
+	if c.rateLimit > 0 {
+		u.limiter = newLimiter(c.rateLimit)
+		u.rateNonBlocking = c.rateNonBlocking
+	}
+	if c.sourceRate > 0 {
+		u.sourceLimits = newSourceLimiter(c.sourceRate, c.sourceBurst)
+	}
+	u.ReadDeadline = c.readDeadline
+	u.WriteDeadline = c.writeDeadline
+	u.readBuffer, u.writeBuffer = c.readBuffer, c.writeBuffer
+}
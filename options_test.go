@@ -0,0 +1,253 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewUDPClientWithOptions(t *testing.T) {
+	const bufferSize = 64 << 10
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithReadDeadline(time.Second),
+		WithWriteDeadline(2*time.Second),
+		WithBufferSize(bufferSize),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	if u.ReadDeadline != time.Second {
+		t.Errorf("expected ReadDeadline %v got %v", time.Second, u.ReadDeadline)
+	}
+	if u.WriteDeadline != 2*time.Second {
+		t.Errorf("expected WriteDeadline %v got %v", 2*time.Second, u.WriteDeadline)
+	}
+	if ip := u.LocalAddr().(*net.UDPAddr).IP; !ip.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("expected local address 127.0.0.1 got %v", ip)
+	}
+	if opts, err := u.SocketOptions(); err == nil {
+		if opts.ReadBuffer < bufferSize || opts.WriteBuffer < bufferSize {
+			t.Errorf("expected buffers of at least %d got %d and %d",
+				bufferSize, opts.ReadBuffer, opts.WriteBuffer)
+		}
+	}
+}
+
+func TestNewUDPClientWithOptions_Fields(t *testing.T) {
+	var transmitted, changed bool
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithJitterEstimate(),
+		WithOnTransmit(func(n int, addr net.Addr) { transmitted = n == 0 }),
+		WithAllowEmptyDatagrams(),
+		WithRemoteChangeHook(func(old, new net.Addr) { changed = true }),
+		WithPacing(time.Millisecond),
+		WithErrorHistory(4),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	if !u.JitterEstimate || !u.AllowEmptyDatagrams || u.PacingGap != time.Millisecond || u.ErrorHistory != 4 {
+		t.Errorf("expected the options applied got %+v", u)
+	}
+	if _, err = u.Transmit(u.LocalAddr().(*net.UDPAddr), nil); err != nil {
+		t.Fatal("failed to transmit an empty datagram -", err)
+	}
+	if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+		t.Fatal("failed to receive an empty datagram -", err)
+	}
+	u.RemoteChangeHook(nil, nil)
+	if !transmitted || !changed {
+		t.Errorf("expected the hooks set got transmit %v and remote change %v", transmitted, changed)
+	}
+
+	if u, err = NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithDropCounter(),
+	); err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	if !u.DropCounter {
+		t.Error("expected DropCounter set")
+	}
+}
+
+func TestWithReadWriteBufferSize(t *testing.T) {
+	const readSize, writeSize = 8 << 20, 32 << 10
+	var logger captureLogger
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithLogger(&logger),
+		WithReadBufferSize(readSize),
+		WithWriteBufferSize(writeSize),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	read, err := u.ReadBufferSize()
+	if err != nil {
+		t.Skip("buffer sizes cannot be read back -", err)
+	}
+	write, err := u.WriteBufferSize()
+	if err != nil {
+		t.Fatal("failed to read send buffer size -", err)
+	}
+	if write < writeSize {
+		t.Errorf("expected send buffer of at least %d got %d", writeSize, write)
+	}
+	// The kernel limits may clamp the large receive buffer, which is logged
+	if clamped := logger.contains("receive buffer clamped"); clamped != (read < readSize) {
+		t.Errorf("expected clamping of %d to %d logged %v got %q", readSize, read, read < readSize, logger.msgs)
+	}
+}
+
+func TestNewUDPClientWithOptions_Invalid(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"read deadline":  WithReadDeadline(-time.Second),
+		"write deadline": WithWriteDeadline(-time.Second),
+		"buffer size":    WithBufferSize(0),
+		"read buffer":    WithReadBufferSize(-1),
+		"write buffer":   WithWriteBufferSize(0),
+		"transmit hook":  WithOnTransmit(nil),
+		"remote hook":    WithRemoteChangeHook(nil),
+		"pacing":         WithPacing(0),
+		"error history":  WithErrorHistory(-1),
+	} {
+		u, err := NewUDPClientWithOptions(WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), opt)
+		if err == nil {
+			u.Close()
+			t.Errorf("expected Error for invalid %s got nil", name)
+		}
+	}
+}
+
+func TestNewUDPClientWithOptions_Network(t *testing.T) {
+	if _, err := NewUDPClientWithOptions(WithNetwork("tcp")); err == nil {
+		t.Error("expected Error for unknown network got nil")
+	}
+
+	u, err := NewUDPClientWithOptions(WithNetwork("udp4"), WithLocalAddr(&net.UDPAddr{IP: net.IPv6loopback}))
+	if err == nil {
+		u.Close()
+		t.Error("expected Error binding udp4 to an IPv6 address got nil")
+	}
+
+	u, err = NewUDPClientWithOptions(WithNetwork("udp6"), WithLocalAddr(&net.UDPAddr{IP: net.IPv6unspecified}))
+	if err != nil {
+		t.Skip("IPv6 unavailable -", err)
+	}
+	defer u.Close()
+	_, err = u.Transmit(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}, []byte("testing"))
+	if !errors.Is(err, ErrAddressFamilyMismatch) {
+		t.Errorf("expected ErrAddressFamilyMismatch on udp6 socket got %v", err)
+	}
+}
+
+func TestNewUDPClientWithOptions_IPv6(t *testing.T) {
+	laddrs := []*net.UDPAddr{{IP: net.IPv6loopback}}
+	if ll := linkLocalAddr(); ll != nil {
+		laddrs = append(laddrs, ll)
+	}
+
+	for _, laddr := range laddrs {
+		t.Run(laddr.String(), func(t *testing.T) {
+			a, err := NewUDPClientWithOptions(WithNetwork("udp6"), WithLocalAddr(laddr))
+			if err != nil {
+				t.Skip("address unavailable -", err)
+			}
+			defer a.Close()
+			b, err := NewUDPClientWithOptions(WithNetwork("udp6"), WithLocalAddr(laddr))
+			if err != nil {
+				t.Fatal("failed to create peer -", err)
+			}
+			defer b.Close()
+
+			message := "Every cloud has a silver lining"
+			if _, err = a.Transmit(b.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			buf := make([]byte, maxBufferSize)
+			n, addr, err := b.ReceiveFrom(buf)
+			if err != nil {
+				t.Fatal("failed to receive -", err)
+			}
+			if string(buf[:n]) != message {
+				t.Errorf("expected %q got %q", message, buf[:n])
+			}
+			if addr.String() != a.LocalAddr().String() {
+				t.Errorf("expected sender %v got %v", a.LocalAddr(), addr)
+			}
+		})
+	}
+}
+
+// linkLocalAddr returns a scoped IPv6 link-local address of the host, nil
+// when there is none.
+func linkLocalAddr() *net.UDPAddr {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if ok && ipnet.IP.To4() == nil && ipnet.IP.IsLinkLocalUnicast() {
+				return &net.UDPAddr{IP: ipnet.IP, Zone: ifi.Name}
+			}
+		}
+	}
+	return nil
+}
+
+func TestNewUDPClientWithOptions_ReusePort(t *testing.T) {
+	a, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithReusePort(true),
+	)
+	if err != nil {
+		t.Skip("SO_REUSEPORT unavailable -", err)
+	}
+	defer a.Close()
+	if !a.ReusePort {
+		t.Error("expected ReusePort to be set")
+	}
+
+	laddr := a.LocalAddr().(*net.UDPAddr)
+	b, err := NewUDPClientWithOptions(WithLocalAddr(laddr), WithReusePort(true))
+	if err != nil {
+		t.Fatal("failed to bind the same port -", err)
+	}
+	defer b.Close()
+	if b.LocalAddr().String() != laddr.String() {
+		t.Errorf("expected %v got %v", laddr, b.LocalAddr())
+	}
+
+	// Without the option the port stays exclusive
+	c, err := NewUDPClient(laddr)
+	if !errors.Is(err, ErrAddrInUse) {
+		if err == nil {
+			c.Close()
+		}
+		t.Errorf("expected ErrAddrInUse got %v", err)
+	}
+}
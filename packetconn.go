@@ -0,0 +1,54 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// UDPClient can be used wherever a net.PacketConn is expected.
+var _ net.PacketConn = (*UDPClient)(nil)
+
+// ReadFrom implements net.PacketConn with ReceiveFrom, the read deadline is
+// the one set by SetReadDeadline or else ReadDeadline. The peers of a
+// unixgram client are returned as a *net.UnixAddr.
+func (u *UDPClient) ReadFrom(p []byte) (int, net.Addr, error) {
+	if u == nil || u.conn == nil {
+		return 0, nil, fmt.Errorf("failed to Receive due to uninitialized client")
+	}
+	return u.receiveFrom(p, u.nextReadDeadline())
+}
+
+// WriteTo implements net.PacketConn with Transmit. A *net.UnixAddr is
+// transmitted to as is, for a unixgram client, while the other addresses
+// than a *net.UDPAddr are resolved from their string form.
+func (u *UDPClient) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if addr == nil {
+		return 0, fmt.Errorf("parameter error in WriteTo")
+	}
+
+	switch addr.(type) {
+	case *net.UDPAddr, *net.UnixAddr:
+	default:
+		var err error
+		addr, err = net.ResolveUDPAddr("udp", addr.String())
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve address in WriteTo - %w", err)
+		}
+	}
+
+	return u.transmit(addr, p)
+}
+
+// SetDeadline sets both the read and write deadlines like SetReadDeadline
+// and SetWriteDeadline. A zero t clears them.
+func (u *UDPClient) SetDeadline(t time.Time) error {
+	if err := u.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return u.SetWriteDeadline(t)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_PacketConn(t *testing.T) {
+	a, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer a.Close()
+	b, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer b.Close()
+
+	var pa, pb net.PacketConn = a, b
+
+	message := "A rolling stone gathers no moss"
+	if _, err = pa.WriteTo([]byte(message), pb.LocalAddr()); err != nil {
+		t.Fatal("failed to write -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, addr, err := pb.ReadFrom(buf)
+	if err != nil {
+		t.Fatal("failed to read -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+	if addr.String() != pa.LocalAddr().String() {
+		t.Errorf("expected sender %v got %v", pa.LocalAddr(), addr)
+	}
+
+	// Addresses of other types are resolved from their string form
+	if _, err = pa.WriteTo([]byte(message), &net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}); err == nil {
+		t.Error("expected Error(address without port) got nil")
+	}
+
+	if err = pb.SetDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal("failed to set deadline -", err)
+	}
+	_, addr, err = pb.ReadFrom(buf)
+	if !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+	if addr != nil {
+		t.Errorf("expected nil address on error got %v", addr)
+	}
+	if _, err = pb.WriteTo([]byte(message), pa.LocalAddr()); !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+}
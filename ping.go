@@ -0,0 +1,146 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"time"
+)
+
+// pingHeaderSize is the length of the session id and sequence number that
+// prefix every probe sent by PingN.
+const pingHeaderSize = 8
+
+// LatencyStats summarizes the round trip times measured by PingN.
+type LatencyStats struct {
+	Sent     int
+	Received int
+	Loss     float64 // percentage of probes without a reply
+
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+}
+
+// PingN sends count probes to addr, one every interval, and measures the
+// round trip time of each reply. The peer must echo the datagrams back
+// unchanged, as the echo server does. Each probe carries payload after an
+// 8 byte header identifying it. A probe without a reply within interval
+// counts as lost. If ctx ends early the statistics of the probes sent so far
+// are returned along with the context error.
+func (u *UDPClient) PingN(ctx context.Context, addr *net.UDPAddr, payload []byte, count int,
+	interval time.Duration) (LatencyStats, error) {
+	var stats LatencyStats
+	if u == nil || u.conn == nil {
+		return stats, fmt.Errorf("failed to PingN due to uninitialized client")
+	}
+
+	if addr == nil || count < 1 || interval <= 0 {
+		return stats, fmt.Errorf("parameter error in PingN")
+	}
+
+	probe := make([]byte, pingHeaderSize+len(payload))
+	if _, err := rand.Read(probe[:4]); err != nil {
+		return stats, fmt.Errorf("failed to generate session id in PingN - %w", err)
+	}
+	copy(probe[pingHeaderSize:], payload)
+
+	rtts := make([]time.Duration, 0, count)
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+	rb := *bp
+	var err error
+	for seq := 0; seq < count && err == nil; seq++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		binary.BigEndian.PutUint32(probe[4:], uint32(seq))
+		sent := time.Now()
+		next := sent.Add(interval)
+		if _, err = u.Transmit(addr, probe); err != nil {
+			break
+		}
+		stats.Sent++
+
+		deadline := next
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err = u.applyReadDeadline(deadline); err != nil {
+			break
+		}
+
+		for {
+			n, flags, from, rerr := u.read(rb)
+			if rerr != nil {
+				if !errors.Is(rerr, os.ErrDeadlineExceeded) {
+					err = fmt.Errorf("failed to read reply in PingN - %w", rerr)
+				}
+				break
+			}
+			n, rerr = u.process(rb, n, flags, from)
+			if rerr == nil && bytes.Equal(rb[:n], probe) {
+				rtts = append(rtts, time.Since(sent))
+				break
+			}
+		}
+
+		// Keep the cadence for the remaining probes
+		if seq < count-1 && err == nil {
+			select {
+			case <-time.After(time.Until(next)):
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	stats.summarize(rtts)
+	if err == nil {
+		err = ctx.Err()
+	}
+	return stats, err
+}
+
+// summarize fills the statistics from the measured round trip times.
+func (s *LatencyStats) summarize(rtts []time.Duration) {
+	s.Received = len(rtts)
+	if s.Sent > 0 {
+		s.Loss = 100 * float64(s.Sent-s.Received) / float64(s.Sent)
+	}
+	if len(rtts) == 0 {
+		return
+	}
+
+	slices.Sort(rtts)
+	var total time.Duration
+	for _, rtt := range rtts {
+		total += rtt
+	}
+	s.Min = rtts[0]
+	s.Max = rtts[len(rtts)-1]
+	s.Mean = total / time.Duration(len(rtts))
+	s.P50 = percentile(rtts, 50)
+	s.P95 = percentile(rtts, 95)
+	s.P99 = percentile(rtts, 99)
+}
+
+// percentile returns the nearest-rank percentile p of the sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_PingN(t *testing.T) {
+	responder, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create responder -", err)
+	}
+	defer responder.Close()
+	responder.ReadDeadline = time.Second
+
+	const (
+		count    = 5
+		delay    = 10 * time.Millisecond
+		interval = 100 * time.Millisecond
+		dropped  = 2
+	)
+
+	// Echo with a delay, ignoring one probe
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		for i := 0; i < count; i++ {
+			n, err := responder.Receive(buf)
+			if err != nil {
+				return
+			}
+			if i == dropped {
+				continue
+			}
+			time.Sleep(delay)
+			_, _ = responder.Transmit(responder.RemoteAddr.(*net.UDPAddr), buf[:n])
+		}
+	}()
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	stats, err := u.PingN(context.Background(), responder.LocalAddr().(*net.UDPAddr),
+		[]byte("ping"), count, interval)
+	if err != nil {
+		t.Fatal("failed to ping -", err)
+	}
+	t.Logf("%+v", stats)
+
+	if stats.Sent != count || stats.Received != count-1 {
+		t.Errorf("expected %d sent and %d received got %d and %d", count, count-1, stats.Sent, stats.Received)
+	}
+	if stats.Loss != 20 {
+		t.Errorf("expected 20%% loss got %v", stats.Loss)
+	}
+	if stats.Min < delay || stats.Max >= interval {
+		t.Errorf("expected round trips between %v and %v got %v to %v", delay, interval, stats.Min, stats.Max)
+	}
+	if !(stats.Min <= stats.P50 && stats.P50 <= stats.P95 && stats.P95 <= stats.P99 && stats.P99 <= stats.Max) {
+		t.Errorf("expected ordered percentiles got %+v", stats)
+	}
+	if stats.Mean < stats.Min || stats.Mean > stats.Max {
+		t.Errorf("expected mean within range got %v", stats.Mean)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := make([]time.Duration, 100)
+	for i := range values {
+		values[i] = time.Duration(i+1) * time.Millisecond
+	}
+	for p, want := range map[float64]time.Duration{
+		50: 50 * time.Millisecond,
+		95: 95 * time.Millisecond,
+		99: 99 * time.Millisecond,
+	} {
+		if got := percentile(values, p); got != want {
+			t.Errorf("expected p%v %v got %v", p, want, got)
+		}
+	}
+	if got := percentile(values[:1], 99); got != time.Millisecond {
+		t.Errorf("expected single value percentile got %v", got)
+	}
+}
+
+func TestUDPClient_PingN_Cancel(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	stats, err := u.PingN(ctx, u.LocalAddr().(*net.UDPAddr), nil, 100, 100*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded got %v", err)
+	}
+	if stats.Sent == 0 || stats.Sent >= 100 {
+		t.Errorf("expected a partial run got %d probes", stats.Sent)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// PacketInfoOOBSize is the size of an oob buffer for ReceiveMsg large enough
+// for the control messages enabled by SetPacketInfo.
+var PacketInfoOOBSize = max(
+	len(ipv4.NewControlMessage(ipv4.FlagDst|ipv4.FlagInterface)),
+	len(ipv6.NewControlMessage(ipv6.FlagDst|ipv6.FlagInterface)),
+)
+
+// SetPacketInfo enables or disables the IP_PKTINFO (IPV6_PKTINFO for IPv6
+// sockets) control messages telling the destination address and the
+// interface of every datagram read by ReceiveMsg. A server bound to the
+// wildcard address learns this way which of its addresses a datagram was
+// sent to. Unsupported on Windows.
+func (u *UDPClient) SetPacketInfo(on bool) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetPacketInfo due to uninitialized client")
+	}
+
+	var err error
+	if u.isIPv6() {
+		err = ipv6.NewPacketConn(u.conn).SetControlMessage(ipv6.FlagDst|ipv6.FlagInterface, on)
+	} else {
+		err = ipv4.NewPacketConn(u.conn).SetControlMessage(ipv4.FlagDst|ipv4.FlagInterface, on)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set packet info in SetPacketInfo - %w", err)
+	}
+
+	return nil
+}
+
+// ReceiveMsg reads a datagram into rb like ReceiveFrom along with its
+// control messages into oob, returning the sizes of both, the message flags
+// and the sender. Use ParsePacketInfo on oob[:oobn] to get the destination
+// address once SetPacketInfo is enabled.
+func (u *UDPClient) ReceiveMsg(rb, oob []byte) (
+	n, oobn, flags int,
+	addr *net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveMsg due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("ReceiveMsg", err) }()
+
+	if len(rb) == 0 {
+		err = fmt.Errorf("parameter error in ReceiveMsg")
+		return
+	}
+
+	err = u.applyReadDeadline(u.nextReadDeadline())
+	if err != nil {
+		err = fmt.Errorf("failed in setting read deadline in ReceiveMsg - %w", err)
+		return
+	}
+
+	for {
+		n, err = retryEINTR(func() (n int, err error) {
+			n, oobn, flags, addr, err = u.conn.ReadMsgUDP(rb, oob)
+			return
+		})
+		if err != nil || u.accepts(addr) {
+			break
+		}
+	}
+	if err != nil {
+		n, oobn, addr = 0, 0, nil
+		err = fmt.Errorf("failed to read data in ReceiveMsg - %w", err)
+		return
+	}
+
+	n, err = u.process(rb, n, flags, addr)
+	return
+}
+
+// ParsePacketInfo returns the destination address and the index of the
+// receiving interface from the control messages of a datagram read by
+// ReceiveMsg with SetPacketInfo enabled.
+func ParsePacketInfo(oob []byte) (dst net.IP, ifIndex int, err error) {
+	var cm4 ipv4.ControlMessage
+	if err = cm4.Parse(oob); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse control message in ParsePacketInfo - %w", err)
+	}
+	if cm4.Dst != nil {
+		return cm4.Dst, cm4.IfIndex, nil
+	}
+
+	var cm6 ipv6.ControlMessage
+	if err = cm6.Parse(oob); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse control message in ParsePacketInfo - %w", err)
+	}
+	if cm6.Dst != nil {
+		return cm6.Dst, cm6.IfIndex, nil
+	}
+
+	return nil, 0, fmt.Errorf("failed in ParsePacketInfo - no packet info found")
+}
+
+// TransmitFrom works like Transmit but sends the datagram from the local
+// address src, carried in an IP_PKTINFO (IPV6_PKTINFO for IPv6 sockets)
+// control message. A server bound to the wildcard address replies this way
+// from the address the request was sent to, as told by ParsePacketInfo,
+// rather than from the one the routing table prefers, which peers on
+// multihomed hosts would not recognize. The port of src is ignored, the
+// datagram always leaves from the port of the client. It is supported on
+// Linux only.
+func (u *UDPClient) TransmitFrom(src, dst *net.UDPAddr, data []byte) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to TransmitFrom due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("TransmitFrom", err) }()
+
+	if src == nil || src.IP == nil || dst == nil || (len(data) == 0 && !u.AllowEmptyDatagrams) {
+		err = fmt.Errorf("parameter error in TransmitFrom")
+		return
+	}
+
+	if u.quiesced.Load() {
+		err = fmt.Errorf("failed to TransmitFrom - %w", ErrQuiesced)
+		return
+	}
+
+	oob, err := u.srcControlMessage(src.IP)
+	if err != nil {
+		err = fmt.Errorf("failed to build source control message in TransmitFrom - %w", err)
+		return
+	}
+
+	return u.writeDatagram("TransmitFrom", dst, data, time.Time{}, func(wire []byte) (n int, err error) {
+		n, _, err = u.conn.WriteMsgUDP(wire, oob, dst)
+		return
+	})
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// srcControlMessage builds an IP_PKTINFO or IPV6_PKTINFO control message
+// matching the family of the socket, which selects src as the source
+// address. IPv4 sources of an IPv6 socket are sent as mapped addresses.
+func (u *UDPClient) srcControlMessage(src net.IP) ([]byte, error) {
+	if u.isIPv6() {
+		return (&ipv6.ControlMessage{Src: src}).Marshal(), nil
+	}
+	return (&ipv4.ControlMessage{Src: src}).Marshal(), nil
+}
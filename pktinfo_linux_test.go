@@ -0,0 +1,61 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestUDPClient_TransmitFrom(t *testing.T) {
+	// All of 127.0.0.0/8 is local on Linux, so a wildcard server is
+	// reachable on 127.0.0.2 while its default source stays 127.0.0.1
+	server, err := NewUDPClientWithOptions(WithNetwork("udp4"), WithLocalAddr(&net.UDPAddr{IP: net.IPv4zero}))
+	if err != nil {
+		t.Fatal("failed to create server -", err)
+	}
+	defer server.Close()
+	if err = server.SetPacketInfo(true); err != nil {
+		t.Fatal("failed to enable packet info -", err)
+	}
+	port := server.LocalAddr().(*net.UDPAddr).Port
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: port}
+	if _, err = peer.Transmit(dst, []byte("ping")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+
+	rb := make([]byte, maxBufferSize)
+	oob := make([]byte, PacketInfoOOBSize)
+	_, oobn, _, from, err := server.ReceiveMsg(rb, oob)
+	if err != nil {
+		t.Fatal("failed to receive request -", err)
+	}
+	local, _, err := ParsePacketInfo(oob[:oobn])
+	if err != nil {
+		t.Fatal("failed to parse packet info -", err)
+	}
+
+	if _, err = server.TransmitFrom(&net.UDPAddr{IP: local}, from, []byte("pong")); err != nil {
+		t.Fatal("failed to reply -", err)
+	}
+	n, err := peer.Receive(rb)
+	if err != nil {
+		t.Fatal("failed to receive reply -", err)
+	}
+	if string(rb[:n]) != "pong" || peer.RemoteAddr.String() != dst.String() {
+		t.Errorf("expected reply from %v got %q from %v", dst, rb[:n], peer.RemoteAddr)
+	}
+
+	if _, err = server.TransmitFrom(nil, from, []byte("pong")); err == nil {
+		t.Errorf("expected error for missing source")
+	}
+}
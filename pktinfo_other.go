@@ -0,0 +1,18 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// srcControlMessage reports that selecting the source address is
+// unsupported.
+func (u *UDPClient) srcControlMessage(src net.IP) ([]byte, error) {
+	return nil, fmt.Errorf("source address selection is only supported on linux")
+}
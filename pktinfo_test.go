@@ -0,0 +1,64 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestUDPClient_ReceiveMsg(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("packet info is unsupported on windows")
+	}
+
+	// Bound to the wildcard address the destination is only known from the
+	// packet info
+	u, err := NewUDPClientWithOptions(WithNetwork("udp4"), WithLocalAddr(&net.UDPAddr{IP: net.IPv4zero}))
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	if err = u.SetPacketInfo(true); err != nil {
+		t.Fatal("failed to enable packet info -", err)
+	}
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: u.LocalAddr().(*net.UDPAddr).Port}
+	if _, err = peer.Transmit(dst, []byte("where am I")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+
+	rb := make([]byte, maxBufferSize)
+	oob := make([]byte, PacketInfoOOBSize)
+	n, oobn, _, from, err := u.ReceiveMsg(rb, oob)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(rb[:n]) != "where am I" || from.String() != peer.LocalAddr().String() {
+		t.Errorf("expected datagram from %v got %q from %v", peer.LocalAddr(), rb[:n], from)
+	}
+
+	ip, ifIndex, err := ParsePacketInfo(oob[:oobn])
+	if err != nil {
+		t.Fatal("failed to parse packet info -", err)
+	}
+	if !ip.Equal(dst.IP) {
+		t.Errorf("expected destination %v got %v", dst.IP, ip)
+	}
+	if ifIndex == 0 {
+		t.Errorf("expected the interface index populated")
+	}
+
+	if _, _, err = ParsePacketInfo(nil); err == nil {
+		t.Errorf("expected error without packet info")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// Query transmits req to addr and reads the reply into resp, waiting up to
+// timeout for it. On a timeout req is transmitted again, up to QueryRetries
+// more times. When QueryVerifySource is set, datagrams from other senders
+// than addr are skipped. Empty or undecodable datagrams are skipped in any
+// case, as are truncated ones from other senders. It returns the size and
+// the sender of the reply, leaving RemoteAddr untouched.
+func (u *UDPClient) Query(addr *net.UDPAddr, req []byte, resp []byte, timeout time.Duration) (
+	n int,
+	from *net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to Query due to uninitialized client")
+		return
+	}
+
+	if addr == nil || len(resp) == 0 || timeout <= 0 {
+		err = fmt.Errorf("parameter error in Query")
+		return
+	}
+
+	attempts := 1 + max(u.QueryRetries, 0)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if _, err = u.Transmit(addr, req); err != nil {
+			err = fmt.Errorf("failed to send request in Query - %w", err)
+			return
+		}
+
+		n, from, err = u.awaitReply(addr, resp, time.Now().Add(timeout))
+		if IsTimeout(err) {
+			if attempt+1 < attempts {
+				u.logf("udp: no reply from %v within %v, retrying (%d/%d)", addr, timeout, attempt+1, attempts-1)
+			}
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to receive reply in Query - %w", err)
+		}
+		return
+	}
+
+	n, from = 0, nil
+	err = fmt.Errorf("failed to receive reply in Query after %d attempts - %w", attempts, err)
+	return
+}
+
+// awaitReply reads the reply to a request sent to addr until deadline,
+// skipping datagrams from other senders when QueryVerifySource is set.
+// Datagrams dropped on reception are not taken for the reply and skipped as
+// well, except a truncated one from addr which is the reply too large for
+// resp.
+func (u *UDPClient) awaitReply(addr *net.UDPAddr, resp []byte, deadline time.Time) (
+	n int,
+	from *net.UDPAddr,
+	err error,
+) {
+	for {
+		var sender net.Addr
+		n, sender, err = u.receiveFrom(resp, deadline)
+		from = udpAddr(sender)
+		if from == nil {
+			return
+		}
+		if u.QueryVerifySource && !sameUDPAddr(from, addr) {
+			continue
+		}
+		if err != nil && !(errors.Is(err, ErrTruncated) && sameUDPAddr(from, addr)) {
+			continue
+		}
+		return
+	}
+}
+
+// sameUDPAddr reports whether a and b are the same IP address and port.
+func sameUDPAddr(a, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.Port == b.Port && a.IP.Equal(b.IP)
+}
+
+// RetryPolicy controls the retransmissions of QueryWithRetry.
+type RetryPolicy struct {
+	// MaxRetries is the number of times the request is transmitted again
+	// when no reply arrived in time.
+	MaxRetries int
+
+	// InitialBackoff is the time waited for the reply to the first
+	// transmission, zero selects the ReadDeadline of the client.
+	InitialBackoff time.Duration
+
+	// Multiplier scales the waiting time after each attempt, values below 1
+	// keep it constant.
+	Multiplier float64
+
+	// Jitter randomizes every waiting time between half and all of its
+	// value, so that clients retrying together spread out.
+	Jitter bool
+}
+
+// backoff returns the time to wait for a reply at the given attempt,
+// counted from zero, after starting with initial.
+func (rp RetryPolicy) backoff(attempt int, initial time.Duration) time.Duration {
+	d := float64(initial)
+	for i := 0; i < attempt && rp.Multiplier > 1; i++ {
+		d *= rp.Multiplier
+	}
+	if rp.Jitter {
+		d = d/2 + rand.Float64()*d/2
+	}
+	return time.Duration(d)
+}
+
+// QueryWithRetry transmits req to addr and returns the first reply, which is
+// waited for with an exponentially growing backoff following rp. The request
+// is transmitted again on every retry. After all attempts timed out their
+// errors are returned joined. A reply larger than MaxPacketSize fails with
+// ErrTruncated, QueryVerifySource applies as for Query.
+func (u *UDPClient) QueryWithRetry(addr *net.UDPAddr, req []byte, rp RetryPolicy) ([]byte, error) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to QueryWithRetry due to uninitialized client")
+	}
+
+	initial := rp.InitialBackoff
+	if initial == 0 {
+		initial = u.ReadDeadline
+	}
+	if addr == nil || rp.MaxRetries < 0 || initial <= 0 {
+		return nil, fmt.Errorf("parameter error in QueryWithRetry")
+	}
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+
+	var errs []error
+	for attempt := 0; attempt <= rp.MaxRetries; attempt++ {
+		if _, err := u.Transmit(addr, req); err != nil {
+			return nil, fmt.Errorf("failed to send request in QueryWithRetry - %w", err)
+		}
+
+		wait := rp.backoff(attempt, initial)
+		n, _, err := u.awaitReply(addr, *bp, time.Now().Add(wait))
+		if IsTimeout(err) {
+			errs = append(errs, fmt.Errorf("attempt %d timed out after %v - %w", attempt+1, wait, err))
+			if attempt < rp.MaxRetries {
+				u.logf("udp: no reply from %v within %v, retrying (%d/%d)", addr, wait, attempt+1, rp.MaxRetries)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive reply in QueryWithRetry - %w", err)
+		}
+
+		reply := make([]byte, n)
+		copy(reply, (*bp)[:n])
+		return reply, nil
+	}
+
+	return nil, fmt.Errorf("failed to receive reply in QueryWithRetry after %d attempts - %w",
+		rp.MaxRetries+1, errors.Join(errs...))
+}
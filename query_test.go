@@ -0,0 +1,246 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startEcho runs an echo server on loopback until the test ends. It ignores
+// the first drop datagrams, like a lossy network, and reports the number of
+// datagrams it got through received when not nil.
+func startEcho(t testing.TB, drop int, received *atomic.Int32) *net.UDPAddr {
+	t.Helper()
+	echo, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create echo server -", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		echo.Close()
+	})
+
+	go func() {
+		defer close(done)
+		for dg, err := range echo.Datagrams(ctx) {
+			if err != nil {
+				return
+			}
+			if received != nil {
+				received.Add(1)
+			}
+			if drop > 0 {
+				drop--
+				continue
+			}
+			echo.Transmit(dg.Addr, dg.Data)
+		}
+	}()
+	return echo.LocalAddr().(*net.UDPAddr)
+}
+
+func TestUDPClient_Query(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.QueryVerifySource = true
+	resp := make([]byte, maxBufferSize)
+
+	t.Run("success", func(t *testing.T) {
+		addr := startEcho(t, 0, nil)
+		n, from, err := u.Query(addr, []byte("ping"), resp, time.Second)
+		if err != nil {
+			t.Fatal("failed to query -", err)
+		}
+		if string(resp[:n]) != "ping" {
+			t.Errorf("expected %q got %q", "ping", resp[:n])
+		}
+		if !sameUDPAddr(from, addr) {
+			t.Errorf("expected reply from %v got %v", addr, from)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var received atomic.Int32
+		addr := startEcho(t, 10, &received)
+		u.QueryRetries = 2
+		defer func() { u.QueryRetries = 0 }()
+		_, _, err := u.Query(addr, []byte("ping"), resp, 20*time.Millisecond)
+		if !IsTimeout(err) {
+			t.Errorf("expected timeout got %v", err)
+		}
+		if got := received.Load(); got != 3 {
+			t.Errorf("expected 3 attempts got %d", got)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		addr := startEcho(t, 1, nil)
+		u.QueryRetries = 1
+		defer func() { u.QueryRetries = 0 }()
+		if _, _, err := u.Query(addr, []byte("ping"), resp, 50*time.Millisecond); err != nil {
+			t.Error("failed to query with a retry -", err)
+		}
+	})
+
+	t.Run("wrong source", func(t *testing.T) {
+		// The target never replies while an impostor does
+		target, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create target -", err)
+		}
+		defer target.Close()
+		impostor, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create impostor -", err)
+		}
+		defer impostor.Close()
+		dst := target.LocalAddr().(*net.UDPAddr)
+		spoof := func() {
+			impostor.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("spoofed"))
+		}
+
+		spoof()
+		_, _, err = u.Query(dst, []byte("ping"), resp, 50*time.Millisecond)
+		if !IsTimeout(err) {
+			t.Errorf("expected the spoofed reply to be skipped got %v", err)
+		}
+
+		u.QueryVerifySource = false
+		defer func() { u.QueryVerifySource = true }()
+		spoof()
+		n, from, err := u.Query(dst, []byte("ping"), resp, 50*time.Millisecond)
+		if err != nil {
+			t.Fatal("failed to query without verification -", err)
+		}
+		if string(resp[:n]) != "spoofed" || !sameUDPAddr(from, impostor.LocalAddr().(*net.UDPAddr)) {
+			t.Errorf("expected the spoofed reply got %q from %v", resp[:n], from)
+		}
+	})
+
+	t.Run("stray datagrams", func(t *testing.T) {
+		addr := startEcho(t, 0, nil)
+		stray, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create stray sender -", err)
+		}
+		defer stray.Close()
+		stray.AllowEmptyDatagrams = true
+
+		u.QueryVerifySource = false
+		defer func() { u.QueryVerifySource = true }()
+		small := make([]byte, 16)
+		for _, data := range [][]byte{nil, make([]byte, 64)} {
+			stray.Transmit(u.LocalAddr().(*net.UDPAddr), data)
+		}
+		n, _, err := u.Query(addr, []byte("ping"), small, time.Second)
+		if err != nil {
+			t.Fatal("failed to query past stray datagrams -", err)
+		}
+		if string(small[:n]) != "ping" {
+			t.Errorf("expected %q got %q", "ping", small[:n])
+		}
+
+		// A reply too large for the buffer is still reported
+		if _, _, err = u.Query(addr, make([]byte, 64), small, time.Second); !errors.Is(err, ErrTruncated) {
+			t.Errorf("expected ErrTruncated got %v", err)
+		}
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}
+		if _, _, err := u.Query(nil, []byte("ping"), resp, time.Second); err == nil {
+			t.Error("expected Error(nil address) got nil")
+		}
+		if _, _, err := u.Query(addr, []byte("ping"), nil, time.Second); err == nil {
+			t.Error("expected Error(empty response buffer) got nil")
+		}
+		if _, _, err := u.Query(addr, []byte("ping"), resp, 0); err == nil {
+			t.Error("expected Error(zero timeout) got nil")
+		}
+	})
+}
+
+func TestUDPClient_QueryWithRetry(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	rp := RetryPolicy{MaxRetries: 3, InitialBackoff: 10 * time.Millisecond, Multiplier: 2}
+
+	t.Run("lossy", func(t *testing.T) {
+		var received atomic.Int32
+		addr := startEcho(t, 2, &received)
+		start := time.Now()
+		reply, err := u.QueryWithRetry(addr, []byte("ping"), rp)
+		if err != nil {
+			t.Fatal("failed to query -", err)
+		}
+		if string(reply) != "ping" {
+			t.Errorf("expected %q got %q", "ping", reply)
+		}
+		if got := received.Load(); got != 3 {
+			t.Errorf("expected 3 transmissions got %d", got)
+		}
+		// Waited 10ms and 20ms for the lost ones
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("expected exponential backoff got %v", elapsed)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		var received atomic.Int32
+		addr := startEcho(t, 10, &received)
+		_, err := u.QueryWithRetry(addr, []byte("ping"), rp)
+		if !IsTimeout(err) {
+			t.Errorf("expected timeout got %v", err)
+		}
+		if got := received.Load(); got != 4 {
+			t.Errorf("expected 4 transmissions got %d", got)
+		}
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		if _, err := u.QueryWithRetry(nil, []byte("ping"), rp); err == nil {
+			t.Error("expected Error(nil address) got nil")
+		}
+		addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}
+		if _, err := u.QueryWithRetry(addr, []byte("ping"), RetryPolicy{MaxRetries: -1}); err == nil {
+			t.Error("expected Error(negative retries) got nil")
+		}
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	rp := RetryPolicy{Multiplier: 1.5}
+	for attempt, want := range []time.Duration{100, 150, 225} {
+		if got := rp.backoff(attempt, 100); got != want {
+			t.Errorf("expected backoff %v at attempt %d got %v", want, attempt, got)
+		}
+	}
+
+	rp.Jitter = true
+	for i := 0; i < 100; i++ {
+		if got := rp.backoff(1, 100); got < 75 || got > 150 {
+			t.Fatalf("expected jittered backoff within [75, 150] got %v", got)
+		}
+	}
+
+	if got := (RetryPolicy{}).backoff(3, 100); got != 100 {
+		t.Errorf("expected constant backoff got %v", got)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !race
+
+package udp
+
+// raceEnabled reports whether the race detector is on.
+const raceEnabled = false
@@ -0,0 +1,157 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned by Transmit when the rate limit set with
+// WithRateLimit is exceeded in non-blocking mode.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// WithRateLimit limits the datagrams transmitted by Transmit, Send and the
+// other transmit calls to packetsPerSecond per second, without bursts. By
+// default the calls block until they are allowed, see WithRateLimitBlocking.
+func WithRateLimit(packetsPerSecond int) Option {
+	return func(c *config) error {
+		if packetsPerSecond <= 0 {
+			return fmt.Errorf("parameter error in WithRateLimit - invalid rate %d", packetsPerSecond)
+		}
+		c.rateLimit = packetsPerSecond
+		return nil
+	}
+}
+
+// WithRateLimitBlocking selects whether the transmit calls block until the
+// rate limit allows them, the default, or fail at once with ErrRateLimited.
+func WithRateLimitBlocking(block bool) Option {
+	return func(c *config) error {
+		c.rateNonBlocking = !block
+		return nil
+	}
+}
+
+// WithPerSourceRateLimit limits the datagrams received from every source IP
+// address to packetsPerSecond, with bursts of up to burst datagrams. Those
+// exceeding the limit of their source are dropped by Receive, which goes on
+// with the next datagram, and counted in the RateLimited field of Stats. A
+// flooding sender thus does not crowd out the others, although its
+// datagrams are still read from the socket. The limit applies where the
+// source filter of SetAllowedSources does.
+func WithPerSourceRateLimit(packetsPerSecond, burst int) Option {
+	return func(c *config) error {
+		if packetsPerSecond <= 0 || burst <= 0 {
+			return fmt.Errorf("parameter error in WithPerSourceRateLimit - invalid rate %d or burst %d", packetsPerSecond, burst)
+		}
+		c.sourceRate = packetsPerSecond
+		c.sourceBurst = burst
+		return nil
+	}
+}
+
+// newLimiter creates the limiter of a rate of packetsPerSecond.
+func newLimiter(packetsPerSecond int) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(packetsPerSecond), 1)
+}
+
+// throttle waits for the rate limiter, or fails with ErrRateLimited in
+// non-blocking mode.
+func (u *UDPClient) throttle() error {
+	return u.throttleUntil(time.Time{})
+}
+
+// throttleUntil is throttle failing with os.ErrDeadlineExceeded when the
+// wait would not end before bound. A zero bound waits as long as needed.
+func (u *UDPClient) throttleUntil(bound time.Time) error {
+	if u.limiter == nil {
+		return nil
+	}
+	if u.rateNonBlocking {
+		if !u.limiter.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	if bound.IsZero() {
+		return u.limiter.Wait(context.Background())
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), bound)
+	defer cancel()
+	if err := u.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w - %w", os.ErrDeadlineExceeded, err)
+	}
+	return nil
+}
+
+// sourceLimiter keeps a token bucket per source address. A bucket left idle
+// long enough to refill is no different from a new one, so it is evicted
+// once that time has passed, which bounds the map to the recent sources.
+type sourceLimiter struct {
+	limit rate.Limit
+	burst int
+	idle  time.Duration // time to refill a bucket, at least a second
+
+	mu        sync.Mutex
+	buckets   map[netip.Addr]*sourceBucket
+	lastSweep time.Time
+}
+
+// sourceBucket is the token bucket of one source.
+type sourceBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newSourceLimiter creates the limiter of WithPerSourceRateLimit.
+func newSourceLimiter(packetsPerSecond, burst int) *sourceLimiter {
+	refill := time.Duration(burst) * time.Second / time.Duration(packetsPerSecond)
+	return &sourceLimiter{
+		limit:   rate.Limit(packetsPerSecond),
+		burst:   burst,
+		idle:    max(refill, time.Second),
+		buckets: make(map[netip.Addr]*sourceBucket),
+	}
+}
+
+// allow reports whether a datagram from ip is within the limit of its
+// source. IPv4-mapped addresses share the bucket of the IPv4 address.
+func (l *sourceLimiter) allow(ip net.IP) bool {
+	key, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	key = key.Unmap()
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.idle {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) >= l.idle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &sourceBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
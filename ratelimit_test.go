@@ -0,0 +1,180 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes about ten seconds")
+	}
+
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithRateLimit(10),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, err = u.Transmit(dst, []byte("throttled")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+	// The first packet goes at once, the other 99 every 100ms
+	if elapsed := time.Since(start); elapsed < 9*time.Second || elapsed > 11*time.Second {
+		t.Errorf("expected about 9.9s got %v", elapsed)
+	}
+}
+
+func TestWithRateLimitBlocking(t *testing.T) {
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithRateLimit(10),
+		WithRateLimitBlocking(false),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}
+	if _, err = u.Transmit(dst, []byte("first")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, err = u.Transmit(dst, []byte("second")); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited got %v", err)
+	}
+	time.Sleep(110 * time.Millisecond)
+	if _, err = u.Transmit(dst, []byte("third")); err != nil {
+		t.Error("failed to transmit after the interval -", err)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithRateLimit(0)); err == nil {
+		t.Error("expected Error(zero rate) got nil")
+	}
+}
+
+func TestWithRateLimitAllPaths(t *testing.T) {
+	for _, path := range transmitPaths {
+		t.Run(path.name, func(t *testing.T) {
+			if path.linuxOnly && runtime.GOOS != "linux" {
+				t.Skip("only supported on linux")
+			}
+			u, err := NewUDPClientWithOptions(
+				WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+				WithRateLimit(10),
+				WithRateLimitBlocking(false),
+			)
+			if err != nil {
+				t.Fatal("failed to create udp client -", err)
+			}
+			defer u.Close()
+
+			n, err := path.send(u, u.LocalAddr().(*net.UDPAddr), 2)
+			if n != 1 || !errors.Is(err, ErrRateLimited) {
+				t.Errorf("expected ErrRateLimited after 1 datagram got %d, %v", n, err)
+			}
+		})
+	}
+}
+
+func TestWithPerSourceRateLimit(t *testing.T) {
+	rx, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithPerSourceRateLimit(10, 5),
+	)
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer rx.Close()
+	dst := rx.LocalAddr().(*net.UDPAddr)
+
+	polite, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create polite sender -", err)
+	}
+	defer polite.Close()
+	// 127.0.0.2 is only local without configuration on Linux
+	flooder, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 2)})
+	if err != nil {
+		t.Skip("second loopback address unavailable -", err)
+	}
+	defer flooder.Close()
+
+	const flood = 100
+	for i := 0; i < flood; i++ {
+		if _, err = flooder.Transmit(dst, []byte("flood")); err != nil {
+			t.Fatal("failed to flood -", err)
+		}
+		if i%25 == 0 {
+			if _, err = polite.Transmit(dst, []byte("polite")); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+		}
+	}
+
+	received := make(map[string]int)
+	buf := make([]byte, maxBufferSize)
+	for {
+		n, err := rx.Receive(buf)
+		if IsTimeout(err) {
+			break
+		}
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		received[string(buf[:n])]++
+	}
+
+	if received["polite"] != 4 {
+		t.Errorf("expected all 4 datagrams of the polite sender got %d", received["polite"])
+	}
+	// The burst and what refilled while flooding get through
+	if received["flood"] < 5 || received["flood"] > 10 {
+		t.Errorf("expected about 5 datagrams of the flooder got %d", received["flood"])
+	}
+	if got := rx.Stats().RateLimited; got != uint64(flood-received["flood"]) {
+		t.Errorf("expected %d rate limited got %d", flood-received["flood"], got)
+	}
+
+	for _, opt := range []Option{WithPerSourceRateLimit(0, 1), WithPerSourceRateLimit(1, 0)} {
+		if u, err := NewUDPClientWithOptions(WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}), opt); err == nil {
+			u.Close()
+			t.Error("expected Error for invalid per source rate limit got nil")
+		}
+	}
+}
+
+func TestSourceLimiter_Evict(t *testing.T) {
+	l := newSourceLimiter(1, 1)
+	a, b := net.IPv4(192, 0, 2, 1), net.IPv4(192, 0, 2, 2)
+	if !l.allow(a.To4()) || l.allow(a.To4()) {
+		t.Fatal("expected a burst of one datagram")
+	}
+	// The IPv4-mapped form shares the bucket
+	if l.allow(a) {
+		t.Error("expected the mapped address limited as well")
+	}
+
+	// Once idle long enough, the bucket is gone at the next sweep
+	l.buckets[netip.AddrFrom4([4]byte{192, 0, 2, 1})].lastSeen = time.Now().Add(-2 * l.idle)
+	l.lastSweep = time.Now().Add(-2 * l.idle)
+	l.allow(b)
+	if len(l.buckets) != 1 {
+		t.Errorf("expected the idle bucket evicted got %d buckets", len(l.buckets))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Backoff between the failed Reconnect attempts of Serve, doubling from
+// the first to the last.
+const (
+	minReconnectBackoff = 10 * time.Millisecond
+	maxReconnectBackoff = 5 * time.Second
+)
+
+// Reconnect closes the socket of the client and opens a new one on the same
+// local address, port included, to recover from a socket stuck in an error
+// state, for instance by ICMP port unreachable messages surfacing as read
+// errors. A client created by DialUDPClient is connected to its remote
+// address again. The deadlines, the fields applied by Bind and the buffer
+// sizes of the options are kept; settings applied to the socket by methods
+// such as SetTTL, SetDSCP or SetPacketInfo must be applied again. A failed
+// Reconnect leaves the client with the closed socket, so it can be retried.
+// It must not run concurrently with other calls on the client, unlike Close
+// which ends them.
+func (u *UDPClient) Reconnect() error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to Reconnect due to uninitialized client")
+	}
+
+	laddr, ok := u.conn.LocalAddr().(*net.UDPAddr)
+	if u.network != "unixgram" && (!ok || laddr.Port == 0) {
+		return fmt.Errorf("failed to Reconnect as the client is not bound to a port")
+	}
+
+	// The old socket may be closed already, the error does not matter
+	_ = u.conn.Close()
+
+	var conn packetConn
+	var err error
+	if u.dialed != nil {
+		conn, err = u.redial(laddr, u.dialed)
+	} else {
+		conn, err = u.open(laddr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reopen socket in Reconnect - %w", err)
+	}
+	u.conn = conn
+	u.readDeadline.Store(0)
+	u.writeDeadline.Store(0)
+	u.features = probeFeatures(conn)
+	u.dropsOnce, u.dropsErr = sync.Once{}, nil
+
+	if err = u.setBufferSizes(u.readBuffer, u.writeBuffer); err != nil {
+		return fmt.Errorf("failed to set buffer size in Reconnect - %w", err)
+	}
+
+	u.logf("udp: reconnected on %v", conn.LocalAddr())
+	return nil
+}
+
+// reconnect calls Reconnect after cause ended a receive of Serve, retrying
+// with a growing backoff until it succeeds, ctx is done or the client is
+// closed.
+func (u *UDPClient) reconnect(ctx context.Context, cause error) error {
+	u.logf("udp: reconnecting after %v", cause)
+	backoff := minReconnectBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-u.Done():
+			return net.ErrClosed
+		case <-time.After(backoff):
+		}
+
+		// Background goroutines such as keepalives transmit meanwhile
+		u.swapMu.Lock()
+		err := u.Reconnect()
+		u.swapMu.Unlock()
+		if err == nil {
+			return nil
+		}
+		u.logf("udp: %v, retrying in %v", err, backoff)
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
+}
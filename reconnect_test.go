@@ -0,0 +1,150 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// failingConn fails the next read with ECONNREFUSED once armed, as an ICMP
+// port unreachable does on a socket with a pending error.
+type failingConn struct {
+	packetConn
+	armed atomic.Bool
+}
+
+func (f *failingConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	if f.armed.CompareAndSwap(true, false) {
+		return 0, nil, syscall.ECONNREFUSED
+	}
+	return f.packetConn.ReadFromUDP(b)
+}
+
+func (f *failingConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	if f.armed.CompareAndSwap(true, false) {
+		return 0, 0, 0, nil, syscall.ECONNREFUSED
+	}
+	return f.packetConn.ReadMsgUDP(b, oob)
+}
+
+func TestUDPClient_Reconnect(t *testing.T) {
+	const readSize = 1 << 16
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithReadBufferSize(readSize),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+	laddr := u.LocalAddr().(*net.UDPAddr)
+	paddr := peer.LocalAddr().(*net.UDPAddr)
+
+	// Force the socket closed under the client
+	u.conn.Close()
+	if _, err = u.Transmit(paddr, []byte("lost")); err == nil {
+		t.Fatal("expected transmit to fail on the closed socket")
+	}
+
+	if err = u.Reconnect(); err != nil {
+		t.Fatal("failed to reconnect -", err)
+	}
+	if got := u.LocalAddr().(*net.UDPAddr); got.Port != laddr.Port {
+		t.Errorf("expected the port %d kept got %d", laddr.Port, got.Port)
+	}
+	if read, err := u.ReadBufferSize(); err == nil && read < readSize {
+		t.Errorf("expected receive buffer of at least %d got %d", readSize, read)
+	}
+
+	buf := make([]byte, maxBufferSize)
+	if _, err = peer.Transmit(laddr, []byte("ping")); err != nil {
+		t.Fatal("failed to transmit to reconnected client -", err)
+	}
+	n, err := u.Receive(buf)
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Fatalf("expected %q got %q and %v", "ping", buf[:n], err)
+	}
+	if _, err = u.Transmit(paddr, []byte("pong")); err != nil {
+		t.Fatal("failed to transmit from reconnected client -", err)
+	}
+	if n, err = peer.Receive(buf); err != nil || string(buf[:n]) != "pong" {
+		t.Errorf("expected %q got %q and %v", "pong", buf[:n], err)
+	}
+
+	var nilClient *UDPClient
+	if err = nilClient.Reconnect(); err == nil {
+		t.Errorf("expected error for nil client")
+	}
+	mock, _ := NewMockUDPClient()
+	if err = mock.Reconnect(); err == nil {
+		t.Errorf("expected error for a client without a port")
+	}
+}
+
+func TestUDPClient_ServeAutoReconnect(t *testing.T) {
+	server, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithAutoReconnect(true),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+	failing := &failingConn{packetConn: server.conn}
+	failing.armed.Store(true)
+	server.conn = failing
+	addr := server.LocalAddr().(*net.UDPAddr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, upperHandler) }()
+
+	client, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer client.Close()
+	client.QueryRetries = 3
+
+	// Keepalives go on transmitting while the socket is swapped
+	sink, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create keepalive sink -", err)
+	}
+	defer sink.Close()
+	stop := server.StartKeepalive(sink.LocalAddr().(*net.UDPAddr), time.Millisecond, []byte("alive"))
+	defer stop()
+
+	resp := make([]byte, maxBufferSize)
+	n, _, err := client.Query(addr, []byte("hello"), resp, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal("failed to query after reconnect -", err)
+	}
+	if string(resp[:n]) != "HELLO" {
+		t.Errorf("expected %q got %q", "HELLO", resp[:n])
+	}
+	cancel()
+	select {
+	case err = <-served:
+		if err != nil {
+			t.Errorf("expected nil on cancellation got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Serve to return on cancellation")
+	}
+	if _, ok := server.conn.(*net.UDPConn); !ok {
+		t.Errorf("expected the failing socket replaced")
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// Reliable layer packets consist of a type byte, the big endian 32 bit
+// session id of the sender and its 32 bit sequence number, followed by the
+// payload for data packets. Acknowledgements echo the session and sequence.
+const (
+	reliableData byte = 1
+	reliableAck  byte = 2
+
+	reliableHeaderSize = 9
+)
+
+const (
+	// DefaultRetransmitInterval is the time SendReliable waits for an
+	// acknowledgement before transmitting the data again.
+	DefaultRetransmitInterval = 200 * time.Millisecond
+
+	// DefaultReliableTimeout is the time SendReliable keeps retransmitting
+	// before giving up.
+	DefaultReliableTimeout = 5 * time.Second
+)
+
+// ErrUnacknowledged is returned by SendReliable when the peer did not
+// acknowledge the data in time.
+var ErrUnacknowledged = errors.New("datagram not acknowledged")
+
+// ReliableClient adds sequence numbers, acknowledgements and retransmission
+// to the datagrams exchanged with a single peer over a UDPClient. Each
+// datagram is acknowledged before the next one is sent, so they are
+// delivered in order and without duplicates. Every ReliableClient sends under
+// a random session id, so that a receiver resynchronises on a restarted
+// sender instead of mistaking its datagrams for duplicates. The underlying
+// client is read by both SendReliable and ReceiveReliable, which must
+// therefore not run concurrently; one side sends while the other receives.
+type ReliableClient struct {
+	u    *UDPClient
+	peer *net.UDPAddr
+
+	// RetransmitInterval is the time waited for an acknowledgement before
+	// the data is transmitted again.
+	RetransmitInterval time.Duration
+
+	// Timeout is the time SendReliable keeps retransmitting before it
+	// fails with ErrUnacknowledged.
+	Timeout time.Duration
+
+	sendMu  sync.Mutex
+	session uint32 // never zero
+	seq     uint32
+
+	recvMu    sync.Mutex
+	current   uint32 // session delivered, zero before the first datagram
+	retired   uint32 // session replaced by current after a restart
+	delivered uint32
+}
+
+// NewReliableClient creates a ReliableClient exchanging datagrams with peer
+// over u.
+func NewReliableClient(u *UDPClient, peer *net.UDPAddr) *ReliableClient {
+	return &ReliableClient{
+		u:                  u,
+		peer:               peer,
+		RetransmitInterval: DefaultRetransmitInterval,
+		Timeout:            DefaultReliableTimeout,
+		session:            rand.Uint32N(math.MaxUint32) + 1,
+	}
+}
+
+// SendReliable transmits data to the peer and blocks until the peer
+// acknowledged it, retransmitting every RetransmitInterval. It fails with
+// ErrUnacknowledged after Timeout. Retransmissions of datagrams already
+// delivered by ReceiveReliable are acknowledged again, as the peer waits for
+// that acknowledgement when the two sides swap roles. Other datagrams
+// received in the meantime are discarded.
+func (r *ReliableClient) SendReliable(data []byte) error {
+	if r == nil || r.u == nil || r.u.conn == nil {
+		return fmt.Errorf("failed to SendReliable due to uninitialized client")
+	}
+
+	if r.peer == nil || r.RetransmitInterval <= 0 || r.Timeout <= 0 {
+		return fmt.Errorf("parameter error in SendReliable")
+	}
+
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+
+	r.seq++
+	pkt := make([]byte, reliableHeaderSize+len(data))
+	putReliableHeader(pkt, reliableData, r.session, r.seq)
+	copy(pkt[reliableHeaderSize:], data)
+
+	bp := r.u.getBuffer()
+	defer r.u.putBuffer(bp)
+
+	deadline := time.Now().Add(r.Timeout)
+	for attempt := 1; time.Now().Before(deadline); attempt++ {
+		if _, err := r.u.Transmit(r.peer, pkt); err != nil {
+			return fmt.Errorf("failed to send data in SendReliable - %w", err)
+		}
+
+		wait := time.Now().Add(r.RetransmitInterval)
+		if wait.After(deadline) {
+			wait = deadline
+		}
+		acked, err := r.awaitAck(*bp, wait)
+		if err != nil {
+			return fmt.Errorf("failed to receive acknowledgement in SendReliable - %w", err)
+		}
+		if acked {
+			return nil
+		}
+		r.u.logf("udp: no acknowledgement of %d from %v, retransmitting (%d)", r.seq, r.peer, attempt)
+	}
+
+	return fmt.Errorf("failed in SendReliable after %v - %w", r.Timeout, ErrUnacknowledged)
+}
+
+// putReliableHeader writes the header of a packet of type typ into b.
+func putReliableHeader(b []byte, typ byte, session, seq uint32) {
+	b[0] = typ
+	binary.BigEndian.PutUint32(b[1:], session)
+	binary.BigEndian.PutUint32(b[5:], seq)
+}
+
+// awaitAck reads until deadline, reporting whether the acknowledgement of
+// the current session and sequence number arrived from the peer.
+func (r *ReliableClient) awaitAck(buf []byte, deadline time.Time) (bool, error) {
+	for {
+		n, sender, err := r.u.receiveFrom(buf, deadline)
+		from := udpAddr(sender)
+		if IsTimeout(err) {
+			return false, nil
+		}
+		if errors.Is(err, ErrTruncated) || errors.Is(err, ErrEmptyDatagram) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if n < reliableHeaderSize || !sameUDPAddr(from, r.peer) {
+			continue
+		}
+		session := binary.BigEndian.Uint32(buf[1:])
+		seq := binary.BigEndian.Uint32(buf[5:])
+		if n == reliableHeaderSize && buf[0] == reliableAck && session == r.session && seq == r.seq {
+			return true, nil
+		}
+		if buf[0] == reliableData && r.isDelivered(session, seq) {
+			// The peer missed our acknowledgement of its last datagram and
+			// waits for it before receiving ours
+			if err = r.ack(session, seq); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// isDelivered reports if the datagram seq of session was already delivered
+// by ReceiveReliable, or belongs to the session the current one retired.
+func (r *ReliableClient) isDelivered(session, seq uint32) bool {
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+	// Serial number comparison copes with the sequence wrapping around
+	return session == r.retired || (session == r.current && int32(seq-r.delivered) <= 0)
+}
+
+// ack acknowledges the datagram seq of session to the peer.
+func (r *ReliableClient) ack(session, seq uint32) error {
+	var pkt [reliableHeaderSize]byte
+	putReliableHeader(pkt[:], reliableAck, session, seq)
+	_, err := r.u.Transmit(r.peer, pkt[:])
+	return err
+}
+
+// deliver acknowledges the data packet pkt of the peer and copies its
+// payload into rb, returning the number of bytes copied. It reports false,
+// after acknowledging them again, for retransmissions of datagrams already
+// delivered and for late ones of a retired session. The caller holds recvMu.
+func (r *ReliableClient) deliver(pkt, rb []byte) (n int, ok bool, err error) {
+	session := binary.BigEndian.Uint32(pkt[1:])
+	seq := binary.BigEndian.Uint32(pkt[5:])
+	if err = r.ack(session, seq); err != nil {
+		return
+	}
+
+	if session == r.retired || (session == r.current && int32(seq-r.delivered) <= 0) {
+		return
+	}
+	if session != r.current {
+		// The sender restarted, or this is its first datagram
+		r.retired, r.current = r.current, session
+	}
+	r.delivered = seq
+	return copy(rb, pkt[reliableHeaderSize:]), true, nil
+}
+
+// ReceiveReliable reads the next datagram sent by SendReliable of the peer
+// into rb, acknowledging it. Retransmissions of datagrams already delivered
+// are acknowledged again and skipped. A datagram of a new session restarts
+// the sequence, while late ones of the session it replaced are skipped. Each
+// read is bound by the ReadDeadline of the client. A datagram larger than rb
+// fills it and fails with ErrTruncated.
+func (r *ReliableClient) ReceiveReliable(rb []byte) (
+	n int,
+	err error,
+) {
+	if r == nil || r.u == nil || r.u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveReliable due to uninitialized client")
+		return
+	}
+
+	if r.peer == nil || len(rb) == 0 {
+		err = fmt.Errorf("parameter error in ReceiveReliable")
+		return
+	}
+
+	r.recvMu.Lock()
+	defer r.recvMu.Unlock()
+
+	bp := r.u.getBuffer()
+	defer r.u.putBuffer(bp)
+	buf := *bp
+
+	for {
+		var sender net.Addr
+		n, sender, err = r.u.receiveFrom(buf, r.u.nextReadDeadline())
+		from := udpAddr(sender)
+		if errors.Is(err, ErrTruncated) || errors.Is(err, ErrEmptyDatagram) {
+			continue
+		}
+		if err != nil {
+			n = 0
+			err = fmt.Errorf("failed to receive data in ReceiveReliable - %w", err)
+			return
+		}
+		if n < reliableHeaderSize || buf[0] != reliableData || !sameUDPAddr(from, r.peer) {
+			continue
+		}
+
+		size := n
+		var ok bool
+		if n, ok, err = r.deliver(buf[:size], rb); err != nil {
+			err = fmt.Errorf("failed to send acknowledgement in ReceiveReliable - %w", err)
+			return
+		}
+		if !ok {
+			continue
+		}
+		if n < size-reliableHeaderSize {
+			err = fmt.Errorf("failed to read data in ReceiveReliable - %w", ErrTruncated)
+		}
+		return
+	}
+}
+
+// AcceptReliable waits until ctx is done for a datagram sent by
+// SendReliable of any peer, so that a server can take part in exchanges
+// started by its clients. The datagram is acknowledged and read into rb as
+// ReceiveReliable does, and the returned ReliableClient exchanges the
+// following datagrams with its sender. A datagram larger than rb fills it
+// and fails with ErrTruncated along with the ReliableClient.
+func (u *UDPClient) AcceptReliable(ctx context.Context, rb []byte) (
+	r *ReliableClient,
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to AcceptReliable due to uninitialized client")
+		return
+	}
+
+	if ctx == nil || len(rb) == 0 {
+		err = fmt.Errorf("parameter error in AcceptReliable")
+		return
+	}
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+	buf := *bp
+
+	for {
+		var sender net.Addr
+		n, sender, err = u.receiveUntil(ctx, buf)
+		if errors.Is(err, ErrTruncated) || errors.Is(err, ErrEmptyDatagram) {
+			continue
+		}
+		if err != nil {
+			n = 0
+			err = fmt.Errorf("failed to receive data in AcceptReliable - %w", err)
+			return
+		}
+		from := udpAddr(sender)
+		if n < reliableHeaderSize || buf[0] != reliableData || from == nil {
+			continue
+		}
+
+		size := n
+		r = NewReliableClient(u, from)
+		if n, _, err = r.deliver(buf[:size], rb); err != nil {
+			r, err = nil, fmt.Errorf("failed to send acknowledgement in AcceptReliable - %w", err)
+			return
+		}
+		if n < size-reliableHeaderSize {
+			err = fmt.Errorf("failed to read data in AcceptReliable - %w", ErrTruncated)
+		}
+		return
+	}
+}
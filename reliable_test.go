@@ -0,0 +1,256 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"testing"
+	"time"
+)
+
+// lossyRelay forwards datagrams between a and b, dropping the given fraction
+// of them in either direction. It runs until the returned client is closed.
+func lossyRelay(t *testing.T, a, b *net.UDPAddr, loss float64) *UDPClient {
+	t.Helper()
+	relay, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create relay -", err)
+	}
+	relay.ReadDeadline = 0
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		for {
+			n, from, err := relay.ReceiveFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil || rng.Float64() < loss {
+				continue
+			}
+			dst := a
+			if sameUDPAddr(from, a) {
+				dst = b
+			}
+			relay.Transmit(dst, buf[:n])
+		}
+	}()
+	return relay
+}
+
+func TestReliableClient(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	tx, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create sender -", err)
+	}
+	defer tx.Close()
+	rx, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer rx.Close()
+	rx.ReadDeadline = 0
+
+	relay := lossyRelay(t, tx.LocalAddr().(*net.UDPAddr), rx.LocalAddr().(*net.UDPAddr), 0.3)
+	defer relay.Close()
+	via := relay.LocalAddr().(*net.UDPAddr)
+
+	sender := NewReliableClient(tx, via)
+	sender.RetransmitInterval = 20 * time.Millisecond
+	receiver := NewReliableClient(rx, via)
+
+	// The receiver keeps acknowledging retransmissions until closed
+	received := make(chan string, 100)
+	go func() {
+		defer close(received)
+		buf := make([]byte, maxBufferSize)
+		for {
+			n, err := receiver.ReceiveReliable(buf)
+			if err != nil {
+				return
+			}
+			received <- string(buf[:n])
+		}
+	}()
+
+	const messages = 20
+	for i := 0; i < messages; i++ {
+		if err = sender.SendReliable([]byte(fmt.Sprint("message ", i))); err != nil {
+			t.Fatal("failed to send reliably -", err)
+		}
+	}
+
+	for i := 0; i < messages; i++ {
+		want := fmt.Sprint("message ", i)
+		if got := <-received; got != want {
+			t.Fatalf("expected %q got %q", want, got)
+		}
+	}
+	select {
+	case got := <-received:
+		t.Errorf("expected no duplicates got %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReliableClient_SenderRestart(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	tx, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create sender -", err)
+	}
+	defer tx.Close()
+	rx, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer rx.Close()
+	rx.ReadDeadline = time.Second
+
+	receiver := NewReliableClient(rx, tx.LocalAddr().(*net.UDPAddr))
+	received := make(chan string, 10)
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		for {
+			n, err := receiver.ReceiveReliable(buf)
+			if err != nil {
+				return
+			}
+			received <- string(buf[:n])
+		}
+	}()
+
+	dst := rx.LocalAddr().(*net.UDPAddr)
+	first := NewReliableClient(tx, dst)
+	for _, msg := range []string{"one", "two"} {
+		if err = first.SendReliable([]byte(msg)); err != nil {
+			t.Fatal("failed to send reliably -", err)
+		}
+	}
+
+	// The restarted sender starts over below the sequence delivered so far
+	restarted := NewReliableClient(tx, dst)
+	restarted.seq = first.seq - 10
+	if err = restarted.SendReliable([]byte("three")); err != nil {
+		t.Fatal("failed to send reliably after restart -", err)
+	}
+
+	// Late retransmissions of the previous run are not delivered again
+	if err = first.SendReliable([]byte("stale")); err != nil {
+		t.Fatal("failed to send reliably from the previous run -", err)
+	}
+	if err = restarted.SendReliable([]byte("four")); err != nil {
+		t.Fatal("failed to send reliably after restart -", err)
+	}
+
+	for _, want := range []string{"one", "two", "three", "four"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("expected %q got %q", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %q to be delivered", want)
+		}
+	}
+}
+
+func TestReliableClient_Unacknowledged(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	u, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	silent, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer silent.Close()
+
+	r := NewReliableClient(u, silent.LocalAddr().(*net.UDPAddr))
+	r.RetransmitInterval = 10 * time.Millisecond
+	r.Timeout = 50 * time.Millisecond
+	if err = r.SendReliable([]byte("hello")); !errors.Is(err, ErrUnacknowledged) {
+		t.Errorf("expected ErrUnacknowledged got %v", err)
+	}
+
+	var nilClient *ReliableClient
+	if err = nilClient.SendReliable([]byte("hello")); err == nil {
+		t.Errorf("expected error for nil client")
+	}
+}
+
+func TestUDPClient_AcceptReliable(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	cu, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create client -", err)
+	}
+	defer cu.Close()
+	cu.ReadDeadline = time.Second
+	su, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create server -", err)
+	}
+	defer su.Close()
+	su.ReadDeadline = time.Second
+
+	relay := lossyRelay(t, cu.LocalAddr().(*net.UDPAddr), su.LocalAddr().(*net.UDPAddr), 0.3)
+	defer relay.Close()
+
+	// The server answers every request, the sides swapping roles each time
+	const rounds = 20
+	served := make(chan error, 1)
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		r, n, err := su.AcceptReliable(context.Background(), buf)
+		for i := 0; err == nil; i++ {
+			r.RetransmitInterval = 20 * time.Millisecond
+			if err = r.SendReliable(append([]byte("re: "), buf[:n]...)); err != nil || i == rounds-1 {
+				break
+			}
+			n, err = r.ReceiveReliable(buf)
+		}
+		served <- err
+	}()
+
+	client := NewReliableClient(cu, relay.LocalAddr().(*net.UDPAddr))
+	client.RetransmitInterval = 20 * time.Millisecond
+	buf := make([]byte, maxBufferSize)
+	for i := 0; i < rounds; i++ {
+		msg := fmt.Sprint("request ", i)
+		if err = client.SendReliable([]byte(msg)); err != nil {
+			t.Fatal("failed to send reliably -", err)
+		}
+		n, err := client.ReceiveReliable(buf)
+		if err != nil {
+			t.Fatal("failed to receive reliably -", err)
+		}
+		if got := string(buf[:n]); got != "re: "+msg {
+			t.Fatalf("expected %q got %q", "re: "+msg, got)
+		}
+	}
+
+	// Lingering acknowledges the last reply again if its acknowledgement was lost
+	cu.ReadDeadline = 200 * time.Millisecond
+	if _, err = client.ReceiveReliable(buf); !IsTimeout(err) {
+		t.Errorf("expected timeout got %v", err)
+	}
+	if err = <-served; err != nil {
+		t.Error("failed to serve reliably -", err)
+	}
+
+	var nilClient *UDPClient
+	if _, _, err = nilClient.AcceptReliable(context.Background(), buf); err == nil {
+		t.Errorf("expected error for nil client")
+	}
+}
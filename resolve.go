@@ -0,0 +1,164 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ResolveTTL specifies the default time a hostname resolved by TransmitTo
+// is remembered.
+const ResolveTTL = time.Minute
+
+// ResolverCache remembers the hostnames resolved by TransmitTo until they
+// expire. Each client has its own unless one is shared between clients with
+// WithSharedResolveCache, the clients then share the resolutions and the
+// concurrent lookups of a hostname wait for a single one. The zero value is
+// ready for use and it is safe for concurrent use.
+type ResolverCache struct {
+	mu    sync.Mutex
+	items map[string]*hostEntry
+}
+
+// hostEntry holds the addresses of a hostname along with their expiry, once
+// done is closed.
+type hostEntry struct {
+	done    chan struct{}
+	ips     []net.IPAddr
+	port    int
+	err     error
+	expires time.Time
+}
+
+// resolved reports whether the lookup of the entry ended.
+func (e *hostEntry) resolved() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// get returns the unexpired entry cached for hostport.
+func (c *ResolverCache) get(hostport string, now time.Time) (*hostEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[hostport]
+	if !ok || !e.resolved() || e.err != nil || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e, true
+}
+
+// lookup returns the addresses cached for hostport, calling resolve when
+// there are none and caching its result for ttl. A lookup in progress is
+// waited for, failed ones are not cached.
+func (c *ResolverCache) lookup(
+	hostport string,
+	ttl time.Duration,
+	resolve func() ([]net.IPAddr, int, error),
+) ([]net.IPAddr, int, error) {
+	c.mu.Lock()
+	now := time.Now()
+	e, ok := c.items[hostport]
+	if ok && (!e.resolved() || now.Before(e.expires)) {
+		c.mu.Unlock()
+		<-e.done
+		return e.ips, e.port, e.err
+	}
+
+	if c.items == nil {
+		c.items = make(map[string]*hostEntry)
+	}
+	for k, e := range c.items {
+		if e.resolved() && !now.Before(e.expires) {
+			delete(c.items, k)
+		}
+	}
+	e = &hostEntry{done: make(chan struct{})}
+	c.items[hostport] = e
+	c.mu.Unlock()
+
+	e.ips, e.port, e.err = resolve()
+	e.expires = time.Now().Add(ttl)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.items, hostport)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.ips, e.port, e.err
+}
+
+// TransmitTo works like Transmit but sends to a "host:port" address. The
+// host is resolved with Resolver, or net.DefaultResolver when nil, and the
+// result is cached for ResolveTTL, or the ResolveTTL constant when zero, in
+// SharedResolveCache when set.
+//
+// When the host has several A/AAAA records the first one in the order of the
+// resolver whose family suits the local socket is chosen, so an IPv4 socket
+// picks the first IPv4 address. The order of the Go resolver follows the
+// destination address selection of RFC 6724.
+func (u *UDPClient) TransmitTo(hostport string, data []byte) (int, error) {
+	if u == nil || u.conn == nil {
+		return 0, fmt.Errorf("failed to TransmitTo due to uninitialized client")
+	}
+
+	addr, err := u.resolveHost(hostport)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %q in TransmitTo - %w", hostport, err)
+	}
+
+	return u.Transmit(addr, data)
+}
+
+// resolveHost returns the cached or freshly resolved address of hostport.
+func (u *UDPClient) resolveHost(hostport string) (*net.UDPAddr, error) {
+	host, service, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := u.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ttl := u.ResolveTTL
+	if ttl == 0 {
+		ttl = ResolveTTL
+	}
+	cache := u.SharedResolveCache
+	if cache == nil {
+		cache = &u.hosts
+	}
+	ips, port, err := cache.lookup(hostport, ttl, func() ([]net.IPAddr, int, error) {
+		ctx := context.Background()
+		port, err := resolver.LookupPort(ctx, "udp", service)
+		if err != nil {
+			return nil, 0, err
+		}
+		ips, err := resolver.LookupIPAddr(ctx, host)
+		return ips, port, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The addresses are cached whatever the family as clients sharing the
+	// cache may have sockets of either family
+	for _, ip := range ips {
+		addr := &net.UDPAddr{IP: ip.IP, Port: port, Zone: ip.Zone}
+		if u.checkFamily(addr) == nil {
+			return addr, nil
+		}
+	}
+	return nil, fmt.Errorf("%w - no address of %s suits the local socket", ErrAddressFamilyMismatch, host)
+}
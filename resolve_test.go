@@ -0,0 +1,172 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestUDPClient_TransmitTo(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	// localhost may also resolve to ::1, the IPv4 socket picks 127.0.0.1
+	hostport := net.JoinHostPort("localhost", strconv.Itoa(peer.LocalAddr().(*net.UDPAddr).Port))
+	message := "Where there is a will there is a way"
+	if _, err = u.TransmitTo(hostport, []byte(message)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, err := peer.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	e, ok := u.hosts.get(hostport, time.Now())
+	if !ok {
+		t.Fatal("expected the resolution to be cached")
+	}
+	if e.port != peer.LocalAddr().(*net.UDPAddr).Port || len(e.ips) == 0 {
+		t.Errorf("expected the addresses of %v got %v port %d", peer.LocalAddr(), e.ips, e.port)
+	}
+	if _, ok = u.hosts.get(hostport, time.Now().Add(ResolveTTL)); ok {
+		t.Error("expected the resolution to expire after ResolveTTL")
+	}
+
+	for _, bad := range []string{"localhost", "localhost:nosuchservice", "[::1]:53"} {
+		if _, err = u.TransmitTo(bad, []byte(message)); err == nil {
+			t.Errorf("expected Error for %q got nil", bad)
+		}
+	}
+	if _, err = (&UDPClient{}).TransmitTo(hostport, []byte(message)); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+}
+
+// stubResolver returns a function creating resolvers, answering 127.0.0.1
+// to A queries after a delay and counting them in queries. Each client gets
+// its own resolver as a resolver merges concurrent lookups.
+func stubResolver(t *testing.T, queries *atomic.Int32) func() *net.Resolver {
+	t.Helper()
+	srv, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to listen -", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := srv.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true})
+			_ = b.StartQuestions()
+			_ = b.Question(q)
+			_ = b.StartAnswers()
+			if q.Type == dnsmessage.TypeA {
+				queries.Add(1)
+				_ = b.AResource(
+					dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60},
+					dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+				)
+			}
+			msg, err := b.Finish()
+			if err != nil {
+				continue
+			}
+			time.AfterFunc(20*time.Millisecond, func() { _, _ = srv.WriteToUDP(msg, addr) })
+		}
+	}()
+
+	return func() *net.Resolver {
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "udp4", srv.LocalAddr().String())
+			},
+		}
+	}
+}
+
+func TestWithSharedResolveCache(t *testing.T) {
+	var queries atomic.Int32
+	newResolver := stubResolver(t, &queries)
+	cache := &ResolverCache{}
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+	hostport := net.JoinHostPort("peer.test.", strconv.Itoa(peer.LocalAddr().(*net.UDPAddr).Port))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		u, err := NewUDPClientWithOptions(
+			WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+			WithSharedResolveCache(cache),
+		)
+		if err != nil {
+			t.Fatal("failed to create udp client -", err)
+		}
+		defer u.Close()
+		u.Resolver = newResolver()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.TransmitTo(hostport, []byte("hello")); err != nil {
+				t.Error("failed to transmit -", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	buf := make([]byte, maxBufferSize)
+	for i := 0; i < 2; i++ {
+		if _, err = peer.Receive(buf); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+	}
+	if n := queries.Load(); n != 1 {
+		t.Errorf("expected a single lookup got %d", n)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithSharedResolveCache(nil)); err == nil {
+		t.Error("expected Error(nil cache) got nil")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrPartialMessage is reported by MessageScanner when a datagram ends with
+// an incomplete application frame.
+var ErrPartialMessage = errors.New("partial message at end of datagram")
+
+// MessageScanner reads datagrams from a UDPClient and splits each of them
+// into application framed messages using a bufio.SplitFunc. Since a datagram
+// is always complete, the split function is called with atEOF set and a
+// frame cut short by the end of the datagram is an error.
+type MessageScanner struct {
+	u     *UDPClient
+	split bufio.SplitFunc
+	buf   []byte
+	rest  []byte
+	msg   []byte
+	addr  net.Addr
+	err   error
+}
+
+// NewMessageScanner creates a scanner receiving on u and framing messages
+// with split, for example SplitLengthPrefixed.
+func NewMessageScanner(u *UDPClient, split bufio.SplitFunc) *MessageScanner {
+	return &MessageScanner{
+		u:     u,
+		split: split,
+		buf:   make([]byte, MaxDatagramSize),
+	}
+}
+
+// Scan advances to the next message, receiving a new datagram when the
+// current one is exhausted. It returns false on a receive or framing error,
+// reported by Err. Unlike bufio.Scanner, scanning can continue after an
+// error, for example after a receive timeout; on a framing error the rest of
+// the offending datagram is dropped.
+func (s *MessageScanner) Scan() bool {
+	s.msg, s.err = nil, nil
+	for {
+		if len(s.rest) == 0 {
+			n, err := s.u.Receive(s.buf)
+			if err != nil {
+				s.err = err
+				return false
+			}
+			s.rest = s.buf[:n]
+			s.addr = s.u.RemoteAddr
+		}
+
+		advance, token, err := s.split(s.rest, true)
+		switch {
+		case err != nil:
+			s.rest = nil
+			s.err = fmt.Errorf("failed to split message - %w", err)
+			return false
+		case advance <= 0 && token == nil:
+			s.rest = nil
+			s.err = ErrPartialMessage
+			return false
+		case advance > len(s.rest):
+			s.rest = nil
+			s.err = fmt.Errorf("split function advanced beyond the datagram")
+			return false
+		}
+
+		s.rest = s.rest[advance:]
+		if token != nil {
+			s.msg = token
+			return true
+		}
+	}
+}
+
+// Message returns the message found by the last successful Scan. The slice
+// is only valid until the next call to Scan.
+func (s *MessageScanner) Message() []byte {
+	return s.msg
+}
+
+// Addr returns the sender of the datagram holding the current message.
+func (s *MessageScanner) Addr() net.Addr {
+	return s.addr
+}
+
+// Err returns the error that stopped the last Scan, nil otherwise.
+func (s *MessageScanner) Err() error {
+	return s.err
+}
+
+// SplitLengthPrefixed is a bufio.SplitFunc for messages prefixed with their
+// length as a 2 byte big endian integer.
+func SplitLengthPrefixed(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil
+	}
+	size := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+size {
+		return 0, nil, nil
+	}
+	return 2 + size, data[2 : 2+size], nil
+}
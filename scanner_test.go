@@ -0,0 +1,82 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"testing"
+)
+
+// frame prefixes each message with its length.
+func frame(msgs ...string) []byte {
+	var b []byte
+	for _, m := range msgs {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(m)))
+		b = append(b, m...)
+	}
+	return b
+}
+
+func TestMessageScanner(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+	s := NewMessageScanner(u, SplitLengthPrefixed)
+
+	t.Run("Several messages in one datagram", func(t *testing.T) {
+		want := []string{"one", "", "three"}
+		if _, err := u.Transmit(dst, frame(want...)); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		for _, w := range want {
+			if !s.Scan() {
+				t.Fatal("failed to scan -", s.Err())
+			}
+			if string(s.Message()) != w {
+				t.Errorf("expected %q got %q", w, s.Message())
+			}
+			if s.Addr().String() != dst.String() {
+				t.Errorf("expected sender %v got %v", dst, s.Addr())
+			}
+		}
+		if s.Scan() {
+			t.Errorf("expected no more messages got %q", s.Message())
+		}
+		if !errors.Is(s.Err(), os.ErrDeadlineExceeded) {
+			t.Errorf("expected a receive timeout got %v", s.Err())
+		}
+	})
+
+	t.Run("Trailing partial frame", func(t *testing.T) {
+		data := append(frame("complete"), frame("partial")[:4]...)
+		if _, err := u.Transmit(dst, data); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if !s.Scan() || string(s.Message()) != "complete" {
+			t.Fatalf("expected %q got %q, %v", "complete", s.Message(), s.Err())
+		}
+		if s.Scan() {
+			t.Fatalf("expected partial frame error got %q", s.Message())
+		}
+		if !errors.Is(s.Err(), ErrPartialMessage) {
+			t.Errorf("expected ErrPartialMessage got %v", s.Err())
+		}
+	})
+
+	t.Run("Scanning resumes after an error", func(t *testing.T) {
+		if _, err := u.Transmit(dst, frame("again")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if !s.Scan() || string(s.Message()) != "again" {
+			t.Errorf("expected %q got %q, %v", "again", s.Message(), s.Err())
+		}
+	})
+}
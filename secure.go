@@ -0,0 +1,232 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// SecureKeySize is the length of the AES-256 key of a SecureClient.
+const SecureKeySize = 32
+
+// NonceSize is the length of the random nonce prefixing every datagram sent
+// by a SecureClient.
+const NonceSize = 12
+
+// DefaultReplayWindow is the number of recently seen nonces a SecureClient
+// remembers to reject replayed datagrams.
+const DefaultReplayWindow = 1024
+
+var (
+	// ErrDecryptFailed is returned by SecureClient when a datagram fails to
+	// authenticate, because it was tampered with or sealed with another key.
+	ErrDecryptFailed = errors.New("failed to decrypt datagram")
+
+	// ErrReplay is returned by SecureClient for a datagram whose nonce was
+	// already seen.
+	ErrReplay = errors.New("replayed datagram")
+)
+
+// SecureClient encrypts the datagrams exchanged over a UDPClient with
+// AES-256-GCM. Each datagram carries a random NonceSize byte nonce followed
+// by the ciphertext and its authentication tag. Datagrams reusing one of the
+// last DefaultReplayWindow nonces are rejected as replays. The key can be
+// changed with RotateKey. It is safe to use from multiple goroutines.
+type SecureClient struct {
+	u *UDPClient
+
+	mu       sync.Mutex
+	aead     cipher.AEAD
+	old      cipher.AEAD // previous key accepted until oldUntil
+	oldUntil time.Time
+	window   replayWindow
+}
+
+// NewSecureClient creates a SecureClient encrypting the datagrams of u with
+// the SecureKeySize byte key shared with the peers.
+func NewSecureClient(u *UDPClient, key []byte) (*SecureClient, error) {
+	if u == nil || len(key) != SecureKeySize {
+		return nil, fmt.Errorf("parameter error in NewSecureClient")
+	}
+
+	aead, err := newSecureAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher in NewSecureClient - %w", err)
+	}
+
+	return &SecureClient{u: u, aead: aead, window: newReplayWindow(DefaultReplayWindow)}, nil
+}
+
+// newSecureAEAD creates the AES-256-GCM cipher of key.
+func newSecureAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RotateKey makes newKey, of SecureKeySize bytes, the key of the client.
+// Datagrams are sent with newKey right away, while those authenticated with
+// the previous key are still accepted for grace, so that the traffic of the
+// peers not yet rotated is not dropped. A rotation during the grace of
+// another one drops the key before the previous one.
+func (s *SecureClient) RotateKey(newKey []byte, grace time.Duration) error {
+	if s == nil || len(newKey) != SecureKeySize {
+		return fmt.Errorf("parameter error in RotateKey")
+	}
+
+	aead, err := newSecureAEAD(newKey)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher in RotateKey - %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.old, s.oldUntil = nil, time.Time{}
+	if grace > 0 {
+		s.old, s.oldUntil = s.aead, time.Now().Add(grace)
+	}
+	s.aead = aead
+	return nil
+}
+
+// keys returns the current key and the previous one while it is accepted.
+func (s *SecureClient) keys() (current, old cipher.AEAD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.old != nil && !time.Now().Before(s.oldUntil) {
+		s.old = nil
+	}
+	return s.aead, s.old
+}
+
+// Transmit encrypts data and sends it to addr. It returns the number of
+// bytes of data sent.
+func (s *SecureClient) Transmit(addr *net.UDPAddr, data []byte) (int, error) {
+	if s == nil {
+		return 0, fmt.Errorf("failed to Transmit due to uninitialized secure client")
+	}
+	aead, _ := s.keys()
+	if aead == nil {
+		return 0, fmt.Errorf("failed to Transmit due to uninitialized secure client")
+	}
+
+	msg := make([]byte, NonceSize, NonceSize+len(data)+aead.Overhead())
+	if _, err := rand.Read(msg); err != nil {
+		return 0, fmt.Errorf("failed to generate nonce in Transmit - %w", err)
+	}
+	msg = aead.Seal(msg, msg, data, nil)
+
+	if _, err := s.u.Transmit(addr, msg); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// ReceiveFrom reads a datagram, authenticates and decrypts it into rb and
+// returns its size and sender. Tampered datagrams fail with
+// ErrDecryptFailed and replayed ones with ErrReplay. A plaintext larger than
+// rb fails with ErrTruncated. During the grace of RotateKey the previous key
+// is tried when the current one fails.
+func (s *SecureClient) ReceiveFrom(rb []byte) (
+	n int,
+	addr *net.UDPAddr,
+	err error,
+) {
+	if s == nil {
+		err = fmt.Errorf("failed to Receive due to uninitialized secure client")
+		return
+	}
+	aead, old := s.keys()
+	if aead == nil {
+		err = fmt.Errorf("failed to Receive due to uninitialized secure client")
+		return
+	}
+
+	bp := s.u.getBuffer()
+	defer s.u.putBuffer(bp)
+
+	n, addr, err = s.u.ReceiveFrom(*bp)
+	if err != nil {
+		n = 0
+		return
+	}
+
+	msg := (*bp)[:n]
+	n = 0
+	if len(msg) < NonceSize+aead.Overhead() {
+		err = fmt.Errorf("failed to read data in Receive - %w", ErrDecryptFailed)
+		return
+	}
+	nonce, ciphertext := msg[:NonceSize], msg[NonceSize:]
+
+	// A failed Open may overwrite its destination, so the ciphertext is
+	// only decrypted in place by the last key tried
+	dst := ciphertext[:0]
+	if old != nil {
+		dst = nil
+	}
+	plain, oerr := aead.Open(dst, nonce, ciphertext, nil)
+	if oerr != nil && old != nil {
+		plain, oerr = old.Open(ciphertext[:0], nonce, ciphertext, nil)
+	}
+	if oerr != nil {
+		err = fmt.Errorf("failed to read data in Receive - %w", ErrDecryptFailed)
+		return
+	}
+
+	s.mu.Lock()
+	fresh := s.window.add([NonceSize]byte(nonce))
+	s.mu.Unlock()
+	if !fresh {
+		err = fmt.Errorf("failed to read data in Receive - %w", ErrReplay)
+		return
+	}
+
+	n = copy(rb, plain)
+	if n < len(plain) {
+		err = fmt.Errorf("failed to read data in Receive - %w", ErrTruncated)
+	}
+	return
+}
+
+// replayWindow remembers the most recent nonces up to its capacity.
+type replayWindow struct {
+	seen  map[[NonceSize]byte]struct{}
+	order [][NonceSize]byte
+	next  int
+}
+
+func newReplayWindow(size int) replayWindow {
+	return replayWindow{
+		seen:  make(map[[NonceSize]byte]struct{}, size),
+		order: make([][NonceSize]byte, 0, size),
+	}
+}
+
+// add records nonce, reporting false when it is already in the window. The
+// oldest nonce is forgotten once the window is full.
+func (w *replayWindow) add(nonce [NonceSize]byte) bool {
+	if _, ok := w.seen[nonce]; ok {
+		return false
+	}
+	if len(w.order) < cap(w.order) {
+		w.order = append(w.order, nonce)
+	} else {
+		delete(w.seen, w.order[w.next])
+		w.order[w.next] = nonce
+		w.next = (w.next + 1) % len(w.order)
+	}
+	w.seen[nonce] = struct{}{}
+	return true
+}
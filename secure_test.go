@@ -0,0 +1,170 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSecureClient(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	key := bytes.Repeat([]byte{0x42}, SecureKeySize)
+
+	tx, rx := chunkPair(t)
+	sender, err := NewSecureClient(tx, key)
+	if err != nil {
+		t.Fatal("failed to create secure sender -", err)
+	}
+	receiver, err := NewSecureClient(rx, key)
+	if err != nil {
+		t.Fatal("failed to create secure receiver -", err)
+	}
+
+	// An eavesdropper captures the datagrams on the wire
+	tap, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create tap -", err)
+	}
+	defer tap.Close()
+
+	message := []byte("Loose lips sink ships")
+	buf := make([]byte, maxBufferSize)
+
+	t.Run("Round trip", func(t *testing.T) {
+		if _, err := sender.Transmit(rx.LocalAddr().(*net.UDPAddr), message); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		n, from, err := receiver.ReceiveFrom(buf)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if string(buf[:n]) != string(message) || from.String() != tx.LocalAddr().String() {
+			t.Errorf("expected %q from %v got %q from %v", message, tx.LocalAddr(), buf[:n], from)
+		}
+	})
+
+	if _, err = sender.Transmit(tap.LocalAddr().(*net.UDPAddr), message); err != nil {
+		t.Fatal("failed to transmit to tap -", err)
+	}
+	n, err := tap.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to capture -", err)
+	}
+	captured := bytes.Clone(buf[:n])
+	if bytes.Contains(captured, message) {
+		t.Errorf("expected the message encrypted on the wire")
+	}
+
+	t.Run("Tampered ciphertext", func(t *testing.T) {
+		tampered := bytes.Clone(captured)
+		tampered[NonceSize] ^= 0xff
+		if _, err := tap.Transmit(rx.LocalAddr().(*net.UDPAddr), tampered); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, _, err := receiver.ReceiveFrom(buf); !errors.Is(err, ErrDecryptFailed) {
+			t.Errorf("expected ErrDecryptFailed got %v", err)
+		}
+	})
+
+	t.Run("Replayed packet", func(t *testing.T) {
+		for i, want := range []error{nil, ErrReplay} {
+			if _, err := tap.Transmit(rx.LocalAddr().(*net.UDPAddr), captured); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			n, _, err := receiver.ReceiveFrom(buf)
+			if !errors.Is(err, want) {
+				t.Errorf("delivery %d expected %v got %v", i, want, err)
+			}
+			if want == nil && string(buf[:n]) != string(message) {
+				t.Errorf("expected %q got %q", message, buf[:n])
+			}
+		}
+	})
+
+	if _, err = NewSecureClient(tx, key[:16]); err == nil {
+		t.Errorf("expected error for short key")
+	}
+}
+
+func TestSecureClient_RotateKey(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{0x42}, SecureKeySize)
+	newKey := bytes.Repeat([]byte{0x24}, SecureKeySize)
+
+	tx, rx := chunkPair(t)
+	sender, err := NewSecureClient(tx, oldKey)
+	if err != nil {
+		t.Fatal("failed to create secure sender -", err)
+	}
+	receiver, err := NewSecureClient(rx, oldKey)
+	if err != nil {
+		t.Fatal("failed to create secure receiver -", err)
+	}
+	if err = receiver.RotateKey(newKey, 100*time.Millisecond); err != nil {
+		t.Fatal("failed to rotate key -", err)
+	}
+
+	message := []byte("Out with the old, in with the new")
+	buf := make([]byte, maxBufferSize)
+	deliver := func() error {
+		if _, err := sender.Transmit(rx.LocalAddr().(*net.UDPAddr), message); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		n, _, err := receiver.ReceiveFrom(buf)
+		if err == nil && string(buf[:n]) != string(message) {
+			t.Errorf("expected %q got %q", message, buf[:n])
+		}
+		return err
+	}
+
+	if err = deliver(); err != nil {
+		t.Errorf("expected the old key accepted during grace got %v", err)
+	}
+	if err = sender.RotateKey(newKey, 0); err != nil {
+		t.Fatal("failed to rotate key -", err)
+	}
+	if err = deliver(); err != nil {
+		t.Errorf("expected the new key accepted got %v", err)
+	}
+
+	// The receiver transmits with the new key only
+	if _, err = receiver.Transmit(tx.LocalAddr().(*net.UDPAddr), message); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, _, err = sender.ReceiveFrom(buf); err != nil {
+		t.Errorf("expected the reply sealed with the new key got %v", err)
+	}
+
+	if err = sender.RotateKey(oldKey, 0); err != nil {
+		t.Fatal("failed to rotate key -", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err = deliver(); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("expected the old key rejected after grace got %v", err)
+	}
+
+	if err = receiver.RotateKey(newKey[:16], 0); err == nil {
+		t.Errorf("expected error for short key")
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	w := newReplayWindow(2)
+	nonces := [3][NonceSize]byte{{1}, {2}, {3}}
+	for _, nonce := range nonces {
+		if !w.add(nonce) {
+			t.Fatalf("expected %v to be fresh", nonce)
+		}
+	}
+	if w.add(nonces[2]) {
+		t.Errorf("expected a recent nonce to be rejected")
+	}
+	if !w.add(nonces[0]) {
+		t.Errorf("expected the oldest nonce to be forgotten")
+	}
+}
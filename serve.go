@@ -0,0 +1,196 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"runtime"
+	"sync"
+)
+
+// Handler processes a datagram received by Serve from addr, which is nil for
+// the peers of a unixgram client. A non-nil reply is transmitted back to the
+// sender. The data is only valid during the call.
+type Handler func(addr *net.UDPAddr, data []byte) ([]byte, error)
+
+// Serve receives datagrams until ctx is done or the client is closed, both
+// of which return nil, and passes each one to handler. Empty and truncated
+// datagrams are dropped. A handler error only skips the reply, it is kept
+// along with failed replies in RecentErrors. Any other receive error ends
+// Serve and is returned, including the timeout of a deadline set with
+// SetReadDeadline, unless AutoReconnect is set in which case the socket is
+// reopened by Reconnect for anything but a timeout.
+func (u *UDPClient) Serve(ctx context.Context, handler Handler) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to Serve due to uninitialized client")
+	}
+
+	if handler == nil {
+		return fmt.Errorf("parameter error in Serve")
+	}
+
+	ctx, done, err := u.shutdown.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to Serve - %w", err)
+	}
+	defer done()
+
+	bp := u.getBuffer()
+	defer u.putBuffer(bp)
+
+	var budget readBudget
+	for {
+		budget.spend(u.ReadBudget)
+		n, addr, err := u.receiveUntil(ctx, *bp)
+		switch {
+		case ctx.Err() != nil, errors.Is(err, net.ErrClosed):
+			return nil
+		case errors.Is(err, ErrEmptyDatagram), errors.Is(err, ErrTruncated):
+			continue
+		case err != nil && u.AutoReconnect && !IsTimeout(err):
+			if rerr := u.reconnect(ctx, err); rerr != nil {
+				return nil
+			}
+			continue
+		case err != nil:
+			return fmt.Errorf("failed to receive in Serve - %w", err)
+		}
+
+		u.reply(addr, (*bp)[:n], handler)
+	}
+}
+
+// ServeConcurrent works like Serve but runs handler on a pool of workers
+// goroutines, so that a slow handler does not hold up the reception. Every
+// datagram is read into its own pooled buffer, which is recycled once its
+// handler returned. Handlers may complete out of order unless
+// OrderedDispatch is set, in which case the workers are partitioned by the
+// address of the sender. ServeConcurrent returns after all handlers
+// finished. Both stop reading when Shutdown is called.
+func (u *UDPClient) ServeConcurrent(ctx context.Context, workers int, handler Handler) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to ServeConcurrent due to uninitialized client")
+	}
+
+	if workers < 1 || handler == nil {
+		return fmt.Errorf("parameter error in ServeConcurrent")
+	}
+
+	ctx, done, err := u.shutdown.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ServeConcurrent - %w", err)
+	}
+	defer done()
+
+	type job struct {
+		addr net.Addr
+		bp   *[]byte
+		n    int
+	}
+	// Ordered dispatch gives every worker a queue of its own
+	queues := make([]chan job, 1)
+	if u.OrderedDispatch {
+		queues = make([]chan job, workers)
+	}
+	for i := range queues {
+		queues[i] = make(chan job, max(workers/len(queues), 1))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(jobs <-chan job) {
+			defer wg.Done()
+			for j := range jobs {
+				u.reply(j.addr, (*j.bp)[:j.n], handler)
+				u.putBuffer(j.bp)
+			}
+		}(queues[i%len(queues)])
+	}
+	defer func() {
+		for _, jobs := range queues {
+			close(jobs)
+		}
+		wg.Wait()
+	}()
+
+	var budget readBudget
+	for {
+		budget.spend(u.ReadBudget)
+		bp := u.getBuffer()
+		n, addr, err := u.receiveUntil(ctx, *bp)
+		switch {
+		case ctx.Err() != nil, errors.Is(err, net.ErrClosed):
+			u.putBuffer(bp)
+			return nil
+		case errors.Is(err, ErrEmptyDatagram), errors.Is(err, ErrTruncated):
+			u.putBuffer(bp)
+			continue
+		case err != nil:
+			u.putBuffer(bp)
+			return fmt.Errorf("failed to receive in ServeConcurrent - %w", err)
+		}
+
+		jobs := queues[0]
+		if len(queues) > 1 {
+			jobs = queues[senderHash(addr)%uint32(len(queues))]
+		}
+		jobs <- job{addr: addr, bp: bp, n: n}
+	}
+}
+
+// senderHash hashes the address of a sender to pick its worker queue. The
+// peers of a unixgram client are hashed by their path, unnamed ones have no
+// address and share a queue.
+func senderHash(addr net.Addr) uint32 {
+	h := fnv.New32a()
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		h.Write(a.IP.To16())
+		h.Write([]byte{byte(a.Port >> 8), byte(a.Port)})
+		h.Write([]byte(a.Zone))
+	case *net.UnixAddr:
+		h.Write([]byte(a.Name))
+	}
+	return h.Sum32()
+}
+
+// yield lets other goroutines run once a read budget is spent.
+var yield = runtime.Gosched
+
+// readBudget counts the reads of a Serve loop against ReadBudget.
+type readBudget int
+
+// spend accounts for a read, yielding first when limit reads were made
+// since the last yield. A limit of zero never yields.
+func (b *readBudget) spend(limit int) {
+	if limit <= 0 {
+		return
+	}
+	if int(*b) >= limit {
+		yield()
+		*b = 0
+	}
+	*b++
+}
+
+// reply runs handler on a datagram and transmits its reply if any. The
+// handler of a unixgram client is passed a nil addr, the reply still goes
+// to the Unix address of a named peer.
+func (u *UDPClient) reply(addr net.Addr, data []byte, handler Handler) {
+	resp, err := handler(udpAddr(addr), data)
+	if err != nil {
+		u.recordError("Serve", fmt.Errorf("failed to handle datagram from %v - %w", addr, err))
+		return
+	}
+	if resp != nil {
+		// Transmit keeps its own errors in RecentErrors
+		_, _ = u.transmit(addr, resp)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upperHandler replies with the datagram in upper case and fails for
+// datagrams starting with "fail".
+func upperHandler(addr *net.UDPAddr, data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte("fail")) {
+		return nil, errors.New("refused")
+	}
+	return bytes.ToUpper(data), nil
+}
+
+func TestUDPClient_Serve(t *testing.T) {
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+	server.ErrorHistory = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, upperHandler) }()
+
+	client, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer client.Close()
+	addr := server.LocalAddr().(*net.UDPAddr)
+	resp := make([]byte, maxBufferSize)
+
+	n, _, err := client.Query(addr, []byte("fail"), resp, 50*time.Millisecond)
+	if !IsTimeout(err) {
+		t.Errorf("expected no reply on handler error got %q and %v", resp[:n], err)
+	}
+
+	n, _, err = client.Query(addr, []byte("hello world"), resp, time.Second)
+	if err != nil {
+		t.Fatal("failed to query -", err)
+	}
+	if string(resp[:n]) != "HELLO WORLD" {
+		t.Errorf("expected %q got %q", "HELLO WORLD", resp[:n])
+	}
+
+	cancel()
+	select {
+	case err = <-served:
+		if err != nil {
+			t.Errorf("expected nil on cancellation got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Serve to return on cancellation")
+	}
+
+	errs := server.RecentErrors()
+	if len(errs) == 0 || errs[0].Op != "Serve" {
+		t.Errorf("expected the handler error to be recorded got %v", errs)
+	}
+
+	if err = server.Serve(context.Background(), nil); err == nil {
+		t.Error("expected Error(nil handler) got nil")
+	}
+	if err = (&UDPClient{}).Serve(context.Background(), upperHandler); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+}
+
+func TestUDPClient_ServeConcurrent(t *testing.T) {
+	const (
+		senders = 20
+		workers = 8
+		delay   = 20 * time.Millisecond
+	)
+
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+
+	slow := func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+		time.Sleep(delay)
+		return upperHandler(addr, data)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.ServeConcurrent(ctx, workers, slow) }()
+
+	addr := server.LocalAddr().(*net.UDPAddr)
+	replies := make(chan error, senders)
+	start := time.Now()
+	for i := 0; i < senders; i++ {
+		go func(i int) {
+			client, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			if err != nil {
+				replies <- err
+				return
+			}
+			defer client.Close()
+
+			// Distinct payloads reveal any mix-up of the shared buffers
+			req := bytes.Repeat([]byte{byte('a' + i)}, 100+i)
+			resp := make([]byte, maxBufferSize)
+			n, _, err := client.Query(addr, req, resp, time.Second)
+			if err == nil && !bytes.Equal(resp[:n], bytes.ToUpper(req)) {
+				err = errors.New("corrupted reply " + string(resp[:n]))
+			}
+			replies <- err
+		}(i)
+	}
+	for i := 0; i < senders; i++ {
+		if err := <-replies; err != nil {
+			t.Error("failed to query -", err)
+		}
+	}
+	// Serially the slow handler would take senders * delay
+	if elapsed := time.Since(start); elapsed >= senders*delay {
+		t.Errorf("expected handlers to run concurrently got %v", elapsed)
+	}
+
+	cancel()
+	if err = <-served; err != nil {
+		t.Errorf("expected nil on cancellation got %v", err)
+	}
+
+	if err = server.ServeConcurrent(context.Background(), 0, upperHandler); err == nil {
+		t.Error("expected Error(no workers) got nil")
+	}
+}
+
+func TestUDPClient_ServeConcurrentOrdered(t *testing.T) {
+	const (
+		peers    = 2
+		messages = 50
+		workers  = 4
+	)
+
+	server, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithOrderedDispatch(),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp server -", err)
+	}
+	defer server.Close()
+
+	// Random handling times reorder datagrams handled by different workers
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	record := func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+		time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+		i, _ := strconv.Atoi(string(data))
+		mu.Lock()
+		seen[addr.String()] = append(seen[addr.String()], i)
+		mu.Unlock()
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.ServeConcurrent(ctx, workers, record) }()
+
+	dst := server.LocalAddr().(*net.UDPAddr)
+	senders := make([]*UDPClient, peers)
+	for p := range senders {
+		senders[p], err = NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create peer -", err)
+		}
+		defer senders[p].Close()
+	}
+	for i := 0; i < messages; i++ {
+		for _, s := range senders {
+			if _, err = s.Transmit(dst, []byte(strconv.Itoa(i))); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(seen[senders[0].LocalAddr().String()]) == messages &&
+			len(seen[senders[1].LocalAddr().String()]) == messages
+		mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err = <-served; err != nil {
+		t.Errorf("expected nil on cancellation got %v", err)
+	}
+
+	for _, s := range senders {
+		got := seen[s.LocalAddr().String()]
+		if len(got) != messages {
+			t.Fatalf("expected %d datagrams from %v got %d", messages, s.LocalAddr(), len(got))
+		}
+		for i, v := range got {
+			if v != i {
+				t.Fatalf("expected datagrams of %v in order got %v", s.LocalAddr(), got)
+			}
+		}
+	}
+}
+
+func TestWithReadBudget(t *testing.T) {
+	const (
+		count  = 100
+		budget = 10
+	)
+
+	var yields atomic.Int32
+	defer func(f func()) { yield = f }(yield)
+	yield = func() { yields.Add(1) }
+
+	for _, workers := range []int{0, 4} {
+		yields.Store(0)
+		u, m := NewMockUDPClient()
+		u.ReadBudget = budget
+		peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+		for i := 0; i < count; i++ {
+			m.Inject([]byte(strconv.Itoa(i)), peer)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var handled atomic.Int32
+		handler := func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+			if handled.Add(1) == count {
+				cancel()
+			}
+			return nil, nil
+		}
+		var err error
+		if workers == 0 {
+			err = u.Serve(ctx, handler)
+		} else {
+			err = u.ServeConcurrent(ctx, workers, handler)
+		}
+		if err != nil {
+			t.Errorf("expected nil on cancellation got %v", err)
+		}
+		if n := yields.Load(); n < count/budget-1 || n > count/budget {
+			t.Errorf("expected %d yields with %d workers got %d", count/budget, workers, n)
+		}
+		u.Close()
+	}
+
+	if err := WithReadBudget(0)(&config{}); err == nil {
+		t.Error("expected Error(zero budget) got nil")
+	}
+}
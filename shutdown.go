@@ -0,0 +1,100 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// shutdownState tracks the Serve loops of a client so that Shutdown can stop
+// and drain them.
+type shutdownState struct {
+	mu       sync.Mutex
+	stopping bool
+	ctx      context.Context // canceled when Shutdown begins
+	cancel   context.CancelFunc
+	serving  sync.WaitGroup
+}
+
+// begin registers a Serve loop, returning ctx also canceled by Shutdown and
+// the function to call once the loop and its handlers are done. It fails
+// while a Shutdown is in progress.
+func (s *shutdownState) begin(ctx context.Context) (context.Context, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return nil, nil, fmt.Errorf("client is shutting down")
+	}
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	s.serving.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		s.serving.Done()
+	}, nil
+}
+
+// stop cancels the contexts of the Serve loops and rejects new ones.
+func (s *shutdownState) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// reset allows serving again after the client was bound anew.
+func (s *shutdownState) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = false
+	s.ctx, s.cancel = nil, nil
+}
+
+// Shutdown gracefully stops a server: Serve and ServeConcurrent stop reading
+// and return once the datagrams already received are handled, then the
+// client is closed. Replies of the handlers are still transmitted while
+// they drain. When ctx expires first the socket is closed at once, failing
+// the replies of the handlers still running, the client is released once
+// they returned and the error returned wraps ctx.Err(), typically
+// context.DeadlineExceeded.
+func (u *UDPClient) Shutdown(ctx context.Context) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to Shutdown due to uninitialized client")
+	}
+
+	u.shutdown.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		u.shutdown.serving.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		if err := u.Close(); err != nil {
+			return fmt.Errorf("failed to close in Shutdown - %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// The handlers still running keep the client, only the socket goes
+	_ = u.conn.Close()
+	go func() {
+		<-drained
+		_ = u.Close()
+	}()
+	return fmt.Errorf("failed to drain handlers in Shutdown - %w", ctx.Err())
+}
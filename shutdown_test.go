@@ -0,0 +1,76 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_Shutdown(t *testing.T) {
+	cases := []struct {
+		name    string
+		delay   time.Duration
+		timeout time.Duration
+		want    error
+	}{
+		{"Drained", 50 * time.Millisecond, time.Second, nil},
+		{"Timed out", 300 * time.Millisecond, 50 * time.Millisecond, context.DeadlineExceeded},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+			server, err := NewUDPClient(loopback)
+			if err != nil {
+				t.Fatal("failed to create udp server -", err)
+			}
+			defer server.Close()
+			client, err := NewUDPClient(loopback)
+			if err != nil {
+				t.Fatal("failed to create udp client -", err)
+			}
+			defer client.Close()
+
+			started := make(chan struct{})
+			slow := func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+				close(started)
+				time.Sleep(tc.delay)
+				return data, nil
+			}
+			served := make(chan error, 1)
+			go func() { served <- server.ServeConcurrent(context.Background(), 2, slow) }()
+
+			if _, err = client.Transmit(server.LocalAddr().(*net.UDPAddr), []byte("in flight")); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+			err = server.Shutdown(ctx)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("expected %v got %v", tc.want, err)
+			}
+			if tc.want == nil {
+				// The reply of the drained handler went out before closing
+				n, err := client.Receive(make([]byte, maxBufferSize))
+				if err != nil || n != len("in flight") {
+					t.Errorf("expected the reply of the drained handler got %d, %v", n, err)
+				}
+			}
+			if err = <-served; err != nil {
+				t.Errorf("expected ServeConcurrent to return nil got %v", err)
+			}
+			select {
+			case <-server.Done():
+			case <-time.After(time.Second):
+				t.Errorf("expected the client closed after Shutdown")
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// SocketOptions holds the effective values of the main socket options as
+// reported by the kernel, which may differ from the requested ones.
+type SocketOptions struct {
+	ReadBuffer  int  // SO_RCVBUF in bytes
+	WriteBuffer int  // SO_SNDBUF in bytes
+	TTL         int  // IP_TTL, or IPV6_UNICAST_HOPS for IPv6 sockets
+	TOS         int  // IP_TOS, or IPV6_TCLASS for IPv6 sockets
+	Broadcast   bool // SO_BROADCAST
+}
+
+// SocketOptions reads back the effective socket options via getsockopt.
+// Linux for instance doubles the requested buffer sizes and clamps them to
+// the system limits. It is supported on unix platforms only.
+func (u *UDPClient) SocketOptions() (SocketOptions, error) {
+	if u == nil || u.conn == nil {
+		return SocketOptions{}, fmt.Errorf("failed to get SocketOptions due to uninitialized client")
+	}
+
+	rc, err := u.conn.SyscallConn()
+	if err != nil {
+		return SocketOptions{}, fmt.Errorf("failed to access socket in SocketOptions - %w", err)
+	}
+
+	var opts SocketOptions
+	var gerr error
+	err = rc.Control(func(fd uintptr) {
+		opts, gerr = getSocketOptions(fd, u.isIPv6())
+	})
+	if err == nil {
+		err = gerr
+	}
+	if err != nil {
+		return SocketOptions{}, fmt.Errorf("failed to read socket options - %w", err)
+	}
+	return opts, nil
+}
+
+// SyscallConn returns the raw connection of the socket, to set options the
+// package does not cover or to hand the file descriptor to other code. The
+// descriptor is only valid within the functions passed to Control, Read and
+// Write of the raw connection; it is closed by Close and replaced by
+// Reconnect. The package keeps the socket in non-blocking mode and relies
+// on the runtime poller for its own reads, writes and deadlines, so reading
+// from or writing to the descriptor while the client is in use, or
+// registering it with another poller such as epoll, steals datagrams and
+// readiness events from the client. Options the package sets itself, like
+// the buffer sizes or don't fragment, may be overridden. A client without a
+// socket, like one of NewMockUDPClient, fails.
+func (u *UDPClient) SyscallConn() (syscall.RawConn, error) {
+	if u == nil || u.conn == nil {
+		return nil, fmt.Errorf("failed to get SyscallConn due to uninitialized client")
+	}
+
+	rc, err := u.conn.SyscallConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to access socket in SyscallConn - %w", err)
+	}
+	return rc, nil
+}
+
+// isIPv6 reports if the socket of the client is an IPv6 socket.
+func (u *UDPClient) isIPv6() bool {
+	local, ok := u.conn.LocalAddr().(*net.UDPAddr)
+	return ok && local.IP.To4() == nil
+}
+
+// ReadBufferSize returns the effective size in bytes of the kernel receive
+// buffer of the socket. It is supported on unix platforms only.
+func (u *UDPClient) ReadBufferSize() (int, error) {
+	opts, err := u.SocketOptions()
+	if err != nil {
+		return 0, err
+	}
+	return opts.ReadBuffer, nil
+}
+
+// WriteBufferSize returns the effective size in bytes of the kernel send
+// buffer of the socket. It is supported on unix platforms only.
+func (u *UDPClient) WriteBufferSize() (int, error) {
+	opts, err := u.SocketOptions()
+	if err != nil {
+		return 0, err
+	}
+	return opts.WriteBuffer, nil
+}
+
+// setBufferSizes sets the kernel buffers of the socket to the sizes that
+// are not zero, logging those the kernel clamped below the request.
+func (u *UDPClient) setBufferSizes(read, write int) error {
+	if read > 0 {
+		if err := u.conn.SetReadBuffer(read); err != nil {
+			return err
+		}
+	}
+	if write > 0 {
+		if err := u.conn.SetWriteBuffer(write); err != nil {
+			return err
+		}
+	}
+	if read <= 0 && write <= 0 {
+		return nil
+	}
+
+	// The effective sizes are only known on unix
+	opts, err := u.SocketOptions()
+	if err != nil {
+		return nil
+	}
+	if read > 0 && opts.ReadBuffer < read {
+		u.logf("udp: receive buffer clamped to %d bytes, %d requested", opts.ReadBuffer, read)
+	}
+	if write > 0 && opts.WriteBuffer < write {
+		u.logf("udp: send buffer clamped to %d bytes, %d requested", opts.WriteBuffer, write)
+	}
+	return nil
+}
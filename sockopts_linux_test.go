@@ -0,0 +1,92 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestUDPClient_SocketOptions(t *testing.T) {
+	if _, err := (&UDPClient{}).SocketOptions(); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+
+	raw, err := os.ReadFile("/proc/sys/net/core/rmem_max")
+	if err != nil {
+		t.Skip("rmem_max unavailable -", err)
+	}
+	rmemMax, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		t.Fatal("failed to parse rmem_max -", err)
+	}
+
+	for _, laddr := range []*net.UDPAddr{
+		{IP: net.IPv4(127, 0, 0, 1)},
+		{IP: net.IPv6loopback},
+	} {
+		t.Run(laddr.String(), func(t *testing.T) {
+			u, err := NewUDPClient(laddr)
+			if err != nil {
+				t.Skip("loopback unavailable -", err)
+			}
+			defer u.Close()
+
+			const requested = 64 << 20
+			if err = u.conn.SetReadBuffer(requested); err != nil {
+				t.Fatal("failed to set read buffer -", err)
+			}
+
+			opts, err := u.SocketOptions()
+			if err != nil {
+				t.Fatal("failed to get socket options -", err)
+			}
+			// Linux doubles the requested size after clamping it to rmem_max
+			if want := 2 * min(requested, rmemMax); opts.ReadBuffer != want {
+				t.Errorf("expected read buffer %d got %d", want, opts.ReadBuffer)
+			}
+			// The net package enables SO_BROADCAST on every UDP socket
+			if opts.WriteBuffer <= 0 || opts.TTL <= 0 || !opts.Broadcast {
+				t.Errorf("unexpected socket options %+v", opts)
+			}
+		})
+	}
+}
+
+func TestUDPClient_SyscallConn(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	rc, err := u.SyscallConn()
+	if err != nil {
+		t.Fatal("failed to get raw connection -", err)
+	}
+	var typ int
+	var gerr error
+	err = rc.Control(func(fd uintptr) {
+		typ, gerr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_TYPE)
+	})
+	if err != nil || gerr != nil {
+		t.Fatal("failed to read socket type -", err, gerr)
+	}
+	if typ != syscall.SOCK_DGRAM {
+		t.Errorf("expected a datagram socket got type %d", typ)
+	}
+
+	if _, err = (&UDPClient{}).SyscallConn(); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+	mock, _ := NewMockUDPClient()
+	if _, err = mock.SyscallConn(); err == nil {
+		t.Error("expected Error for a client without a socket got nil")
+	}
+}
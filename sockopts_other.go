@@ -0,0 +1,14 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !unix
+
+package udp
+
+import "fmt"
+
+// getSocketOptions reports that reading socket options is unsupported.
+func getSocketOptions(fd uintptr, ipv6 bool) (SocketOptions, error) {
+	return SocketOptions{}, fmt.Errorf("socket options are only supported on unix platforms")
+}
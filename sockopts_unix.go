@@ -0,0 +1,36 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build unix
+
+package udp
+
+import "syscall"
+
+// getSocketOptions reads the options of SocketOptions from fd.
+func getSocketOptions(fd uintptr, ipv6 bool) (opts SocketOptions, err error) {
+	s := int(fd)
+	ttlLevel, ttlName := syscall.IPPROTO_IP, syscall.IP_TTL
+	tosLevel, tosName := syscall.IPPROTO_IP, syscall.IP_TOS
+	if ipv6 {
+		ttlLevel, ttlName = syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS
+		tosLevel, tosName = syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS
+	}
+
+	if opts.ReadBuffer, err = syscall.GetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_RCVBUF); err != nil {
+		return
+	}
+	if opts.WriteBuffer, err = syscall.GetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_SNDBUF); err != nil {
+		return
+	}
+	if opts.TTL, err = syscall.GetsockoptInt(s, ttlLevel, ttlName); err != nil {
+		return
+	}
+	if opts.TOS, err = syscall.GetsockoptInt(s, tosLevel, tosName); err != nil {
+		return
+	}
+	broadcast, err := syscall.GetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_BROADCAST)
+	opts.Broadcast = broadcast != 0
+	return
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import "net"
+
+// sourceFilter selects the senders whose datagrams are received, those in
+// one of nets for an allowlist and the others for a denylist.
+type sourceFilter struct {
+	nets []*net.IPNet
+	deny bool
+}
+
+// SetAllowedSources makes the client receive only the datagrams sent from
+// an address within one of cidrs, those of other senders are dropped without
+// an error and the read goes on with the next datagram until the deadline.
+// It replaces any filter set before, nil or an empty list removes it. The
+// filter applies to Receive and its variants, ReceiveMsg, ReceiveExact,
+// ReceiveBatch and Datagrams, and may be changed while they run.
+func (u *UDPClient) SetAllowedSources(cidrs []*net.IPNet) {
+	u.setSourceFilter(cidrs, false)
+}
+
+// SetDeniedSources works like SetAllowedSources but drops the datagrams
+// sent from an address within one of cidrs, receiving all others.
+func (u *UDPClient) SetDeniedSources(cidrs []*net.IPNet) {
+	u.setSourceFilter(cidrs, true)
+}
+
+// setSourceFilter installs a filter of cidrs, or removes it when empty.
+func (u *UDPClient) setSourceFilter(cidrs []*net.IPNet, deny bool) {
+	if u == nil {
+		return
+	}
+	if len(cidrs) == 0 {
+		u.sources.Store(nil)
+		return
+	}
+	u.sources.Store(&sourceFilter{
+		nets: append([]*net.IPNet(nil), cidrs...),
+		deny: deny,
+	})
+}
+
+// accepts reports whether datagrams from addr pass the source filter and
+// the per source rate limit, counting those dropped by the latter. IPv4
+// networks also match the IPv4-mapped senders of an IPv6 socket. The peers
+// of a unixgram client have no IP and only pass a denylist.
+func (u *UDPClient) accepts(from net.Addr) bool {
+	addr := udpAddr(from)
+	if f := u.sources.Load(); f != nil && !f.accepts(addr) {
+		return false
+	}
+	if u.sourceLimits != nil && addr != nil && !u.sourceLimits.allow(addr.IP) {
+		u.stats.rateLimited.Add(1)
+		return false
+	}
+	return true
+}
+
+// accepts reports whether the filter lets datagrams from addr through, a
+// nil addr being in none of its networks.
+func (f *sourceFilter) accepts(addr *net.UDPAddr) bool {
+	if addr == nil {
+		return f.deny
+	}
+	for _, n := range f.nets {
+		if n.Contains(addr.IP) {
+			return !f.deny
+		}
+	}
+	return f.deny
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_SetAllowedSources(t *testing.T) {
+	rx, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer rx.Close()
+	rx.ReadDeadline = 100 * time.Millisecond
+	dst := rx.LocalAddr().(*net.UDPAddr)
+
+	allowed, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create allowed sender -", err)
+	}
+	defer allowed.Close()
+	// 127.0.0.2 is only local without configuration on Linux
+	other, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 2)})
+	if err != nil {
+		t.Skip("second loopback address unavailable -", err)
+	}
+	defer other.Close()
+
+	_, only, _ := net.ParseCIDR("127.0.0.1/32")
+	rx.SetAllowedSources([]*net.IPNet{only})
+
+	buf := make([]byte, maxBufferSize)
+	receive := func(want string, from *UDPClient) {
+		t.Helper()
+		n, err := rx.Receive(buf)
+		if err != nil {
+			t.Fatalf("failed to receive %q - %v", want, err)
+		}
+		if string(buf[:n]) != want || !sameUDPAddr(rx.RemoteAddr.(*net.UDPAddr), from.LocalAddr().(*net.UDPAddr)) {
+			t.Errorf("expected %q from %v got %q from %v", want, from.LocalAddr(), buf[:n], rx.RemoteAddr)
+		}
+	}
+
+	// The datagram of the other sender is skipped by the same Receive
+	other.Transmit(dst, []byte("blocked"))
+	allowed.Transmit(dst, []byte("allowed"))
+	receive("allowed", allowed)
+
+	other.Transmit(dst, []byte("blocked"))
+	if n, err := rx.Receive(buf); !IsTimeout(err) {
+		t.Errorf("expected timeout got %q and %v", buf[:n], err)
+	}
+
+	bufs := [][]byte{make([]byte, maxBufferSize), make([]byte, maxBufferSize)}
+	other.Transmit(dst, []byte("blocked"))
+	allowed.Transmit(dst, []byte("allowed"))
+	results, err := rx.ReceiveBatch(bufs)
+	if err != nil {
+		t.Fatal("failed to receive batch -", err)
+	}
+	if len(results) != 1 || string(bufs[results[0].Buf][:results[0].N]) != "allowed" ||
+		!sameUDPAddr(results[0].Addr, allowed.LocalAddr().(*net.UDPAddr)) {
+		t.Errorf("expected only the allowed datagram got %d", len(results))
+	}
+
+	// A denylist inverts the selection
+	rx.SetDeniedSources([]*net.IPNet{only})
+	allowed.Transmit(dst, []byte("denied"))
+	other.Transmit(dst, []byte("other"))
+	receive("other", other)
+
+	// Without a filter every sender is received
+	rx.SetAllowedSources(nil)
+	allowed.Transmit(dst, []byte("first"))
+	receive("first", allowed)
+	other.Transmit(dst, []byte("second"))
+	receive("second", other)
+}
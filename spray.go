@@ -0,0 +1,78 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sync/atomic"
+)
+
+// SprayClient spreads outgoing datagrams across several local sockets, each
+// bound to its own source port. Receivers hashing on the 4-tuple (ECMP, RSS)
+// then distribute the traffic over multiple queues and cores instead of
+// pinning it to one.
+type SprayClient struct {
+	clients []*UDPClient
+	next    atomic.Uint64
+}
+
+// NewSprayClient creates a SprayClient owning n sockets bound to the IP of
+// laddr on ephemeral ports. The port of laddr is ignored.
+func NewSprayClient(n int, laddr *net.UDPAddr) (*SprayClient, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("parameter error in NewSprayClient")
+	}
+
+	local := &net.UDPAddr{}
+	if laddr != nil {
+		local.IP = laddr.IP
+		local.Zone = laddr.Zone
+	}
+
+	s := &SprayClient{clients: make([]*UDPClient, 0, n)}
+	for i := 0; i < n; i++ {
+		u, err := NewUDPClient(local)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to create socket %d in NewSprayClient - %w", i, err)
+		}
+		s.clients = append(s.clients, u)
+	}
+	return s, nil
+}
+
+// Clients returns the sockets owned by the SprayClient in rotation order,
+// for example to receive replies or adjust deadlines.
+func (s *SprayClient) Clients() []*UDPClient {
+	return s.clients
+}
+
+// Transmit sends data to addr from the next socket in round-robin order.
+func (s *SprayClient) Transmit(addr *net.UDPAddr, data []byte) (int, error) {
+	i := (s.next.Add(1) - 1) % uint64(len(s.clients))
+	return s.clients[i].Transmit(addr, data)
+}
+
+// TransmitHashed sends data to addr from the socket selected by hashing key,
+// so datagrams of the same flow always leave from the same source port.
+func (s *SprayClient) TransmitHashed(key []byte, addr *net.UDPAddr, data []byte) (int, error) {
+	h := fnv.New64a()
+	h.Write(key)
+	return s.clients[h.Sum64()%uint64(len(s.clients))].Transmit(addr, data)
+}
+
+// Close closes all the sockets owned by the SprayClient.
+func (s *SprayClient) Close() error {
+	var errs []error
+	for _, u := range s.clients {
+		if err := u.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
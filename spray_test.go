@@ -0,0 +1,67 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSprayClient(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+	if _, err := NewSprayClient(0, loopback); err == nil {
+		t.Error("expected Error(no sockets) got nil")
+	}
+
+	s, err := NewSprayClient(3, loopback)
+	if err != nil {
+		t.Fatal("failed to create spray client -", err)
+	}
+	defer s.Close()
+
+	r, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer r.Close()
+	dst := r.LocalAddr().(*net.UDPAddr)
+
+	receive := func() int {
+		t.Helper()
+		if _, err := r.Receive(make([]byte, maxBufferSize)); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		return r.RemoteAddr.(*net.UDPAddr).Port
+	}
+
+	t.Run("Round robin", func(t *testing.T) {
+		clients := s.Clients()
+		for i := 0; i < 2*len(clients); i++ {
+			if _, err := s.Transmit(dst, []byte("testing")); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			want := clients[i%len(clients)].LocalAddr().(*net.UDPAddr).Port
+			if got := receive(); got != want {
+				t.Errorf("datagram %d expected source port %d got %d", i, want, got)
+			}
+		}
+	})
+
+	t.Run("Hashed", func(t *testing.T) {
+		var first int
+		for i := 0; i < 3; i++ {
+			if _, err := s.TransmitHashed([]byte("flow-1"), dst, []byte("testing")); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			port := receive()
+			if i == 0 {
+				first = port
+			} else if port != first {
+				t.Errorf("expected flow to stay on port %d got %d", first, port)
+			}
+		}
+	})
+}
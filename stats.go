@@ -0,0 +1,81 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import "sync/atomic"
+
+// Stats is a snapshot of the traffic counters of a client. Byte counts are
+// those of the datagrams on the wire, including the framing added by
+// WithChecksum and WithCompression.
+type Stats struct {
+	BytesSent       uint64 // bytes of the datagrams sent
+	BytesReceived   uint64 // bytes of the datagrams received
+	PacketsSent     uint64 // datagrams sent
+	PacketsReceived uint64 // datagrams received, including truncated ones
+	Errors          uint64 // errors of the client, those kept by RecentErrors
+	RateLimited     uint64 // datagrams dropped by WithPerSourceRateLimit
+	KernelDrops     uint64 // datagrams dropped by the kernel, see KernelDrops
+}
+
+// counters holds the live values behind Stats.
+type counters struct {
+	bytesSent       atomic.Uint64
+	bytesReceived   atomic.Uint64
+	packetsSent     atomic.Uint64
+	packetsReceived atomic.Uint64
+	errors          atomic.Uint64
+	rateLimited     atomic.Uint64
+}
+
+// sent accounts for a datagram of n bytes sent.
+func (c *counters) sent(n int) {
+	c.packetsSent.Add(1)
+	c.bytesSent.Add(uint64(n))
+}
+
+// received accounts for a datagram of n bytes received.
+func (c *counters) received(n int) {
+	c.packetsReceived.Add(1)
+	c.bytesReceived.Add(uint64(n))
+}
+
+// Stats returns a snapshot of the traffic counters of the client, which is
+// safe to call concurrently with transmissions and receptions. The counters
+// are read one at a time so they may be off by the datagrams in flight.
+func (u *UDPClient) Stats() Stats {
+	if u == nil {
+		return Stats{}
+	}
+	return Stats{
+		BytesSent:       u.stats.bytesSent.Load(),
+		BytesReceived:   u.stats.bytesReceived.Load(),
+		PacketsSent:     u.stats.packetsSent.Load(),
+		PacketsReceived: u.stats.packetsReceived.Load(),
+		Errors:          u.stats.errors.Load(),
+		RateLimited:     u.stats.rateLimited.Load(),
+		KernelDrops:     uint64(u.kernelDrops.Load() - u.kernelDropsBase.Load()),
+	}
+}
+
+// SnapshotAndReset returns the traffic counters of the client like Stats
+// and zeroes them, so that successive snapshots add up to the whole traffic
+// without gaps or overlaps, for exporters reporting deltas. It must not be
+// combined with exporters of the running totals such as the metrics
+// package, whose counters would go backwards.
+func (u *UDPClient) SnapshotAndReset() Stats {
+	if u == nil {
+		return Stats{}
+	}
+	drops := u.kernelDrops.Load()
+	return Stats{
+		BytesSent:       u.stats.bytesSent.Swap(0),
+		BytesReceived:   u.stats.bytesReceived.Swap(0),
+		PacketsSent:     u.stats.packetsSent.Swap(0),
+		PacketsReceived: u.stats.packetsReceived.Swap(0),
+		Errors:          u.stats.errors.Swap(0),
+		RateLimited:     u.stats.rateLimited.Swap(0),
+		KernelDrops:     uint64(drops - u.kernelDropsBase.Swap(drops)),
+	}
+}
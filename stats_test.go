@@ -0,0 +1,190 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUDPClient_Stats(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	const packets, size = 10, 100
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		// Snapshots are safe while the counters change
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			u.Stats()
+		}
+	}()
+
+	buf := make([]byte, maxBufferSize)
+	for i := 0; i < packets; i++ {
+		if _, err = u.Transmit(dst, make([]byte, size)); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err = u.Receive(buf); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+	}
+	// One timeout and one parameter error
+	u.Receive(buf)
+	u.Transmit(nil, nil)
+	wg.Wait()
+
+	want := Stats{
+		BytesSent:       packets * size,
+		BytesReceived:   packets * size,
+		PacketsSent:     packets,
+		PacketsReceived: packets,
+		Errors:          2,
+	}
+	if got := u.Stats(); got != want {
+		t.Errorf("expected %+v got %+v", want, got)
+	}
+
+	var nilClient *UDPClient
+	if got := nilClient.Stats(); got != (Stats{}) {
+		t.Errorf("expected empty stats for nil client got %+v", got)
+	}
+}
+
+func TestUDPClient_StatsAllPaths(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+	data := make([]byte, 100)
+
+	results, err := u.TransmitMultiConcurrent([]*net.UDPAddr{dst, dst, dst}, data, 2, time.Second)
+	if err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatal("failed to transmit -", r.Err)
+		}
+	}
+	sent := len(results)
+	if runtime.GOOS == "linux" {
+		if _, err = u.TransmitWithTTL(dst, data, 8); err != nil {
+			t.Fatal("failed to transmit with TTL -", err)
+		}
+		if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		sent++
+	}
+
+	if _, _, _, err = u.ReceiveExact(make([]byte, maxBufferSize)); err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, err := range u.Datagrams(ctx) {
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		break
+	}
+	dgs, _ := u.ReceiveChan(ctx, 0)
+	if _, ok := <-dgs; !ok {
+		t.Fatal("failed to receive from the channel")
+	}
+	cancel()
+	for range dgs {
+	}
+
+	want := Stats{
+		BytesSent:       uint64(sent * len(data)),
+		BytesReceived:   uint64(sent * len(data)),
+		PacketsSent:     uint64(sent),
+		PacketsReceived: uint64(sent),
+	}
+	if got := u.Stats(); got != want {
+		t.Errorf("expected %+v got %+v", want, got)
+	}
+}
+
+func TestUDPClient_SnapshotAndReset(t *testing.T) {
+	const (
+		senders = 8
+		count   = 500
+		size    = 10
+	)
+
+	u, _ := NewMockUDPClient()
+	defer u.Close()
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				if _, err := u.Transmit(peer, make([]byte, size)); err != nil {
+					t.Error("failed to transmit -", err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var total Stats
+	add := func(s Stats) {
+		total.PacketsSent += s.PacketsSent
+		total.BytesSent += s.BytesSent
+	}
+	for exported := false; !exported; {
+		select {
+		case <-done:
+			exported = true
+		default:
+			add(u.SnapshotAndReset())
+		}
+	}
+	add(u.SnapshotAndReset())
+
+	if total.PacketsSent != senders*count || total.BytesSent != senders*count*size {
+		t.Errorf("expected %d datagrams of %d bytes got %+v", senders*count, size, total)
+	}
+	if got := u.Stats(); got != (Stats{}) {
+		t.Errorf("expected zeroed counters got %+v", got)
+	}
+
+	u.kernelDrops.Store(5)
+	if got := u.SnapshotAndReset().KernelDrops; got != 5 {
+		t.Errorf("expected 5 kernel drops got %d", got)
+	}
+	u.kernelDrops.Store(7)
+	if got := u.Stats().KernelDrops; got != 2 {
+		t.Errorf("expected 2 kernel drops since the reset got %d", got)
+	}
+
+	var nilClient *UDPClient
+	if got := nilClient.SnapshotAndReset(); got != (Stats{}) {
+		t.Errorf("expected empty stats for nil client got %+v", got)
+	}
+}
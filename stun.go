@@ -0,0 +1,174 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+)
+
+// STUN message constants from RFC 5389.
+const (
+	stunHeaderSize           = 20
+	stunMagicCookie          = 0x2112A442
+	stunBindingRequest       = 0x0001
+	stunBindingSuccess       = 0x0101
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXORMappedAddress = 0x0020
+	stunFamilyIPv4           = 0x01
+	stunFamilyIPv6           = 0x02
+	stunRetransmits          = 3
+)
+
+// stunRTO is the initial retransmission timeout of a STUN request, doubled
+// on every retransmission as RFC 5389 describes, replaceable for testing.
+var stunRTO = 500 * time.Millisecond
+
+// DiscoverPublicAddr sends a STUN binding request (RFC 5389) to the server at
+// the "host:port" stunServer and returns the public address the server saw
+// the request coming from. Peers behind a NAT can exchange these addresses to
+// punch holes towards each other. The server is resolved for the network of
+// the client. The request is retransmitted when no reply arrives within the
+// retransmission timeout, starting at 500ms and doubling, up to 3 times in
+// all. Datagrams from other senders, those dropped on reception and
+// malformed responses are skipped while waiting.
+func (u *UDPClient) DiscoverPublicAddr(stunServer string) (netip.AddrPort, error) {
+	if u == nil || u.conn == nil {
+		return netip.AddrPort{}, fmt.Errorf("failed to DiscoverPublicAddr due to uninitialized client")
+	}
+
+	network := u.network
+	if network != "udp4" && network != "udp6" {
+		network = "udp"
+	}
+	addr, err := net.ResolveUDPAddr(network, stunServer)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("failed to resolve STUN server - %w", err)
+	}
+
+	req := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	if _, err = rand.Read(req[8:stunHeaderSize]); err != nil {
+		return netip.AddrPort{}, fmt.Errorf("failed to generate STUN transaction id - %w", err)
+	}
+
+	rb := make([]byte, 1500)
+	rto := stunRTO
+	for attempt := 0; attempt < stunRetransmits; attempt++ {
+		if _, err = u.Transmit(addr, req); err != nil {
+			return netip.AddrPort{}, fmt.Errorf("failed to send STUN request - %w", err)
+		}
+
+		deadline := time.Now().Add(rto)
+		rto *= 2
+		for {
+			n, from, err := u.receiveFrom(rb, deadline)
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				// Retransmit the request
+				break
+			}
+			if err != nil && from == nil {
+				return netip.AddrPort{}, fmt.Errorf("failed to receive STUN response - %w", err)
+			}
+			if err != nil || !sameUDPAddr(udpAddr(from), addr) ||
+				n < stunHeaderSize || !bytes.Equal(rb[8:stunHeaderSize], req[8:stunHeaderSize]) {
+				// A datagram dropped on reception or not the response to
+				// this request
+				continue
+			}
+			if ap, err := parseSTUNResponse(rb[:n]); err == nil {
+				return ap, nil
+			}
+		}
+	}
+	return netip.AddrPort{}, fmt.Errorf("failed to receive STUN response - %w", os.ErrDeadlineExceeded)
+}
+
+// parseSTUNResponse extracts the mapped address from a STUN binding success
+// response, preferring XOR-MAPPED-ADDRESS over MAPPED-ADDRESS.
+func parseSTUNResponse(msg []byte) (netip.AddrPort, error) {
+	if binary.BigEndian.Uint16(msg[0:]) != stunBindingSuccess ||
+		binary.BigEndian.Uint32(msg[4:]) != stunMagicCookie {
+		return netip.AddrPort{}, fmt.Errorf("invalid STUN binding response")
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:]))
+	if stunHeaderSize+length > len(msg) {
+		return netip.AddrPort{}, fmt.Errorf("truncated STUN binding response")
+	}
+
+	var mapped netip.AddrPort
+	attrs := msg[stunHeaderSize : stunHeaderSize+length]
+	for len(attrs) >= 4 {
+		typ := binary.BigEndian.Uint16(attrs[0:])
+		size := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+size > len(attrs) {
+			return netip.AddrPort{}, fmt.Errorf("truncated STUN attribute")
+		}
+		value := attrs[4 : 4+size]
+
+		switch typ {
+		case stunAttrXORMappedAddress:
+			return decodeSTUNAddress(value, msg[4:stunHeaderSize])
+		case stunAttrMappedAddress:
+			if ap, err := decodeSTUNAddress(value, nil); err == nil {
+				mapped = ap
+			}
+		}
+
+		// Attributes are padded to a multiple of 4 bytes
+		size = (size + 3) &^ 3
+		if 4+size > len(attrs) {
+			break
+		}
+		attrs = attrs[4+size:]
+	}
+
+	if !mapped.IsValid() {
+		return netip.AddrPort{}, fmt.Errorf("no mapped address in STUN binding response")
+	}
+	return mapped, nil
+}
+
+// decodeSTUNAddress decodes a (XOR-)MAPPED-ADDRESS attribute value. When key
+// holds the magic cookie and transaction id the value is XOR decoded.
+func decodeSTUNAddress(value []byte, key []byte) (netip.AddrPort, error) {
+	if len(value) < 4 {
+		return netip.AddrPort{}, fmt.Errorf("invalid STUN address attribute")
+	}
+
+	var ip []byte
+	switch value[1] {
+	case stunFamilyIPv4:
+		ip = make([]byte, net.IPv4len)
+	case stunFamilyIPv6:
+		ip = make([]byte, net.IPv6len)
+	default:
+		return netip.AddrPort{}, fmt.Errorf("unknown STUN address family %d", value[1])
+	}
+	if len(value) < 4+len(ip) {
+		return netip.AddrPort{}, fmt.Errorf("invalid STUN address attribute")
+	}
+
+	port := binary.BigEndian.Uint16(value[2:])
+	copy(ip, value[4:])
+	if key != nil {
+		port ^= uint16(stunMagicCookie >> 16)
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+
+	a, _ := netip.AddrFromSlice(ip)
+	return netip.AddrPortFrom(a, port), nil
+}
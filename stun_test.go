@@ -0,0 +1,211 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+)
+
+// stunResponse builds a binding success response for req carrying the
+// XOR-MAPPED-ADDRESS of the IPv4 mapped address.
+func stunResponse(req []byte, mapped netip.AddrPort) []byte {
+	resp := make([]byte, stunHeaderSize+12)
+	binary.BigEndian.PutUint16(resp[0:], stunBindingSuccess)
+	binary.BigEndian.PutUint16(resp[2:], 12)
+	copy(resp[4:stunHeaderSize], req[4:stunHeaderSize])
+
+	attr := resp[stunHeaderSize:]
+	binary.BigEndian.PutUint16(attr[0:], stunAttrXORMappedAddress)
+	binary.BigEndian.PutUint16(attr[2:], 8)
+	attr[5] = stunFamilyIPv4
+	binary.BigEndian.PutUint16(attr[6:], mapped.Port()^uint16(stunMagicCookie>>16))
+	ip := mapped.Addr().As4()
+	binary.BigEndian.PutUint32(attr[8:], binary.BigEndian.Uint32(ip[:])^stunMagicCookie)
+	return resp
+}
+
+func TestUDPClient_DiscoverPublicAddr(t *testing.T) {
+	mapped := netip.MustParseAddrPort("203.0.113.5:54321")
+
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create STUN server -", err)
+	}
+	defer server.Close()
+	server.ReadDeadline = time.Second
+
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		n, err := server.Receive(buf)
+		if err != nil || n < stunHeaderSize {
+			t.Error("failed to receive STUN request -", err)
+			return
+		}
+		if typ := binary.BigEndian.Uint16(buf); typ != stunBindingRequest {
+			t.Errorf("expected binding request got type %#x", typ)
+		}
+		_, err = server.Transmit(server.RemoteAddr.(*net.UDPAddr), stunResponse(buf[:n], mapped))
+		if err != nil {
+			t.Error("failed to send STUN response -", err)
+		}
+	}()
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.ReadDeadline = time.Second
+
+	got, err := u.DiscoverPublicAddr(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal("failed to discover public address -", err)
+	}
+	if got != mapped {
+		t.Errorf("expected %v got %v", mapped, got)
+	}
+}
+
+func TestUDPClient_DiscoverPublicAddr_Stray(t *testing.T) {
+	mapped := netip.MustParseAddrPort("203.0.113.5:54321")
+
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create STUN server -", err)
+	}
+	defer server.Close()
+	server.ReadDeadline = time.Second
+	noise, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create noise client -", err)
+	}
+	defer noise.Close()
+	noise.AllowEmptyDatagrams = true
+
+	// Empty, oversized and forged datagrams precede the response
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		n, err := server.Receive(buf)
+		if err != nil || n < stunHeaderSize {
+			t.Error("failed to receive STUN request -", err)
+			return
+		}
+		client := server.RemoteAddr.(*net.UDPAddr)
+		forged := stunResponse(buf[:n], netip.MustParseAddrPort("198.51.100.1:1"))
+		for _, data := range [][]byte{nil, make([]byte, 2000), forged} {
+			if _, err = noise.Transmit(client, data); err != nil {
+				t.Error("failed to send noise -", err)
+			}
+		}
+		malformed := append([]byte(nil), buf[:stunHeaderSize]...)
+		if _, err = server.Transmit(client, malformed); err != nil {
+			t.Error("failed to send malformed response -", err)
+		}
+		if _, err = server.Transmit(client, stunResponse(buf[:n], mapped)); err != nil {
+			t.Error("failed to send STUN response -", err)
+		}
+	}()
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	got, err := u.DiscoverPublicAddr(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal("failed to discover public address -", err)
+	}
+	if got != mapped {
+		t.Errorf("expected %v got %v", mapped, got)
+	}
+}
+
+func TestUDPClient_DiscoverPublicAddr_Retransmit(t *testing.T) {
+	defer func(rto time.Duration) { stunRTO = rto }(stunRTO)
+	stunRTO = 20 * time.Millisecond
+	mapped := netip.MustParseAddrPort("203.0.113.5:54321")
+
+	server, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create STUN server -", err)
+	}
+	defer server.Close()
+	server.ReadDeadline = time.Second
+
+	// The first request is lost, the retransmission is answered
+	go func() {
+		buf := make([]byte, maxBufferSize)
+		for i := 0; i < 2; i++ {
+			n, err := server.Receive(buf)
+			if err != nil || n < stunHeaderSize {
+				t.Error("failed to receive STUN request -", err)
+				return
+			}
+			if i == 0 {
+				continue
+			}
+			_, err = server.Transmit(server.RemoteAddr.(*net.UDPAddr), stunResponse(buf[:n], mapped))
+			if err != nil {
+				t.Error("failed to send STUN response -", err)
+			}
+		}
+	}()
+
+	u, err := NewUDPClientWithOptions(
+		WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}),
+		WithNetwork("udp4"),
+	)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	got, err := u.DiscoverPublicAddr(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal("failed to discover public address -", err)
+	}
+	if got != mapped {
+		t.Errorf("expected %v got %v", mapped, got)
+	}
+
+	// A silent server fails once the retransmissions are exhausted
+	start := time.Now()
+	_, err = u.DiscoverPublicAddr(server.LocalAddr().String())
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected os.ErrDeadlineExceeded got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 7*stunRTO {
+		t.Errorf("expected %v of retransmissions got %v", 7*stunRTO, elapsed)
+	}
+}
+
+func TestParseSTUNResponse_Errors(t *testing.T) {
+	req := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+
+	resp := stunResponse(req, netip.MustParseAddrPort("192.0.2.1:1"))
+	binary.BigEndian.PutUint16(resp[0:], 0x0111)
+	if _, err := parseSTUNResponse(resp); err == nil {
+		t.Error("expected Error(error response) got nil")
+	}
+
+	resp = stunResponse(req, netip.MustParseAddrPort("192.0.2.1:1"))
+	if _, err := parseSTUNResponse(resp[:stunHeaderSize+6]); err == nil {
+		t.Error("expected Error(truncated) got nil")
+	}
+
+	resp = stunResponse(req, netip.MustParseAddrPort("192.0.2.1:1"))[:stunHeaderSize]
+	binary.BigEndian.PutUint16(resp[2:], 0)
+	if _, err := parseSTUNResponse(resp); err == nil {
+		t.Error("expected Error(no address) got nil")
+	}
+}
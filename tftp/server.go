@@ -0,0 +1,106 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tftp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/boseji/udp"
+)
+
+// Error codes from RFC 1350 used by the Server.
+const (
+	errNotDefined      = 0
+	errFileNotFound    = 1
+	errAccessViolation = 2
+	errIllegalOp       = 4
+)
+
+// Server answers TFTP like read and write requests, one transfer at a time.
+type Server struct {
+	u *udp.UDPClient
+
+	// Open returns the content of a file requested by a client.
+	Open func(name string) (io.Reader, error)
+
+	// Create returns the destination of a file sent by a client.
+	Create func(name string) (io.Writer, error)
+
+	// Retries is the number of retransmissions of a packet before giving up.
+	Retries int
+}
+
+// NewServer creates a Server answering requests received on u. The
+// ReadDeadline of u is used as the retransmission timeout.
+func NewServer(u *udp.UDPClient) *Server {
+	return &Server{u: u, Retries: DefaultRetries}
+}
+
+// Serve handles requests until ctx is done. A failed transfer does not stop
+// the server, and empty or truncated datagrams are dropped along with
+// packets other than requests, such as retransmissions of the last block of
+// a transfer already completed. It returns the context error or the first
+// receive error.
+func (s *Server) Serve(ctx context.Context) error {
+	buf := s.u.GetBuffer()
+	defer s.u.PutBuffer(buf)
+	for {
+		r, n, err := s.u.AcceptReliable(ctx, buf)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, udp.ErrTruncated) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if n < 2 {
+			continue
+		}
+		op := binary.BigEndian.Uint16(buf)
+		if op != opRRQ && op != opWRQ {
+			continue
+		}
+		t := newTransfer(s.u, r, s.Retries)
+		_ = s.handle(t, op, buf[:n])
+		t.close()
+	}
+}
+
+// handle runs the transfer requested by the RRQ or WRQ packet pkt.
+func (s *Server) handle(t *transfer, op uint16, pkt []byte) error {
+	name, err := parseRequest(pkt)
+	if err != nil {
+		return t.send(errorPacket(errNotDefined, err.Error()))
+	}
+
+	if op == opRRQ {
+		if s.Open == nil {
+			return t.send(errorPacket(errAccessViolation, "reading is not allowed"))
+		}
+		r, err := s.Open(name)
+		if err != nil {
+			return t.send(errorPacket(errFileNotFound, err.Error()))
+		}
+		return t.sendFile(r)
+	}
+
+	if s.Create == nil {
+		return t.send(errorPacket(errAccessViolation, "writing is not allowed"))
+	}
+	w, err := s.Create(name)
+	if err != nil {
+		return t.send(errorPacket(errAccessViolation, err.Error()))
+	}
+	if err = t.send(block(opACK, 0, nil)); err != nil {
+		return err
+	}
+	return t.receiveFile(w)
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+// Package tftp implements a minimal TFTP like (RFC 1350) block transfer on
+// the reliable layer of the udp package. Files are sent in numbered DATA
+// blocks of BlockSize bytes, each one carried by a udp.ReliableClient which
+// has it acknowledged before the next one is sent, retransmitting it when
+// the acknowledgement does not arrive within the client ReadDeadline.
+//
+// Unlike TFTP the packets travel behind the header of the reliable layer,
+// the whole transfer uses the sockets the request was exchanged on, and only
+// the "octet" mode is supported. As in TFTP a write request is answered with
+// an ACK of block 0, a read request with the first DATA block and a failure
+// with an ERROR packet.
+package tftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/boseji/udp"
+)
+
+// Packet opcodes from RFC 1350.
+const (
+	opRRQ   = 1
+	opWRQ   = 2
+	opDATA  = 3
+	opACK   = 4
+	opERROR = 5
+)
+
+const (
+	// BlockSize is the payload carried by every DATA packet except the
+	// last one, which is shorter and marks the end of the transfer.
+	BlockSize = 512
+
+	// DefaultRetries is the number of retransmissions of a packet before a
+	// transfer is abandoned.
+	DefaultRetries = 5
+
+	// mode is the only transfer mode supported
+	mode = "octet"
+)
+
+// ErrTimeout is returned when the peer stopped responding during a transfer.
+var ErrTimeout = errors.New("tftp transfer timed out")
+
+// Error is an ERROR packet sent by the peer.
+type Error struct {
+	Code    uint16
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tftp error %d - %s", e.Code, e.Message)
+}
+
+// Client performs file transfers with a TFTP like Server.
+type Client struct {
+	u *udp.UDPClient
+
+	// Retries is the number of retransmissions of a packet before giving up.
+	Retries int
+}
+
+// NewClient creates a Client transferring files over u. The ReadDeadline
+// of u is used as the retransmission timeout.
+func NewClient(u *udp.UDPClient) *Client {
+	return &Client{u: u, Retries: DefaultRetries}
+}
+
+// Put uploads the content of r as the file name to the server at addr.
+func (c *Client) Put(addr *net.UDPAddr, name string, r io.Reader) error {
+	t := newTransfer(c.u, udp.NewReliableClient(c.u, addr), c.Retries)
+	defer t.close()
+	err := t.send(request(opWRQ, name))
+	if err == nil {
+		err = t.expect(opACK, 0)
+	}
+	if err == nil {
+		err = t.sendFile(r)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to Put %q - %w", name, err)
+	}
+	return nil
+}
+
+// Get downloads the file name from the server at addr into w.
+func (c *Client) Get(addr *net.UDPAddr, name string, w io.Writer) error {
+	t := newTransfer(c.u, udp.NewReliableClient(c.u, addr), c.Retries)
+	defer t.close()
+	err := t.send(request(opRRQ, name))
+	if err == nil {
+		err = t.receiveFile(w)
+	}
+	if err == nil {
+		t.linger()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to Get %q - %w", name, err)
+	}
+	return nil
+}
+
+// request builds a RRQ or WRQ packet.
+func request(op uint16, name string) []byte {
+	pkt := binary.BigEndian.AppendUint16(nil, op)
+	pkt = append(pkt, name...)
+	pkt = append(pkt, 0)
+	pkt = append(pkt, mode...)
+	return append(pkt, 0)
+}
+
+// parseRequest extracts the file name of a RRQ or WRQ packet.
+func parseRequest(pkt []byte) (string, error) {
+	fields := bytes.SplitN(pkt[2:], []byte{0}, 3)
+	if len(fields) < 3 || len(fields[0]) == 0 {
+		return "", fmt.Errorf("malformed request")
+	}
+	if !bytes.EqualFold(fields[1], []byte(mode)) {
+		return "", fmt.Errorf("unsupported mode %q", fields[1])
+	}
+	return string(fields[0]), nil
+}
+
+// block builds a DATA or ACK packet.
+func block(op, n uint16, data []byte) []byte {
+	pkt := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint16(pkt, op)
+	binary.BigEndian.PutUint16(pkt[2:], n)
+	return append(pkt, data...)
+}
+
+// errorPacket builds an ERROR packet.
+func errorPacket(code uint16, msg string) []byte {
+	pkt := block(opERROR, code, []byte(msg))
+	return append(pkt, 0)
+}
+
+// parseError decodes an ERROR packet.
+func parseError(pkt []byte) error {
+	if len(pkt) < 4 {
+		return &Error{Message: "malformed error"}
+	}
+	msg, _, _ := bytes.Cut(pkt[4:], []byte{0})
+	return &Error{Code: binary.BigEndian.Uint16(pkt[2:]), Message: string(msg)}
+}
+
+// isBlock reports if pkt is the op packet of block n.
+func isBlock(pkt []byte, op, n uint16) bool {
+	return len(pkt) >= 4 && binary.BigEndian.Uint16(pkt) == op && binary.BigEndian.Uint16(pkt[2:]) == n
+}
+
+// transfer exchanges the packets of a transfer with a single peer.
+type transfer struct {
+	u       *udp.UDPClient
+	r       *udp.ReliableClient
+	retries int
+	buf     []byte
+}
+
+// newTransfer creates a transfer over r, retransmitting every ReadDeadline
+// of u and giving up after retries retransmissions.
+func newTransfer(u *udp.UDPClient, r *udp.ReliableClient, retries int) *transfer {
+	if u.ReadDeadline > 0 {
+		r.RetransmitInterval = u.ReadDeadline
+	}
+	r.Timeout = time.Duration(retries+1) * r.RetransmitInterval
+	return &transfer{u: u, r: r, retries: retries, buf: u.GetBuffer()}
+}
+
+// close returns the buffer of the transfer.
+func (t *transfer) close() {
+	t.u.PutBuffer(t.buf)
+}
+
+// send transmits pkt and waits for the peer to acknowledge it.
+func (t *transfer) send(pkt []byte) error {
+	err := t.r.SendReliable(pkt)
+	if errors.Is(err, udp.ErrUnacknowledged) {
+		return ErrTimeout
+	}
+	return err
+}
+
+// receive returns the next packet of the peer, waiting up to retries+1
+// read deadlines for it. An ERROR packet is returned as its *Error.
+func (t *transfer) receive() ([]byte, error) {
+	for attempt := 0; attempt <= t.retries; {
+		n, err := t.r.ReceiveReliable(t.buf)
+		if udp.IsTimeout(err) {
+			attempt++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n >= 4 && binary.BigEndian.Uint16(t.buf) == opERROR {
+			return nil, parseError(t.buf[:n])
+		}
+		return t.buf[:n], nil
+	}
+	return nil, ErrTimeout
+}
+
+// expect receives the op packet of block n.
+func (t *transfer) expect(op, n uint16) error {
+	pkt, err := t.receive()
+	if err != nil {
+		return err
+	}
+	if !isBlock(pkt, op, n) {
+		_ = t.send(errorPacket(errIllegalOp, "unexpected packet"))
+		return fmt.Errorf("unexpected packet")
+	}
+	return nil
+}
+
+// abort reports the local failure err to the peer before returning it.
+func (t *transfer) abort(err error) error {
+	_ = t.send(errorPacket(errNotDefined, err.Error()))
+	return err
+}
+
+// sendFile sends the content of r to the peer as DATA blocks.
+func (t *transfer) sendFile(r io.Reader) error {
+	data := make([]byte, BlockSize)
+	for n := uint16(1); ; n++ {
+		size, err := io.ReadFull(r, data)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return t.abort(err)
+		}
+		if err = t.send(block(opDATA, n, data[:size])); err != nil {
+			return err
+		}
+		if size < BlockSize {
+			return nil
+		}
+	}
+}
+
+// receiveFile writes the DATA blocks sent by the peer into w.
+func (t *transfer) receiveFile(w io.Writer) error {
+	for n := uint16(1); ; n++ {
+		pkt, err := t.receive()
+		if err != nil {
+			return err
+		}
+		if !isBlock(pkt, opDATA, n) {
+			return t.abort(fmt.Errorf("unexpected packet"))
+		}
+		if _, err = w.Write(pkt[4:]); err != nil {
+			return t.abort(err)
+		}
+		if len(pkt)-4 < BlockSize {
+			return nil
+		}
+	}
+}
+
+// linger waits one read deadline after the last block, acknowledging it
+// again if the peer retransmits it because the acknowledgement was lost.
+// Without a ReadDeadline there is nothing to bound the wait and it returns.
+func (t *transfer) linger() {
+	if t.u.ReadDeadline > 0 {
+		_, _ = t.r.ReceiveReliable(t.buf)
+	}
+}
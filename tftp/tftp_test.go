@@ -0,0 +1,188 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boseji/udp"
+)
+
+// store is an in-memory file system for the test server.
+type store struct {
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+func (s *store) Open(name string) (io.Reader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return bytes.NewReader(f.Bytes()), nil
+}
+
+func (s *store) Create(name string) (io.Writer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = &bytes.Buffer{}
+	return s.files[name], nil
+}
+
+func newClient(t *testing.T) *udp.UDPClient {
+	t.Helper()
+	u, err := udp.NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	u.ReadDeadline = 100 * time.Millisecond
+	return u
+}
+
+func TestTransfer(t *testing.T) {
+	su := newClient(t)
+	defer su.Close()
+	fs := &store{files: map[string]*bytes.Buffer{}}
+	server := NewServer(su)
+	server.Open = fs.Open
+	server.Create = fs.Create
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Error("expected server to stop on cancel got", err)
+		}
+	}()
+
+	cu := newClient(t)
+	defer cu.Close()
+	client := NewClient(cu)
+	addr := su.LocalAddr().(*net.UDPAddr)
+
+	for _, size := range []int{0, 100, 2 * BlockSize, 5000} {
+		content := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(content)
+
+		if err := client.Put(addr, "file.bin", bytes.NewReader(content)); err != nil {
+			t.Fatalf("failed to put %d bytes - %v", size, err)
+		}
+		var got bytes.Buffer
+		if err := client.Get(addr, "file.bin", &got); err != nil {
+			t.Fatalf("failed to get %d bytes - %v", size, err)
+		}
+		if !bytes.Equal(got.Bytes(), content) {
+			t.Errorf("expected %d identical bytes got %d", size, got.Len())
+		}
+	}
+
+	var got bytes.Buffer
+	err := client.Get(addr, "missing.bin", &got)
+	var terr *Error
+	if !errors.As(err, &terr) || terr.Code != errFileNotFound {
+		t.Errorf("expected file not found error got %v", err)
+	}
+}
+
+func TestTransfer_Timeout(t *testing.T) {
+	// Nobody answers on the address of a closed client
+	dead := newClient(t)
+	addr := dead.LocalAddr().(*net.UDPAddr)
+	dead.Close()
+
+	cu := newClient(t)
+	defer cu.Close()
+	client := NewClient(cu)
+	client.Retries = 1
+
+	err := client.Put(addr, "file.bin", bytes.NewReader([]byte("testing")))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout got %v", err)
+	}
+}
+
+func TestTransfer_DropsBadDatagrams(t *testing.T) {
+	su := newClient(t)
+	defer su.Close()
+	su.SetMaxPacketSize(4 + BlockSize + 64)
+	fs := &store{files: map[string]*bytes.Buffer{}}
+	server := NewServer(su)
+	server.Open = fs.Open
+	server.Create = fs.Create
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Error("expected server to stop on cancel got", err)
+		}
+	}()
+
+	cu := newClient(t)
+	defer cu.Close()
+	client := NewClient(cu)
+	addr := su.LocalAddr().(*net.UDPAddr)
+
+	// Empty and oversized datagrams reach both the server and the client
+	noise := newClient(t)
+	defer noise.Close()
+	noise.AllowEmptyDatagrams = true
+	for _, dst := range []*net.UDPAddr{addr, cu.LocalAddr().(*net.UDPAddr)} {
+		for _, data := range [][]byte{nil, make([]byte, 2*BlockSize)} {
+			if _, err := noise.Transmit(dst, data); err != nil {
+				t.Fatal("failed to transmit noise -", err)
+			}
+		}
+	}
+
+	content := []byte("Still waters run deep")
+	if err := client.Put(addr, "file.bin", bytes.NewReader(content)); err != nil {
+		t.Fatal("failed to put -", err)
+	}
+	var got bytes.Buffer
+	if err := client.Get(addr, "file.bin", &got); err != nil {
+		t.Fatal("failed to get -", err)
+	}
+	if !bytes.Equal(got.Bytes(), content) {
+		t.Errorf("expected %q got %q", content, got.Bytes())
+	}
+}
+
+func TestServer_Cancel(t *testing.T) {
+	su := newClient(t)
+	defer su.Close()
+	su.ReadDeadline = 0
+	server := NewServer(su)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Error("expected server to stop on cancel got", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected server to stop on cancel without a read deadline")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// TransmitWithTTL works like Transmit but sends the datagram with the given
+// IP TTL (hop limit for IPv6 destinations), carried in a control message so
+// the socket wide setting is left untouched for other datagrams. This is
+// useful for traceroute like probes. It is supported on Linux only.
+func (u *UDPClient) TransmitWithTTL(addr *net.UDPAddr, data []byte, ttl int) (
+	n int,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to TransmitWithTTL due to uninitialized client")
+		return
+	}
+	defer func() { u.recordError("TransmitWithTTL", err) }()
+
+	if addr == nil || (len(data) == 0 && !u.AllowEmptyDatagrams) || ttl < 1 || ttl > 255 {
+		err = fmt.Errorf("parameter error in TransmitWithTTL")
+		return
+	}
+
+	if u.quiesced.Load() {
+		err = fmt.Errorf("failed to TransmitWithTTL - %w", ErrQuiesced)
+		return
+	}
+
+	oob, err := ttlControlMessage(addr, ttl)
+	if err != nil {
+		err = fmt.Errorf("failed to build TTL control message in TransmitWithTTL - %w", err)
+		return
+	}
+
+	return u.writeDatagram("TransmitWithTTL", addr, data, time.Time{}, func(wire []byte) (n int, err error) {
+		n, _, err = u.conn.WriteMsgUDP(wire, oob, addr)
+		return
+	})
+}
+
+// SetTTL sets the IP TTL (hop limit for IPv6 sockets) of all following
+// datagrams, both unicast and multicast. A TTL of 1 keeps discovery packets
+// on the local segment.
+func (u *UDPClient) SetTTL(ttl int) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetTTL due to uninitialized client")
+	}
+
+	if ttl < 1 || ttl > 255 {
+		return fmt.Errorf("parameter error in SetTTL")
+	}
+
+	var err error
+	if u.isIPv6() {
+		p := ipv6.NewPacketConn(u.conn)
+		if err = p.SetHopLimit(ttl); err == nil {
+			err = p.SetMulticastHopLimit(ttl)
+		}
+	} else {
+		p := ipv4.NewPacketConn(u.conn)
+		if err = p.SetTTL(ttl); err == nil {
+			err = p.SetMulticastTTL(ttl)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set TTL in SetTTL - %w", err)
+	}
+
+	return nil
+}
+
+// GetTTL returns the IP TTL (hop limit for IPv6 sockets) of unicast
+// datagrams sent by the client.
+func (u *UDPClient) GetTTL() (int, error) {
+	if u == nil || u.conn == nil {
+		return 0, fmt.Errorf("failed to GetTTL due to uninitialized client")
+	}
+
+	var ttl int
+	var err error
+	if u.isIPv6() {
+		ttl, err = ipv6.NewPacketConn(u.conn).HopLimit()
+	} else {
+		ttl, err = ipv4.NewPacketConn(u.conn).TTL()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get TTL in GetTTL - %w", err)
+	}
+
+	return ttl, nil
+}
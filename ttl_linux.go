@@ -0,0 +1,29 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// ttlControlMessage builds an IP_TTL or IPV6_HOPLIMIT control message
+// matching the family of the destination.
+func ttlControlMessage(addr *net.UDPAddr, ttl int) ([]byte, error) {
+	level, typ := syscall.IPPROTO_IP, syscall.IP_TTL
+	if addr.IP != nil && addr.IP.To4() == nil {
+		level, typ = syscall.IPPROTO_IPV6, syscall.IPV6_HOPLIMIT
+	}
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	h.Level = int32(level)
+	h.Type = int32(typ)
+	h.SetLen(syscall.CmsgLen(4))
+	binary.NativeEndian.PutUint32(oob[syscall.CmsgLen(0):], uint32(ttl))
+	return oob, nil
+}
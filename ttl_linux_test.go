@@ -0,0 +1,86 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// receiveTTL reads one datagram and returns the TTL it arrived with.
+func receiveTTL(t *testing.T, conn packetConn) int {
+	t.Helper()
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUDP(make([]byte, maxBufferSize), oob)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		t.Fatal("failed to parse control message -", err)
+	}
+	for _, m := range msgs {
+		if m.Header.Level == syscall.IPPROTO_IP && m.Header.Type == syscall.IP_TTL {
+			return int(binary.NativeEndian.Uint32(m.Data))
+		}
+	}
+	t.Fatal("no TTL control message received")
+	return 0
+}
+
+func TestUDPClient_TransmitWithTTL(t *testing.T) {
+	r, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create receiver -", err)
+	}
+	defer r.Close()
+	rc, err := r.conn.SyscallConn()
+	if err != nil {
+		t.Fatal("failed to get raw conn -", err)
+	}
+	_ = rc.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_RECVTTL, 1)
+	})
+	if err != nil {
+		t.Fatal("failed to enable IP_RECVTTL -", err)
+	}
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := r.LocalAddr().(*net.UDPAddr)
+
+	if _, err = u.TransmitWithTTL(dst, []byte("probe"), 1); err != nil {
+		t.Fatal("failed to transmit with TTL -", err)
+	}
+	if _, err = u.Transmit(dst, []byte("regular")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+
+	if ttl := receiveTTL(t, r.conn); ttl != 1 {
+		t.Errorf("expected TTL 1 on the probe got %d", ttl)
+	}
+	if ttl := receiveTTL(t, r.conn); ttl == 1 {
+		t.Error("expected the default TTL on the following datagram got 1")
+	}
+
+	if _, err = u.TransmitWithTTL(dst, []byte("probe"), 0); err == nil {
+		t.Error("expected Error(invalid TTL) got nil")
+	}
+
+	// Failures are reported like those of Transmit
+	errs := u.Stats().Errors
+	if _, err = u.TransmitWithTTL(dst, make([]byte, 70000), 8); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("expected ErrPacketTooLarge got %v", err)
+	}
+	if got := u.Stats().Errors - errs; got != 1 {
+		t.Errorf("expected the failure to be recorded got %d errors", got)
+	}
+}
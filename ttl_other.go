@@ -0,0 +1,17 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+//go:build !linux
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// ttlControlMessage reports that per datagram TTL is unsupported.
+func ttlControlMessage(addr *net.UDPAddr, ttl int) ([]byte, error) {
+	return nil, fmt.Errorf("per datagram TTL is only supported on linux")
+}
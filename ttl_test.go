@@ -0,0 +1,48 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestUDPClient_SetTTL(t *testing.T) {
+	for _, laddr := range []*net.UDPAddr{
+		{IP: net.IPv4(127, 0, 0, 1)},
+		{IP: net.IPv6loopback},
+	} {
+		t.Run(laddr.String(), func(t *testing.T) {
+			u, err := NewUDPClient(laddr)
+			if err != nil {
+				t.Skip("loopback unavailable -", err)
+			}
+			defer u.Close()
+
+			for _, ttl := range []int{1, 64, 255} {
+				if err = u.SetTTL(ttl); err != nil {
+					t.Fatal("failed to set TTL -", err)
+				}
+				got, err := u.GetTTL()
+				if err != nil {
+					t.Fatal("failed to get TTL -", err)
+				}
+				if got != ttl {
+					t.Errorf("expected TTL %d got %d", ttl, got)
+				}
+			}
+
+			for _, ttl := range []int{0, 256} {
+				if err = u.SetTTL(ttl); err == nil {
+					t.Errorf("expected Error for TTL %d got nil", ttl)
+				}
+			}
+		})
+	}
+
+	if _, err := (&UDPClient{}).GetTTL(); err == nil {
+		t.Error("expected Error for uninitialized client got nil")
+	}
+}
@@ -7,14 +7,25 @@
 package udp
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
 
 	// LocalUDPport represents the default local receiving port for UDP client/server
+	// Note: It only applies when no local address is given, Port 0 selects an
+	// ephemeral port.
 	LocalUDPport = 62048
 
 	// ReadDeadline specifies the UDP default ReadDeadline duration value
@@ -26,49 +37,590 @@ const (
 	WriteDeadline = 50 * time.Millisecond
 )
 
+var (
+	// ErrAddressFamilyMismatch is returned when the destination address does not
+	// belong to the address family the local socket is bound to.
+	ErrAddressFamilyMismatch = errors.New("address family mismatch")
+
+	// ErrQuiesced is returned by Transmit while the client is quiesced.
+	ErrQuiesced = errors.New("client is quiesced")
+
+	// ErrPermission is returned when binding the local address is not
+	// permitted, typically for privileged ports below 1024.
+	ErrPermission = errors.New("permission denied")
+
+	// ErrAddrInUse is returned when the local address is already bound.
+	ErrAddrInUse = errors.New("address already in use")
+
+	// ErrEmptyDatagram is returned by Receive for a zero-length datagram
+	// unless AllowEmptyDatagrams is set.
+	ErrEmptyDatagram = errors.New("empty datagram")
+
+	// ErrTruncated is returned by Receive along with the bytes read when the
+	// datagram did not fit in the buffer.
+	ErrTruncated = errors.New("datagram truncated")
+
+	// ErrAlreadyClosed is returned by Close for a client that was never
+	// opened.
+	ErrAlreadyClosed = errors.New("client already closed")
+)
+
 // UDPClient helps to create a local UDP message sender
 // and receiver interface.
 type UDPClient struct {
-	conn          *net.UDPConn
+	conn packetConn
+
+	// ReadDeadline and WriteDeadline bound every Receive and Transmit call,
+	// zero disables the deadline so that the call blocks until it completes.
+	// They are ignored while SetReadDeadline or SetWriteDeadline is active.
 	ReadDeadline  time.Duration
 	WriteDeadline time.Duration
-	RemoteAddr    net.Addr
+
+	// RemoteAddr is the sender of the last datagram read by Receive. It is
+	// not changed by Transmit, so a client may transmit from multiple
+	// goroutines.
+	RemoteAddr net.Addr
+
+	// JitterEstimate enables estimating the inter-arrival jitter of received
+	// datagrams, reported by Jitter.
+	JitterEstimate bool
+
+	// TransmitHook if set is called after every successful transmission with
+	// the number of payload bytes written and the destination. It is not
+	// called for failed transmissions.
+	TransmitHook func(n int, addr net.Addr)
+
+	// WriteBackpressure is the time Transmit keeps retrying while the kernel
+	// is out of buffer space (ENOBUFS) before returning ErrNoBufferSpace.
+	// Zero disables the retries.
+	WriteBackpressure time.Duration
+
+	// AllowEmptyDatagrams permits Transmit to send zero-length datagrams and
+	// makes Receive report a received one as n == 0 with a nil error and the
+	// sender in RemoteAddr. Otherwise Transmit rejects empty data and Receive
+	// returns ErrEmptyDatagram.
+	AllowEmptyDatagrams bool
+
+	// DropCounter enables tracking of datagrams dropped by the kernel due to
+	// a full receive buffer, reported by KernelDrops. It is Linux only;
+	// elsewhere Receive fails while it is set.
+	DropCounter bool
+
+	// RemoteChangeHook if set is called by Receive when a datagram arrives
+	// from a different sender than the previous one, which may reveal an
+	// unexpected peer change or spoofing in connected-style usage.
+	RemoteChangeHook func(old, new net.Addr)
+
+	// OnReceive if set is called after every successful reception with the
+	// sender and the data read. The data aliases the receive buffer, so the
+	// hook must copy it to retain it beyond the call.
+	OnReceive func(addr *net.UDPAddr, data []byte)
+
+	// PacingGap is the minimum interval between the start of consecutive
+	// transmissions, Transmit and the other transmit calls sleep as needed
+	// to keep a steady packet cadence. Zero disables pacing.
+	PacingGap time.Duration
+
+	// ErrorHistory is the number of recent Transmit and Receive errors kept
+	// for RecentErrors. Zero disables the history. It must be set before the
+	// first error occurs.
+	ErrorHistory int
+
+	// MaxPacketSize is the largest datagram the client expects, which sizes
+	// the buffers it allocates on its own. Zero selects MaxDatagramSize.
+	MaxPacketSize int
+
+	// ReusePort makes Bind set SO_REUSEADDR and SO_REUSEPORT, so that
+	// several clients or processes can bind the same port and share its
+	// traffic. It is supported on Linux, macOS and the BSDs only, elsewhere
+	// Bind fails while it is set.
+	ReusePort bool
+
+	// Interface if set makes Bind bind the socket to the network interface,
+	// so that datagrams leave through it whatever the routing table says.
+	// Linux uses SO_BINDTODEVICE, which needs CAP_NET_RAW before Linux 5.7.
+	// Elsewhere the unspecified local address is replaced by an address of
+	// the interface, which only steers the source address on most systems.
+	Interface *net.Interface
+
+	// Resolver resolves the hostnames given to TransmitTo, nil selects
+	// net.DefaultResolver.
+	Resolver *net.Resolver
+
+	// ResolveTTL is the time a hostname resolved by TransmitTo is cached,
+	// zero selects the default ResolveTTL.
+	ResolveTTL time.Duration
+
+	// SharedResolveCache if set caches the hostnames resolved by TransmitTo
+	// instead of the cache of the client, see ResolverCache.
+	SharedResolveCache *ResolverCache
+
+	// QueryRetries is the number of times Query transmits the request again
+	// when no reply arrived in time.
+	QueryRetries int
+
+	// QueryVerifySource makes Query skip replies from other senders than the
+	// queried address.
+	QueryVerifySource bool
+
+	// Logger if set receives internal events such as failed deadline
+	// resets, truncated datagrams and retries. Nil disables the logging.
+	Logger Logger
+
+	// Slog if set receives structured records of every datagram sent and
+	// received at debug level, with remote_addr and bytes, and of failures
+	// at error level, with op and err. Nil disables it.
+	Slog *slog.Logger
+
+	// AutoReconnect makes Serve call Reconnect with an exponential backoff
+	// when a receive fails for another reason than a timeout or the client
+	// being closed, instead of returning the error. Keepalives started by
+	// StartKeepalive wait for the socket to be swapped, while other
+	// goroutines must not use the client meanwhile. It is ignored by
+	// ServeConcurrent, whose workers transmit while Reconnect swaps the
+	// socket.
+	AutoReconnect bool
+
+	// OrderedDispatch makes ServeConcurrent hand all the datagrams of a
+	// sender to the same worker, so that they are handled in their order of
+	// arrival while different senders are still handled in parallel.
+	OrderedDispatch bool
+
+	// ReadBudget is the number of datagrams Serve and ServeConcurrent read
+	// in a row before yielding the processor, so that a flooded socket does
+	// not starve the other goroutines of the process. Zero never yields.
+	ReadBudget int
+
+	// ReceiveRetry if set reports the transient receive errors, on which
+	// ReceiveContext reads again until the context is done instead of
+	// returning. Timeouts are never retried.
+	ReceiveRetry func(err error) bool
+
+	// Readers is the number of goroutines ReceiveChan reads the socket
+	// with, one when below 2. Datagrams read by different goroutines may
+	// be delivered out of order, even those of a single sender.
+	Readers int
+
+	// CopyThreshold when positive makes ReceiveMessage return the datagrams
+	// larger than this many bytes in the pooled buffer they were read into
+	// instead of a copy, see ReceiveMessage. Zero copies every datagram.
+	CopyThreshold int
+
+	network         string        // "udp" when empty, or "udp4", "udp6" or "unixgram"
+	unixAddr        *net.UnixAddr // bound by a unixgram client
+	dialed          *net.UDPAddr  // remote address of a connected socket
+	features        map[Feature]bool
+	quiesced        atomic.Bool
+	closed          atomic.Bool
+	doneMu          sync.Mutex
+	done            chan struct{}
+	isDone          bool         // Close has signalled done
+	readDeadline    atomic.Int64 // Unix nanoseconds, zero when not set
+	writeDeadline   atomic.Int64 // Unix nanoseconds, zero when not set
+	explicitRead    atomic.Int64 // set by SetReadDeadline, zero when not set
+	explicitWrite   atomic.Int64 // set by SetWriteDeadline, zero when not set
+	jitter          jitterEstimator
+	dropsOnce       sync.Once
+	dropsErr        error
+	kernelDrops     atomic.Uint32
+	kernelDropsBase atomic.Uint32 // kernelDrops at the last SnapshotAndReset
+	lastSender      net.Addr
+	pacingMu        sync.Mutex
+	nextTransmit    time.Time
+	errHistory      errorRing
+	bufPool         sync.Pool
+	bufHeaders      sync.Pool // emptied *[]byte of the buffers handed out
+	hosts           ResolverCache
+	stats           counters
+	shutdown        shutdownState
+	background      sync.WaitGroup
+	swapMu          sync.RWMutex // held by background transmissions, locked to swap conn
+	sources         atomic.Pointer[sourceFilter]
+	limiter         *rate.Limiter
+	sourceLimits    *sourceLimiter
+	rateNonBlocking bool
+	checksum        bool
+	compression     Compression
+
+	compressionThreshold int
+	readBuffer           int // requested by WithReadBufferSize, for Reconnect
+	writeBuffer          int // requested by WithWriteBufferSize, for Reconnect
 }
 
 // Close helps to close the local UDP client.
-// This also implements the io.Closer Interface.
+// This also implements the io.Closer Interface. Only the first call closes
+// the socket, later ones return nil so that Close can be both deferred and
+// called explicitly. It returns ErrAlreadyClosed for a client that was never
+// opened.
 func (u *UDPClient) Close() error {
-	defer func() { u.conn = nil }()
+	if u == nil {
+		return ErrAlreadyClosed
+	}
+	if !u.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if u.conn == nil {
+		u.closed.Store(false)
+		return ErrAlreadyClosed
+	}
+	defer func() {
+		// Background goroutines such as keepalives stop on done and must
+		// be gone before the socket is released
+		u.signalDone()
+		u.background.Wait()
+		u.conn = nil
+		u.readDeadline.Store(0)
+		u.writeDeadline.Store(0)
+	}()
 	return u.conn.Close()
 }
 
+// Done returns a channel that is closed once the client is closed, so that
+// goroutines using the client can stop. The channel is created on the first
+// call.
+func (u *UDPClient) Done() <-chan struct{} {
+	if u == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	u.doneMu.Lock()
+	defer u.doneMu.Unlock()
+	if u.done == nil {
+		u.done = make(chan struct{})
+		if u.isDone {
+			close(u.done)
+		}
+	}
+	return u.done
+}
+
+// signalDone closes the channel of Done, or marks it to be created closed.
+func (u *UDPClient) signalDone() {
+	u.doneMu.Lock()
+	defer u.doneMu.Unlock()
+	if u.done != nil && !u.isDone {
+		close(u.done)
+	}
+	u.isDone = true
+}
+
+// goBackground runs f in a goroutine Close waits for, reporting false and
+// running nothing when the client is closing. The closing state is checked
+// under the lock of signalDone, so that Close never waits while a goroutine
+// is being added.
+func (u *UDPClient) goBackground(f func()) bool {
+	u.doneMu.Lock()
+	defer u.doneMu.Unlock()
+	if u.isDone || u.closed.Load() {
+		return false
+	}
+	u.background.Add(1)
+	go func() {
+		defer u.background.Done()
+		f()
+	}()
+	return true
+}
+
+// resetDone gives a client bound again after Close a fresh Done channel.
+func (u *UDPClient) resetDone() {
+	u.doneMu.Lock()
+	defer u.doneMu.Unlock()
+	if u.isDone {
+		u.done = nil
+		u.isDone = false
+	}
+}
+
 // Default setup the required default values needed for the client to function.
 func (u *UDPClient) Default(laddr *net.UDPAddr) (*UDPClient, error) {
 
 	if u == nil {
-		u = &UDPClient{
-			ReadDeadline:  ReadDeadline,
-			WriteDeadline: WriteDeadline,
+		u, _ = NewUnbound()
+	}
+
+	if u.conn == nil {
+		if err := u.Bind(laddr); err != nil {
+			return nil, err
 		}
 	}
 
+	return u, nil
+}
+
+// NewUnbound creates a client configured by opts like
+// NewUDPClientWithOptions but without a socket. It can be bound with Bind,
+// possibly retrying on other addresses when binding fails, while
+// WithLocalAddr is ignored.
+func NewUnbound(opts ...Option) (*UDPClient, error) {
+	c, err := newConfig("NewUnbound", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &UDPClient{}
+	c.apply(u)
+	return u, nil
+}
+
+// Bind opens the socket of an unbound client on the local address laddr,
+// LocalUDPport on all interfaces when nil, with the buffer sizes of the
+// options. A unixgram client is bound to its WithLocalUnixAddr instead. It
+// fails if the client is already bound, a failed Bind leaves the client
+// unbound.
+func (u *UDPClient) Bind(laddr *net.UDPAddr) error {
+	if u == nil {
+		return fmt.Errorf("failed to Bind due to uninitialized client")
+	}
+
+	if u.conn != nil {
+		return fmt.Errorf("failed to Bind as the client is already bound to %v", u.conn.LocalAddr())
+	}
+
 	if laddr == nil {
 		laddr = &net.UDPAddr{Port: LocalUDPport}
 	}
 
-	if u.conn == nil {
-		conn, err := net.ListenUDP("udp", laddr)
+	conn, err := u.open(laddr)
+	if err != nil {
+		return err
+	}
+	u.conn = conn
+	if err = u.setBufferSizes(u.readBuffer, u.writeBuffer); err != nil {
+		u.conn = nil
+		conn.Close()
+		return fmt.Errorf("failed to set buffer size in Bind - %w", err)
+	}
+	u.closed.Store(false)
+	u.resetDone()
+	u.shutdown.reset()
+	u.features = probeFeatures(conn)
+
+	return nil
+}
+
+// open opens the socket of the client on laddr, or on unixAddr for a
+// unixgram client, telling permission and address in use failures apart.
+func (u *UDPClient) open(laddr *net.UDPAddr) (packetConn, error) {
+	network := u.network
+	if network == "" {
+		network = "udp"
+	}
+
+	var conn packetConn
+	var err error
+	if network == "unixgram" {
+		conn, err = listenUnixgram(u.unixAddr)
+	} else {
+		conn, err = u.listen(network, laddr)
+	}
+	switch {
+	case errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		return nil, fmt.Errorf("failed to perform UDP listen in UDPClient - %w"+
+			" (ports below 1024 need elevated privileges) - %w", ErrPermission, err)
+	case errors.Is(err, syscall.EADDRINUSE):
+		return nil, fmt.Errorf("failed to perform UDP listen in UDPClient - %w - %w", ErrAddrInUse, err)
+	case err != nil:
+		return nil, fmt.Errorf("failed to perform UDP listen in UDPClient - %w", err)
+	}
+	return conn, nil
+}
+
+// listen opens the socket for open, applying ReusePort and Interface.
+func (u *UDPClient) listen(network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+	var controls []func(fd uintptr) error
+	if u.ReusePort {
+		controls = append(controls, setReusePort)
+	}
+	if u.Interface != nil {
+		if bindToDeviceSupported {
+			name := u.Interface.Name
+			controls = append(controls, func(fd uintptr) error {
+				return bindToDevice(fd, name)
+			})
+		} else {
+			var err error
+			laddr, err = interfaceAddr(u.Interface, network, laddr)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(controls) == 0 {
+		return net.ListenUDP(network, laddr)
+	}
+
+	lc := net.ListenConfig{Control: func(_, _ string, c syscall.RawConn) error {
+		var cerr error
+		err := c.Control(func(fd uintptr) {
+			for _, control := range controls {
+				if cerr = control(fd); cerr != nil {
+					return
+				}
+			}
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to perform UDP listen in UDPClient - %w", err)
+			return err
 		}
-		u.conn = conn
+		return cerr
+	}}
+	pc, err := lc.ListenPacket(context.Background(), network, laddr.String())
+	if err != nil {
+		return nil, err
 	}
+	return pc.(*net.UDPConn), nil
+}
 
-	return u, nil
+// Quiesce stops the client from transmitting while it keeps receiving, so
+// inbound requests can be finished during maintenance. Transmit returns
+// ErrQuiesced until Unquiesce is called.
+func (u *UDPClient) Quiesce() {
+	u.quiesced.Store(true)
+}
+
+// Unquiesce allows the client to transmit again after Quiesce.
+func (u *UDPClient) Unquiesce() {
+	u.quiesced.Store(false)
+}
+
+// SetReadDeadline sets an absolute deadline for all following reads, for
+// instance to bound a whole request/response exchange. While it is set it
+// takes precedence over ReadDeadline, which Receive no longer applies. A zero
+// t clears it and restores the per-call ReadDeadline.
+func (u *UDPClient) SetReadDeadline(t time.Time) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetReadDeadline due to uninitialized client")
+	}
+	err := u.applyReadDeadline(t)
+	if err != nil {
+		return fmt.Errorf("failed in setting read deadline in SetReadDeadline - %w", err)
+	}
+	if t.IsZero() {
+		u.explicitRead.Store(0)
+	} else {
+		u.explicitRead.Store(t.UnixNano())
+	}
+	return nil
+}
+
+// SetWriteDeadline sets an absolute deadline for all following writes. While
+// it is set it takes precedence over WriteDeadline, which Transmit no longer
+// applies. A zero t clears it and restores the per-call WriteDeadline.
+func (u *UDPClient) SetWriteDeadline(t time.Time) error {
+	if u == nil || u.conn == nil {
+		return fmt.Errorf("failed to SetWriteDeadline due to uninitialized client")
+	}
+	err := u.applyWriteDeadline(t)
+	if err != nil {
+		return fmt.Errorf("failed in setting write deadline in SetWriteDeadline - %w", err)
+	}
+	if t.IsZero() {
+		u.explicitWrite.Store(0)
+	} else {
+		u.explicitWrite.Store(t.UnixNano())
+	}
+	return nil
+}
+
+// nextReadDeadline returns the deadline for a read starting now, which is the
+// one set by SetReadDeadline if any, otherwise ReadDeadline from now. Zero
+// means no deadline.
+func (u *UDPClient) nextReadDeadline() time.Time {
+	if ns := u.explicitRead.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	if u.ReadDeadline == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(u.ReadDeadline)
+}
+
+// nextWriteDeadline returns the deadline for a write starting now, which is
+// the one set by SetWriteDeadline if any, otherwise WriteDeadline from now.
+// Zero means no deadline.
+func (u *UDPClient) nextWriteDeadline() time.Time {
+	if ns := u.explicitWrite.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	if u.WriteDeadline == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(u.WriteDeadline)
+}
+
+// boundedWriteDeadline returns the write deadline of the next datagram, the
+// earlier of nextWriteDeadline and bound unless bound is zero.
+func (u *UDPClient) boundedWriteDeadline(bound time.Time) time.Time {
+	d := u.nextWriteDeadline()
+	if !bound.IsZero() && (d.IsZero() || bound.Before(d)) {
+		return bound
+	}
+	return d
+}
+
+// applyReadDeadline applies t as the read deadline of the socket and keeps
+// track of it for EffectiveReadDeadline. A zero t clears the deadline.
+func (u *UDPClient) applyReadDeadline(t time.Time) error {
+	err := u.conn.SetReadDeadline(t)
+	if err != nil {
+		return err
+	}
+	if t.IsZero() {
+		u.readDeadline.Store(0)
+	} else {
+		u.readDeadline.Store(t.UnixNano())
+	}
+	return nil
+}
+
+// EffectiveReadDeadline returns the read deadline currently applied to the
+// socket and whether any is set. The `net` package offers no getter, so the
+// value is the one last applied by this client. It is safe to call
+// concurrently with the other methods.
+func (u *UDPClient) EffectiveReadDeadline() (time.Time, bool) {
+	if u == nil {
+		return time.Time{}, false
+	}
+	ns := u.readDeadline.Load()
+	if ns == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+// applyWriteDeadline applies t as the write deadline of the socket and keeps
+// track of it for EffectiveWriteDeadline. A zero t clears the deadline.
+func (u *UDPClient) applyWriteDeadline(t time.Time) error {
+	err := u.conn.SetWriteDeadline(t)
+	if err != nil {
+		return err
+	}
+	if t.IsZero() {
+		u.writeDeadline.Store(0)
+	} else {
+		u.writeDeadline.Store(t.UnixNano())
+	}
+	return nil
+}
+
+// EffectiveWriteDeadline is EffectiveReadDeadline for the write deadline.
+func (u *UDPClient) EffectiveWriteDeadline() (time.Time, bool) {
+	if u == nil {
+		return time.Time{}, false
+	}
+	ns := u.writeDeadline.Load()
+	if ns == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
 }
 
 // LocalAddr returns the current local UDP address if the client
-// is active. Nil other wise.
+// is active, with the port chosen by the system after an ephemeral bind.
+// Nil other wise.
 func (u *UDPClient) LocalAddr() net.Addr {
 	if u != nil && u.conn != nil {
 		return u.conn.LocalAddr()
@@ -76,75 +628,495 @@ func (u *UDPClient) LocalAddr() net.Addr {
 	return nil
 }
 
+// checkFamily verifies that the destination address can be reached from the
+// address family of the local socket. A socket bound to an IPv4 address only
+// accepts IPv4 destinations, one bound to a specific IPv6 address only accepts
+// IPv6 destinations, while an unspecified IPv6 bind is dual-stack. A unixgram
+// socket only accepts Unix destinations.
+func (u *UDPClient) checkFamily(dst net.Addr) error {
+	addr, isUDP := dst.(*net.UDPAddr)
+	if unix := u.network == "unixgram"; unix == isUDP {
+		return fmt.Errorf("%w - %s socket cannot reach %v", ErrAddressFamilyMismatch, u.conn.LocalAddr().Network(), dst)
+	}
+
+	local, ok := u.conn.LocalAddr().(*net.UDPAddr)
+	if !isUDP || !ok || local.IP == nil || addr.IP == nil {
+		return nil
+	}
+
+	localV4 := local.IP.To4() != nil
+	remoteV4 := addr.IP.To4() != nil
+	switch {
+	case localV4 && !remoteV4:
+		return fmt.Errorf("%w - IPv4 socket cannot reach %v", ErrAddressFamilyMismatch, addr)
+	case !localV4 && (!local.IP.IsUnspecified() || u.network == "udp6") && remoteV4:
+		return fmt.Errorf("%w - IPv6 socket cannot reach %v", ErrAddressFamilyMismatch, addr)
+	}
+	return nil
+}
+
+// pace blocks until PacingGap has passed since the previous paced
+// transmission. Concurrent callers are released one at a time.
+func (u *UDPClient) pace() {
+	u.pacingMu.Lock()
+	defer u.pacingMu.Unlock()
+
+	if wait := time.Until(u.nextTransmit); wait > 0 {
+		time.Sleep(wait)
+	}
+	u.nextTransmit = time.Now().Add(u.PacingGap)
+}
+
 // Transmit helps to send a block of data to a intended receiver at the specified
 // address. This uses the pre-initialized instance of local UDP client.
+// It is safe to call Transmit from multiple goroutines. A datagram exceeding
+// the path MTU while the don't fragment bit is set fails with
+// ErrPacketTooLarge.
 func (u *UDPClient) Transmit(addr *net.UDPAddr, data []byte) (
 	n int,
 	err error,
+) {
+	if addr == nil {
+		return u.transmit(nil, data)
+	}
+	return u.transmit(addr, data)
+}
+
+// transmit is Transmit to an address of any network, the *net.UnixAddr of
+// the peers of a unixgram client included.
+func (u *UDPClient) transmit(addr net.Addr, data []byte) (
+	n int,
+	err error,
 ) {
 	if u == nil || u.conn == nil {
 		err = fmt.Errorf("failed to Transmit due to uninitialized client")
 		return
 	}
+	defer func() { u.recordError("Transmit", err) }()
 
-	if addr == nil || len(data) == 0 {
+	if addr == nil || (len(data) == 0 && !u.AllowEmptyDatagrams) {
 		err = fmt.Errorf("parameter error in Transmit")
 		return
 	}
 
-	timeout := time.Now().Add(u.WriteDeadline)
-	err = u.conn.SetWriteDeadline(timeout)
+	if u.quiesced.Load() {
+		err = fmt.Errorf("failed to Transmit - %w", ErrQuiesced)
+		return
+	}
+
+	return u.writeDatagram("Transmit", addr, data, time.Time{}, func(wire []byte) (int, error) {
+		return u.conn.WriteTo(wire, addr)
+	})
+}
+
+// writeDatagram is the write path shared by Transmit and its variants,
+// named op in the errors. It waits for the rate limit, failing when the
+// wait would not end before bound unless bound is zero, and for PacingGap.
+// Then it sets the write deadline, the earlier of the one of the client and
+// bound, and passes data framed for the wire to write, retrying on EINTR and
+// under backpressure. The datagram sent is accounted for and reported to
+// TransmitHook. The caller checks the parameters and quiescing.
+func (u *UDPClient) writeDatagram(op string, addr net.Addr, data []byte, bound time.Time,
+	write func(wire []byte) (int, error)) (
+	n int,
+	err error,
+) {
+	if err = u.throttleUntil(bound); err != nil {
+		err = fmt.Errorf("failed to %s - %w", op, err)
+		return
+	}
+
+	err = u.checkFamily(addr)
 	if err != nil {
-		err = fmt.Errorf("failed in setting write deadline in Transmit - %w", err)
+		err = fmt.Errorf("failed to validate address in %s - %w", op, err)
+		return
+	}
+
+	if u.PacingGap > 0 {
+		u.pace()
+	}
+
+	if !bound.IsZero() && !time.Now().Before(bound) {
+		err = fmt.Errorf("failed to write data in %s - %w", op, os.ErrDeadlineExceeded)
 		return
 	}
 
-	u.RemoteAddr = addr
-	n, err = u.conn.WriteTo(data, addr)
+	deadline := u.boundedWriteDeadline(bound)
+	err = u.applyWriteDeadline(deadline)
 	if err != nil {
-		err = fmt.Errorf("failed to write data in Transmit - %w", err)
+		u.logf("udp: failed to reset write deadline in %s - %v", op, err)
+		err = fmt.Errorf("failed in setting write deadline in %s - %w", op, err)
+		return
+	}
+
+	wire := u.encode(data)
+	n, err = u.writeWithBackpressure(bound, deadline, func() (int, error) {
+		return retryEINTR(func() (int, error) {
+			return write(wire)
+		})
+	})
+	if err != nil {
+		n = 0
+		err = fmt.Errorf("failed to write data in %s - %w", op, packetTooLarge(err))
+		return
+	}
+	u.stats.sent(n)
+	u.logTraffic("transmitted", addr, n)
+	n = len(data)
+
+	if u.TransmitHook != nil {
+		u.TransmitHook(n, addr)
 	}
 
 	return
 }
 
 // Receive helps to read data from a remote UDP Server. It also uses the
-// open local client for reception
+// open local client for reception. The sender is stored in RemoteAddr, use
+// ReceiveFrom to receive from multiple goroutines.
 func (u *UDPClient) Receive(rb []byte) (
 	n int,
 	err error,
+) {
+	if u == nil {
+		err = fmt.Errorf("failed to Receive due to uninitialized client")
+		return
+	}
+	if u.conn == nil {
+		u.RemoteAddr = nil
+		err = fmt.Errorf("failed to Receive due to uninitialized client")
+		return
+	}
+
+	var from net.Addr
+	n, from, err = u.receiveFrom(rb, u.nextReadDeadline())
+	u.RemoteAddr = from
+	if from == nil {
+		return
+	}
+
+	if err == nil {
+		old := u.lastSender
+		u.lastSender = from
+		if u.RemoteChangeHook != nil && old != nil && old.String() != from.String() {
+			u.RemoteChangeHook(old, from)
+		}
+	}
+
+	return
+}
+
+// ReceiveFrom reads data like Receive but returns the sender instead of
+// storing it in RemoteAddr, so it can be used from multiple goroutines.
+func (u *UDPClient) ReceiveFrom(rb []byte) (
+	n int,
+	addr *net.UDPAddr,
+	err error,
 ) {
 	if u == nil || u.conn == nil {
 		err = fmt.Errorf("failed to Receive due to uninitialized client")
 		return
 	}
+	n, from, err := u.receiveFrom(rb, u.nextReadDeadline())
+	return n, udpAddr(from), err
+}
+
+// ReceiveContext reads data like ReceiveFrom but waits until a datagram
+// arrives or the context is done instead of applying ReadDeadline. The
+// deadline of the context, if any, bounds the read along with the one set by
+// SetReadDeadline. When the context ends first the returned error wraps
+// ctx.Err(). Errors ReceiveRetry reports as transient are retried.
+func (u *UDPClient) ReceiveContext(ctx context.Context, rb []byte) (
+	n int,
+	addr *net.UDPAddr,
+	err error,
+) {
+	if u == nil || u.conn == nil {
+		err = fmt.Errorf("failed to ReceiveContext due to uninitialized client")
+		return
+	}
+	n, from, err := u.receiveUntil(ctx, rb)
+	return n, udpAddr(from), err
+}
+
+// receiveUntil is ReceiveContext returning the sender of any network.
+func (u *UDPClient) receiveUntil(ctx context.Context, rb []byte) (
+	n int,
+	from net.Addr,
+	err error,
+) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("failed to read data in ReceiveContext - %w", err)
+		return
+	}
+
+	deadline, _ := ctx.Deadline()
+	if ns := u.explicitRead.Load(); ns != 0 {
+		if t := time.Unix(0, ns); deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	for {
+		n, from, err = u.receiveContext(ctx, rb, deadline)
+		if err == nil || u.ReceiveRetry == nil || ctx.Err() != nil ||
+			errors.Is(err, os.ErrDeadlineExceeded) || !u.ReceiveRetry(err) {
+			break
+		}
+	}
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		if ctxDeadline, ok := ctx.Deadline(); ok && !deadline.Before(ctxDeadline) {
+			// The socket deadline may fire just before the context one
+			<-ctx.Done()
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("failed to read data in ReceiveContext - %w", ctx.Err())
+		}
+	}
+	return
+}
+
+// receiveContext reads a single datagram into rb with the read deadline set
+// to deadline, until ctx is done.
+func (u *UDPClient) receiveContext(ctx context.Context, rb []byte, deadline time.Time) (
+	n int,
+	from net.Addr,
+	err error,
+) {
+	// Unblock the pending read once the context is done. The callback is
+	// registered after the deadline is applied so that it cannot be
+	// overwritten, and one that already started is waited for so it cannot
+	// expire a later read.
+	conn := u.conn
+	unblocked := make(chan struct{})
+	var stop func() bool
+	defer func() {
+		if stop != nil && !stop() {
+			<-unblocked
+		}
+	}()
+
+	return u.receiveArmed(rb, deadline, func() {
+		stop = context.AfterFunc(ctx, func() {
+			defer close(unblocked)
+			t := time.Now()
+			if conn.SetReadDeadline(t) == nil {
+				u.readDeadline.Store(t.UnixNano())
+			}
+		})
+	})
+}
+
+// receiveFrom reads a single datagram into rb with the read deadline set to
+// deadline, a zero deadline blocks until a datagram arrives.
+func (u *UDPClient) receiveFrom(rb []byte, deadline time.Time) (
+	n int,
+	from net.Addr,
+	err error,
+) {
+	return u.receiveArmed(rb, deadline, nil)
+}
+
+// receiveArmed is receiveFrom calling armed, when not nil, once the read
+// deadline is applied and before reading.
+func (u *UDPClient) receiveArmed(rb []byte, deadline time.Time, armed func()) (
+	n int,
+	from net.Addr,
+	err error,
+) {
+	defer func() { u.recordError("Receive", err) }()
 
 	if len(rb) == 0 {
 		err = fmt.Errorf("parameter error in Receive")
 		return
 	}
 
-	timeout := time.Now().Add(u.ReadDeadline)
-	err = u.conn.SetReadDeadline(timeout)
+	err = u.applyReadDeadline(deadline)
 	if err != nil {
+		u.logf("udp: failed to reset read deadline in Receive - %v", err)
 		err = fmt.Errorf("failed in setting read deadline in Receive - %w", err)
 		return
 	}
+	if armed != nil {
+		armed()
+	}
 
-	n, addr, err := u.conn.ReadFrom(rb)
+	var flags int
+	n, flags, from, err = u.read(rb)
 	if err != nil {
 		err = fmt.Errorf("failed to read data in Receive - %w", err)
+		return
 	}
-	u.RemoteAddr = addr
 
+	n, err = u.process(rb, n, flags, from)
 	return
 }
 
-// NewUDPClient creates a local UDP client with a supplied listen port
-func NewUDPClient(laddr *net.UDPAddr) (p *UDPClient, err error) {
-
-	// Get the Default values
-	p, err = p.Default(laddr)
+// read reads a datagram into rb, returning its size, the message flags
+// where available and the sender, a *net.UDPAddr or the *net.UnixAddr of a
+// named unixgram peer. Datagrams of senders rejected by the source filter
+// are skipped.
+func (u *UDPClient) read(rb []byte) (
+	n int,
+	flags int,
+	from net.Addr,
+	err error,
+) {
+	for {
+		n, err = retryEINTR(func() (n int, err error) {
+			from = nil
+			if c, ok := u.conn.(*unixgramConn); ok {
+				n, flags, from, err = c.readMsg(rb)
+				return
+			}
 
+			var addr *net.UDPAddr
+			switch {
+			case u.DropCounter:
+				n, flags, addr, err = u.receiveCountingDrops(rb)
+			case msgTrunc != 0:
+				n, _, flags, addr, err = u.conn.ReadMsgUDP(rb, nil)
+			default:
+				n, addr, err = u.conn.ReadFromUDP(rb)
+			}
+			if addr != nil {
+				from = addr
+			}
+			return
+		})
+		if err != nil || u.accepts(from) {
+			break
+		}
+	}
+	if err != nil {
+		// ReadMsgUDP reports a zero address and may report n < 0 on errors
+		n, from = 0, nil
+	}
 	return
 }
+
+// udpAddr returns the sender from as a *net.UDPAddr, nil for the peers of a
+// unixgram client.
+func udpAddr(from net.Addr) *net.UDPAddr {
+	addr, _ := from.(*net.UDPAddr)
+	return addr
+}
+
+// process accounts for a datagram of n bytes read into rb from addr and
+// strips its framing, returning the length of the payload. Truncated
+// datagrams, those failing to decode and unwanted empty ones are reported
+// as errors.
+func (u *UDPClient) process(rb []byte, n, flags int, addr net.Addr) (int, error) {
+	u.stats.received(n)
+	u.logTraffic("received", addr, n)
+
+	if u.JitterEstimate {
+		u.jitter.observe(time.Now())
+	}
+
+	// Without MSG_TRUNC a full buffer is the only hint of truncation
+	if flags&msgTrunc != 0 || (msgTrunc == 0 && n == len(rb)) {
+		u.logf("udp: truncated datagram from %v to %d bytes", addr, n)
+		return n, fmt.Errorf("failed to read data in Receive - %w", ErrTruncated)
+	}
+
+	n, err := u.decode(rb, n)
+	if err != nil {
+		return n, fmt.Errorf("failed to read data in Receive - %w", err)
+	}
+
+	if n == 0 && !u.AllowEmptyDatagrams {
+		return n, fmt.Errorf("failed to read data in Receive - %w", ErrEmptyDatagram)
+	}
+
+	if u.OnReceive != nil {
+		u.OnReceive(udpAddr(addr), rb[:n])
+	}
+	return n, nil
+}
+
+// SetMaxPacketSize sets MaxPacketSize to n limited to MaxDatagramSize, zero
+// or less selects the default.
+func (u *UDPClient) SetMaxPacketSize(n int) {
+	u.MaxPacketSize = min(max(n, 0), MaxDatagramSize)
+}
+
+// maxPacketSize returns the effective MaxPacketSize.
+func (u *UDPClient) maxPacketSize() int {
+	if u.MaxPacketSize <= 0 || u.MaxPacketSize > MaxDatagramSize {
+		return MaxDatagramSize
+	}
+	return u.MaxPacketSize
+}
+
+// IsTimeout reports whether err, or any error it wraps, is a net.Error that
+// timed out. Every error returned by Receive and Transmit on an expired
+// deadline satisfies it.
+func IsTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// retryEINTR repeats op while it is interrupted by a signal (EINTR). The
+// deadline applied to the socket still bounds every attempt.
+func retryEINTR(op func() (int, error)) (int, error) {
+	for {
+		n, err := op()
+		if !errors.Is(err, syscall.EINTR) {
+			return n, err
+		}
+	}
+}
+
+// receiveCountingDrops reads a datagram along with its control messages to
+// keep track of the kernel drop count. It also returns the message flags.
+func (u *UDPClient) receiveCountingDrops(rb []byte) (int, int, *net.UDPAddr, error) {
+	u.dropsOnce.Do(func() {
+		u.dropsErr = enableDropCounter(u.conn)
+	})
+	if u.dropsErr != nil {
+		return 0, 0, nil, u.dropsErr
+	}
+
+	oob := make([]byte, dropCounterOOBSize)
+	n, oobn, flags, addr, err := u.conn.ReadMsgUDP(rb, oob)
+	if err != nil {
+		return n, 0, nil, err
+	}
+	if drops, ok := parseDropCount(oob[:oobn]); ok {
+		u.kernelDrops.Store(drops)
+	}
+	return n, flags, addr, nil
+}
+
+// NewUDPClient creates a local UDP client with a supplied listen port. A
+// nil laddr listens on LocalUDPport, while a zero Port lets the system pick
+// an ephemeral port, reported by LocalAddr.
+func NewUDPClient(laddr *net.UDPAddr) (*UDPClient, error) {
+	return NewUDPClientWithOptions(WithLocalAddr(laddr))
+}
+
+// FromConn wraps an existing UDP connection, for example one handed over by
+// a listener or a test, into a UDPClient configured by opts like
+// NewUDPClientWithOptions. No new socket is opened, so the options about
+// binding such as WithLocalAddr and WithReusePort are ignored. The client
+// takes ownership of conn and closes it on Close, conn is left open when
+// FromConn fails.
+func FromConn(conn *net.UDPConn, opts ...Option) (*UDPClient, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("parameter error in FromConn")
+	}
+
+	c, err := newConfig("FromConn", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &UDPClient{conn: conn, features: probeFeatures(conn)}
+	u.dialed, _ = conn.RemoteAddr().(*net.UDPAddr)
+	c.apply(u)
+	if err = u.setBufferSizes(u.readBuffer, u.writeBuffer); err != nil {
+		return nil, fmt.Errorf("failed to set buffer size in FromConn - %w", err)
+	}
+	return u, nil
+}
@@ -6,9 +6,14 @@ package udp
 
 import (
 	"context"
+	"errors"
 	"net"
+	"os"
+	"runtime"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 const (
@@ -40,6 +45,60 @@ func TestUDPClient_Create(t *testing.T) {
 	u.Close()
 }
 
+func TestUDPClient_EphemeralPort(t *testing.T) {
+	a, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer a.Close()
+	port := a.LocalAddr().(*net.UDPAddr).Port
+	if port == 0 || port == LocalUDPport {
+		t.Errorf("expected an ephemeral port got %d", port)
+	}
+
+	// Instances do not collide as they would on LocalUDPport
+	b, err := NewUDPClientWithOptions(WithLocalAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}))
+	if err != nil {
+		t.Fatal("failed to create second udp client -", err)
+	}
+	defer b.Close()
+	if other := b.LocalAddr().(*net.UDPAddr).Port; other == 0 || other == port {
+		t.Errorf("expected a distinct ephemeral port got %d", other)
+	}
+
+	if _, err = b.Transmit(a.LocalAddr().(*net.UDPAddr), []byte("hello")); err != nil {
+		t.Fatal("failed to transmit to ephemeral port -", err)
+	}
+	if _, err = a.Receive(make([]byte, maxBufferSize)); err != nil {
+		t.Error("failed to receive on ephemeral port -", err)
+	}
+}
+
+func TestUDPClient_Close(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	conn := u.conn
+	for i := 0; i < 3; i++ {
+		if err = u.Close(); err != nil {
+			t.Errorf("expected Close %d to succeed got %v", i+1, err)
+		}
+	}
+	// Only the first Close reached the socket
+	if err = conn.Close(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected the socket to be closed got %v", err)
+	}
+
+	if err = (&UDPClient{}).Close(); !errors.Is(err, ErrAlreadyClosed) {
+		t.Errorf("expected ErrAlreadyClosed for uninitialized client got %v", err)
+	}
+	var nilClient *UDPClient
+	if err = nilClient.Close(); !errors.Is(err, ErrAlreadyClosed) {
+		t.Errorf("expected ErrAlreadyClosed for nil client got %v", err)
+	}
+}
+
 func TestUDPClient_TxRx(t *testing.T) {
 	message := "Speak last, Show respect, power and wisdom shall follow"
 
@@ -216,3 +275,992 @@ func TestUDPClient_Errors(t *testing.T) {
 		}
 	})
 }
+
+func TestUDPClient_AddressFamily(t *testing.T) {
+	t.Run("IPv4 destination from IPv6 socket", func(t *testing.T) {
+		u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv6loopback})
+		if err != nil {
+			t.Skip("IPv6 loopback unavailable -", err)
+		}
+		defer u.Close()
+
+		_, err = u.Transmit(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}, []byte("testing"))
+		if !errors.Is(err, ErrAddressFamilyMismatch) {
+			t.Errorf("expected ErrAddressFamilyMismatch got %v", err)
+		}
+	})
+
+	t.Run("IPv6 destination from IPv4 socket", func(t *testing.T) {
+		u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Error("failed to create udp client -", err)
+			return
+		}
+		defer u.Close()
+
+		_, err = u.Transmit(&net.UDPAddr{IP: net.IPv6loopback, Port: testingPort}, []byte("testing"))
+		if !errors.Is(err, ErrAddressFamilyMismatch) {
+			t.Errorf("expected ErrAddressFamilyMismatch got %v", err)
+		}
+	})
+
+	t.Run("Matching family on dual-stack socket", func(t *testing.T) {
+		u, err := NewUDPClient(&net.UDPAddr{})
+		if err != nil {
+			t.Error("failed to create udp client -", err)
+			return
+		}
+		defer u.Close()
+
+		_, err = u.Transmit(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: testingPort}, []byte("testing"))
+		if errors.Is(err, ErrAddressFamilyMismatch) {
+			t.Error("unexpected ErrAddressFamilyMismatch on dual-stack socket")
+		}
+	})
+}
+
+func TestUDPClient_Quiesce(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	u.Quiesce()
+	_, err = u.Transmit(peer.LocalAddr().(*net.UDPAddr), []byte("testing"))
+	if !errors.Is(err, ErrQuiesced) {
+		t.Errorf("expected ErrQuiesced got %v", err)
+	}
+
+	_, err = peer.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("testing"))
+	if err != nil {
+		t.Fatal("failed to write peer -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	if _, err = u.Receive(buf); err != nil {
+		t.Error("expected Receive to work while quiesced got", err)
+	}
+
+	u.Unquiesce()
+	_, err = u.Transmit(peer.LocalAddr().(*net.UDPAddr), []byte("testing"))
+	if err != nil {
+		t.Error("expected Transmit to work after Unquiesce got", err)
+	}
+}
+
+func TestUDPClient_EffectiveReadDeadline(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	if d, ok := u.EffectiveReadDeadline(); ok {
+		t.Errorf("expected no deadline on a fresh client got %v", d)
+	}
+
+	before := time.Now()
+	_, err = u.Receive(make([]byte, maxBufferSize))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatal("expected timeout got", err)
+	}
+	d, ok := u.EffectiveReadDeadline()
+	if !ok {
+		t.Fatal("expected a deadline after Receive")
+	}
+	if d.Before(before.Add(u.ReadDeadline)) || d.After(time.Now().Add(u.ReadDeadline)) {
+		t.Errorf("expected deadline near %v got %v", before.Add(u.ReadDeadline), d)
+	}
+
+	if err = u.applyReadDeadline(time.Time{}); err != nil {
+		t.Fatal("failed to clear deadline -", err)
+	}
+	if d, ok := u.EffectiveReadDeadline(); ok {
+		t.Errorf("expected no deadline after clearing got %v", d)
+	}
+}
+
+func TestUDPClient_TransmitHook(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	var calls []int
+	var addrs []net.Addr
+	u.TransmitHook = func(n int, addr net.Addr) {
+		calls = append(calls, n)
+		addrs = append(addrs, addr)
+	}
+
+	dst := u.LocalAddr().(*net.UDPAddr)
+	message := []byte("Patience is bitter, but its fruit is sweet")
+	for i := 0; i < 2; i++ {
+		if _, err = u.Transmit(dst, message); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+
+	// Failed transmissions must not fire the hook
+	_, _ = u.Transmit(nil, message)
+	u.Quiesce()
+	_, _ = u.Transmit(dst, message)
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 hook calls got %d", len(calls))
+	}
+	for i := range calls {
+		if calls[i] != len(message) || addrs[i] != dst {
+			t.Errorf("call %d expected (%d, %v) got (%d, %v)", i, len(message), dst, calls[i], addrs[i])
+		}
+	}
+}
+
+func TestUDPClient_BindErrors(t *testing.T) {
+	t.Run("Privileged port", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("privileged ports can be bound by root")
+		}
+		_, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53})
+		if !errors.Is(err, ErrPermission) {
+			t.Errorf("expected ErrPermission got %v", err)
+		}
+		if errors.Is(err, ErrAddrInUse) {
+			t.Error("unexpected ErrAddrInUse for a privileged port")
+		}
+	})
+
+	t.Run("Port in use", func(t *testing.T) {
+		u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create udp client -", err)
+		}
+		defer u.Close()
+
+		_, err = NewUDPClient(u.LocalAddr().(*net.UDPAddr))
+		if !errors.Is(err, ErrAddrInUse) {
+			t.Errorf("expected ErrAddrInUse got %v", err)
+		}
+		if errors.Is(err, ErrPermission) {
+			t.Error("unexpected ErrPermission for a port in use")
+		}
+	})
+}
+
+func TestFromConn(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to listen -", err)
+	}
+	defer conn.Close()
+	u, err := FromConn(conn, WithWriteDeadline(2*time.Second), WithChecksum(true))
+	if err != nil {
+		t.Fatal("failed to adopt conn -", err)
+	}
+
+	if u.ReadDeadline != ReadDeadline || u.WriteDeadline != 2*time.Second {
+		t.Errorf("expected the deadlines of the options got %v and %v", u.ReadDeadline, u.WriteDeadline)
+	}
+	if !u.checksum {
+		t.Error("expected the checksum option applied")
+	}
+	if u.LocalAddr() != conn.LocalAddr() {
+		t.Errorf("expected local address %v got %v", conn.LocalAddr(), u.LocalAddr())
+	}
+
+	message := "Still waters run deep"
+	if _, err = u.Transmit(conn.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, err := u.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+
+	u.Close()
+	if _, err = conn.WriteTo([]byte(message), conn.LocalAddr()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected Close to close the adopted conn got %v", err)
+	}
+
+	if _, err = FromConn(nil); err == nil {
+		t.Error("expected Error(nil conn) got nil")
+	}
+	if _, err = FromConn(conn, WithPacing(0)); err == nil {
+		t.Error("expected Error(invalid option) got nil")
+	}
+}
+
+func TestUDPClient_EmptyDatagrams(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+	buf := make([]byte, maxBufferSize)
+
+	t.Run("Allowed", func(t *testing.T) {
+		u.AllowEmptyDatagrams = true
+		u.RemoteAddr = nil
+		n, err := u.Transmit(dst, nil)
+		if err != nil || n != 0 {
+			t.Fatalf("expected an empty transmit got (%d, %v)", n, err)
+		}
+		n, err = u.Receive(buf)
+		if err != nil || n != 0 {
+			t.Fatalf("expected an empty datagram got (%d, %v)", n, err)
+		}
+		if u.RemoteAddr == nil || u.RemoteAddr.String() != dst.String() {
+			t.Errorf("expected sender %v got %v", dst, u.RemoteAddr)
+		}
+	})
+
+	t.Run("Not allowed", func(t *testing.T) {
+		u.AllowEmptyDatagrams = true
+		if _, err := u.Transmit(dst, []byte{}); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+
+		u.AllowEmptyDatagrams = false
+		if _, err := u.Transmit(dst, []byte{}); err == nil {
+			t.Error("expected Error(empty data) got nil")
+		}
+		if _, err := u.Receive(buf); !errors.Is(err, ErrEmptyDatagram) {
+			t.Errorf("expected ErrEmptyDatagram got %v", err)
+		}
+	})
+}
+
+func TestRetryEINTR(t *testing.T) {
+	calls := 0
+	n, err := retryEINTR(func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, &net.OpError{Op: "read", Net: "udp", Err: os.NewSyscallError("recvfrom", syscall.EINTR)}
+		}
+		return 5, nil
+	})
+	if err != nil || n != 5 || calls != 3 {
+		t.Errorf("expected (5, nil) after 3 calls got (%d, %v) after %d", n, err, calls)
+	}
+
+	calls = 0
+	_, err = retryEINTR(func() (int, error) {
+		calls++
+		return 0, syscall.ECONNREFUSED
+	})
+	if !errors.Is(err, syscall.ECONNREFUSED) || calls != 1 {
+		t.Errorf("expected other errors to pass through got %v after %d calls", err, calls)
+	}
+}
+
+func TestUDPClient_RemoteChangeHook(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	u, err := NewUDPClient(loopback)
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	var peers [2]*UDPClient
+	for i := range peers {
+		peers[i], err = NewUDPClient(loopback)
+		if err != nil {
+			t.Fatal("failed to create peer -", err)
+		}
+		defer peers[i].Close()
+	}
+
+	var changes [][2]net.Addr
+	u.RemoteChangeHook = func(old, new net.Addr) {
+		changes = append(changes, [2]net.Addr{old, new})
+	}
+
+	buf := make([]byte, maxBufferSize)
+	for _, i := range []int{0, 0, 1} {
+		if _, err = peers[i].Transmit(u.LocalAddr().(*net.UDPAddr), []byte("testing")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err = u.Receive(buf); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+	}
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change got %d", len(changes))
+	}
+	if changes[0][0].String() != peers[0].LocalAddr().String() ||
+		changes[0][1].String() != peers[1].LocalAddr().String() {
+		t.Errorf("expected change %v -> %v got %v -> %v",
+			peers[0].LocalAddr(), peers[1].LocalAddr(), changes[0][0], changes[0][1])
+	}
+}
+
+func TestUDPClient_OnReceive(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	type packet struct {
+		addr *net.UDPAddr
+		data string
+	}
+	var received []packet
+	u.OnReceive = func(addr *net.UDPAddr, data []byte) {
+		received = append(received, packet{addr, string(data)})
+	}
+
+	message := []byte("Well begun is half done")
+	if _, err = u.Transmit(dst, message); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+
+	// Failed receptions must not fire the hook
+	_, _ = u.Receive(make([]byte, maxBufferSize))
+
+	if len(received) != 1 || received[0].addr.String() != dst.String() || received[0].data != string(message) {
+		t.Errorf("expected one reception of %q from %v got %v", message, dst, received)
+	}
+}
+
+func TestUDPClient_PacingGap(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.PacingGap = 20 * time.Millisecond
+
+	var sent []time.Time
+	u.TransmitHook = func(n int, addr net.Addr) {
+		sent = append(sent, time.Now())
+	}
+
+	const burst = 5
+	dst := u.LocalAddr().(*net.UDPAddr)
+	start := time.Now()
+	for i := 0; i < burst; i++ {
+		if _, err = u.Transmit(dst, []byte("testing")); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < (burst-1)*u.PacingGap {
+		t.Errorf("expected the burst to take at least %v took %v", (burst-1)*u.PacingGap, elapsed)
+	}
+
+	// The hook runs after the write, allow for the write time to vary
+	const tolerance = time.Millisecond
+	for i := 1; i < len(sent); i++ {
+		if gap := sent[i].Sub(sent[i-1]); gap < u.PacingGap-tolerance {
+			t.Errorf("datagram %d sent %v after the previous one, expected at least %v", i, gap, u.PacingGap)
+		}
+	}
+}
+
+// transmitPath sends count datagrams to dst through one of the transmit
+// calls of u, returning the number sent.
+type transmitPath struct {
+	name      string
+	linuxOnly bool
+	send      func(u *UDPClient, dst *net.UDPAddr, count int) (int, error)
+}
+
+// transmitPaths lists the transmit calls other than Transmit.
+var transmitPaths = []transmitPath{
+	{"TransmitMultiConcurrent", false, func(u *UDPClient, dst *net.UDPAddr, count int) (int, error) {
+		addrs := make([]*net.UDPAddr, count)
+		for i := range addrs {
+			addrs[i] = dst
+		}
+		results, err := u.TransmitMultiConcurrent(addrs, []byte("testing"), 1, time.Second)
+		if err != nil {
+			return 0, err
+		}
+		for i, r := range results {
+			if r.Err != nil {
+				return i, r.Err
+			}
+		}
+		return count, nil
+	}},
+	{"TransmitBatch", false, func(u *UDPClient, dst *net.UDPAddr, count int) (int, error) {
+		packets := make([][]byte, count)
+		for i := range packets {
+			packets[i] = []byte("testing")
+		}
+		return u.TransmitBatch(dst, packets)
+	}},
+	{"TransmitWithTTL", true, func(u *UDPClient, dst *net.UDPAddr, count int) (int, error) {
+		for i := 0; i < count; i++ {
+			if _, err := u.TransmitWithTTL(dst, []byte("testing"), 8); err != nil {
+				return i, err
+			}
+		}
+		return count, nil
+	}},
+	{"TransmitFrom", true, func(u *UDPClient, dst *net.UDPAddr, count int) (int, error) {
+		src := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+		for i := 0; i < count; i++ {
+			if _, err := u.TransmitFrom(src, dst, []byte("testing")); err != nil {
+				return i, err
+			}
+		}
+		return count, nil
+	}},
+}
+
+func TestUDPClient_PacingGapAllPaths(t *testing.T) {
+	for _, path := range transmitPaths {
+		t.Run(path.name, func(t *testing.T) {
+			if path.linuxOnly && runtime.GOOS != "linux" {
+				t.Skip("only supported on linux")
+			}
+			u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			if err != nil {
+				t.Fatal("failed to create udp client -", err)
+			}
+			defer u.Close()
+			u.PacingGap = 20 * time.Millisecond
+
+			const burst = 3
+			start := time.Now()
+			if _, err = path.send(u, u.LocalAddr().(*net.UDPAddr), burst); err != nil {
+				t.Fatal("failed to transmit -", err)
+			}
+			if elapsed := time.Since(start); elapsed < (burst-1)*u.PacingGap {
+				t.Errorf("expected the burst to take at least %v took %v", (burst-1)*u.PacingGap, elapsed)
+			}
+		})
+	}
+}
+
+func TestUDPClient_Bind(t *testing.T) {
+	taken, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer taken.Close()
+
+	u, err := NewUnbound(WithReadDeadline(time.Second), WithBufferSize(64<<10))
+	if err != nil {
+		t.Fatal("failed to create unbound client -", err)
+	}
+	if u.LocalAddr() != nil {
+		t.Error("expected an unbound client to have no local address")
+	}
+
+	err = u.Bind(taken.LocalAddr().(*net.UDPAddr))
+	if !errors.Is(err, ErrAddrInUse) {
+		t.Fatalf("expected ErrAddrInUse got %v", err)
+	}
+
+	if err = u.Bind(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}); err != nil {
+		t.Fatal("failed to bind a free port -", err)
+	}
+	defer u.Close()
+	if u.ReadDeadline != time.Second {
+		t.Errorf("expected configuration to survive rebinding got %v", u.ReadDeadline)
+	}
+	if opts, err := u.SocketOptions(); err == nil && opts.ReadBuffer < 64<<10 {
+		t.Errorf("expected the buffer size applied on Bind got %d", opts.ReadBuffer)
+	}
+
+	if err = u.Bind(nil); err == nil {
+		t.Error("expected Error(already bound) got nil")
+	}
+
+	if _, err = u.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("testing")); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	if _, err = u.Receive(make([]byte, maxBufferSize)); err != nil {
+		t.Error("failed to receive -", err)
+	}
+}
+
+func TestUDPClient_ReceiveFrom(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	message := "Actions speak louder than words"
+	if _, err = peer.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+
+	buf := make([]byte, maxBufferSize)
+	n, addr, err := u.ReceiveFrom(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+	if addr.String() != peer.LocalAddr().String() {
+		t.Errorf("expected sender %v got %v", peer.LocalAddr(), addr)
+	}
+	if u.RemoteAddr != nil {
+		t.Errorf("expected RemoteAddr to be left alone got %v", u.RemoteAddr)
+	}
+
+	var nilClient *UDPClient
+	if _, _, err = nilClient.ReceiveFrom(buf); err == nil {
+		t.Error("expected Error on nil client got nil")
+	}
+	if _, _, err = u.ReceiveFrom(nil); err == nil {
+		t.Error("expected Error(empty buffer) got nil")
+	}
+}
+
+func TestUDPClient_ReceiveContext(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	buf := make([]byte, maxBufferSize)
+
+	// Cancelled while blocked well past ReadDeadline
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(4*u.ReadDeadline, cancel)
+	_, _, err = u.ReceiveContext(ctx, buf)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled got %v", err)
+	}
+
+	// Bounded by the context deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = u.ReceiveContext(ctx, buf)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded got %v", err)
+	}
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	message := "Patience is bitter, but its fruit is sweet"
+	time.AfterFunc(2*u.ReadDeadline, func() {
+		peer.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message))
+	})
+	n, addr, err := u.ReceiveContext(context.Background(), buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+	if addr.String() != peer.LocalAddr().String() {
+		t.Errorf("expected sender %v got %v", peer.LocalAddr(), addr)
+	}
+
+	var nilClient *UDPClient
+	if _, _, err = nilClient.ReceiveContext(context.Background(), buf); err == nil {
+		t.Error("expected Error on nil client got nil")
+	}
+}
+
+// cancelOnArm cancels a context while the client applies a read deadline
+// without limit, right before a ReceiveContext starts reading.
+type cancelOnArm struct {
+	packetConn
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnArm) SetReadDeadline(t time.Time) error {
+	if t.IsZero() {
+		c.cancel()
+		// Let a callback registered before the deadline run first
+		time.Sleep(10 * time.Millisecond)
+	}
+	return c.packetConn.SetReadDeadline(t)
+}
+
+func TestUDPClient_ReceiveContextCancelAtStart(t *testing.T) {
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	u.conn = &cancelOnArm{packetConn: m, cancel: cancel}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := u.ReceiveContext(ctx, make([]byte, maxBufferSize))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ReceiveContext to return after cancellation")
+	}
+}
+
+// failReads fails the first reads with err.
+type failReads struct {
+	packetConn
+	err   error
+	count int
+}
+
+func (c *failReads) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	if c.count > 0 {
+		c.count--
+		return 0, 0, 0, nil, c.err
+	}
+	return c.packetConn.ReadMsgUDP(b, oob)
+}
+
+func (c *failReads) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	if c.count > 0 {
+		c.count--
+		return 0, nil, c.err
+	}
+	return c.packetConn.ReadFromUDP(b)
+}
+
+func TestWithReceiveRetry(t *testing.T) {
+	transient := func(err error) bool { return errors.Is(err, syscall.ENOBUFS) }
+	peer := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+	rb := make([]byte, maxBufferSize)
+
+	u, m := NewMockUDPClient()
+	defer u.Close()
+	u.conn = &failReads{packetConn: m, err: syscall.ENOBUFS, count: 2}
+	m.Inject([]byte("hello"), peer)
+	if _, _, err := u.ReceiveContext(context.Background(), rb); !errors.Is(err, syscall.ENOBUFS) {
+		t.Errorf("expected ENOBUFS without retry got %v", err)
+	}
+
+	u.ReceiveRetry = transient
+	n, _, err := u.ReceiveContext(context.Background(), rb)
+	if err != nil {
+		t.Fatal("failed to receive after a transient error -", err)
+	}
+	if string(rb[:n]) != "hello" {
+		t.Errorf("expected %q got %q", "hello", rb[:n])
+	}
+
+	// Timeouts and other errors are not retried
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err = u.ReceiveContext(ctx, rb); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded got %v", err)
+	}
+	u.conn = &failReads{packetConn: m, err: syscall.ECONNREFUSED, count: 1}
+	if _, _, err = u.ReceiveContext(context.Background(), rb); !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("expected ECONNREFUSED got %v", err)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithReceiveRetry(nil)); err == nil {
+		t.Error("expected Error(nil predicate) got nil")
+	}
+}
+
+func TestUDPClient_TransmitConcurrent(t *testing.T) {
+	const senders = 50
+
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	peers := make([]*UDPClient, senders)
+	for i := range peers {
+		peers[i], err = NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal("failed to create peer -", err)
+		}
+		defer peers[i].Close()
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		wg.Add(1)
+		go func(dst *net.UDPAddr) {
+			defer wg.Done()
+			if _, err := u.Transmit(dst, []byte("hello")); err != nil {
+				t.Error("failed to transmit -", err)
+			}
+		}(p.LocalAddr().(*net.UDPAddr))
+	}
+	wg.Wait()
+
+	if u.RemoteAddr != nil {
+		t.Errorf("expected RemoteAddr untouched by Transmit got %v", u.RemoteAddr)
+	}
+
+	buf := make([]byte, maxBufferSize)
+	for _, p := range peers {
+		n, err := p.Receive(buf)
+		if err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+		if string(buf[:n]) != "hello" {
+			t.Errorf("expected %q got %q", "hello", buf[:n])
+		}
+		if p.RemoteAddr.String() != u.LocalAddr().String() {
+			t.Errorf("expected sender %v got %v", u.LocalAddr(), p.RemoteAddr)
+		}
+	}
+}
+
+func TestUDPClient_ZeroDeadline(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	u.ReadDeadline = 0
+	u.WriteDeadline = 0
+
+	peer, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create peer -", err)
+	}
+	defer peer.Close()
+
+	// Send well after the default deadline would have expired
+	delay := 4 * ReadDeadline
+	message := "Still waters run deep"
+	time.AfterFunc(delay, func() {
+		peer.Transmit(u.LocalAddr().(*net.UDPAddr), []byte(message))
+	})
+
+	start := time.Now()
+	buf := make([]byte, maxBufferSize)
+	n, err := u.Receive(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("expected Receive to block for %v got %v", delay, elapsed)
+	}
+	if string(buf[:n]) != message {
+		t.Errorf("expected %q got %q", message, buf[:n])
+	}
+	if _, ok := u.EffectiveReadDeadline(); ok {
+		t.Error("expected no read deadline to be set")
+	}
+
+	if _, err = u.Transmit(peer.LocalAddr().(*net.UDPAddr), []byte(message)); err != nil {
+		t.Error("failed to transmit without deadline -", err)
+	}
+}
+
+func TestUDPClient_SetDeadline(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	var nilClient *UDPClient
+	if err = nilClient.SetReadDeadline(time.Now()); err == nil {
+		t.Error("expected Error on nil client got nil")
+	}
+	if err = nilClient.SetWriteDeadline(time.Now()); err == nil {
+		t.Error("expected Error on nil client got nil")
+	}
+
+	// An explicit deadline outlives ReadDeadline for the whole exchange
+	deadline := time.Now().Add(4 * u.ReadDeadline)
+	if err = u.SetReadDeadline(deadline); err != nil {
+		t.Fatal("failed to set read deadline -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err = u.Receive(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 4*u.ReadDeadline {
+		t.Errorf("expected reads to last until the explicit deadline got %v", elapsed)
+	}
+	if got, ok := u.EffectiveReadDeadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("expected effective deadline %v got %v", deadline, got)
+	}
+
+	// Clearing it restores the relative ReadDeadline
+	if err = u.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal("failed to clear read deadline -", err)
+	}
+	start = time.Now()
+	if _, err = u.Receive(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*u.ReadDeadline {
+		t.Errorf("expected the relative deadline to apply got %v", elapsed)
+	}
+
+	// A past write deadline fails Transmit until it is cleared
+	if err = u.SetWriteDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal("failed to set write deadline -", err)
+	}
+	dst := u.LocalAddr().(*net.UDPAddr)
+	if _, err = u.Transmit(dst, []byte("late")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected deadline exceeded got %v", err)
+	}
+	if err = u.SetWriteDeadline(time.Time{}); err != nil {
+		t.Fatal("failed to clear write deadline -", err)
+	}
+	if _, err = u.Transmit(dst, []byte("on time")); err != nil {
+		t.Error("failed to transmit -", err)
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+
+	buf := make([]byte, maxBufferSize)
+	_, err = u.Receive(buf)
+	if !IsTimeout(err) {
+		t.Errorf("expected Receive timeout got %v", err)
+	}
+
+	if err = u.SetWriteDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal("failed to set write deadline -", err)
+	}
+	_, err = u.Transmit(u.LocalAddr().(*net.UDPAddr), []byte("late"))
+	if !IsTimeout(err) {
+		t.Errorf("expected Transmit timeout got %v", err)
+	}
+
+	for _, err := range []error{nil, ErrEmptyDatagram, net.ErrClosed} {
+		if IsTimeout(err) {
+			t.Errorf("expected %v not to be a timeout", err)
+		}
+	}
+}
+
+func TestUDPClient_Truncated(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	defer u.Close()
+	dst := u.LocalAddr().(*net.UDPAddr)
+
+	if _, err = u.Transmit(dst, make([]byte, 4096)); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, 1024)
+	n, err := u.Receive(buf)
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated got %v", err)
+	}
+	if n != len(buf) {
+		t.Errorf("expected %d bytes got %d", len(buf), n)
+	}
+
+	if msgTrunc != 0 {
+		if _, err = u.Transmit(dst, make([]byte, len(buf))); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err = u.Receive(buf); err != nil {
+			t.Error("expected an exactly fitting datagram to be received got", err)
+		}
+	}
+}
+
+func TestUDPClient_SetMaxPacketSize(t *testing.T) {
+	u, err := NewUnbound()
+	if err != nil {
+		t.Fatal("failed to create unbound client -", err)
+	}
+	for _, tc := range []struct{ set, want int }{
+		{1500, 1500},
+		{-1, MaxDatagramSize},
+		{0, MaxDatagramSize},
+		{1 << 20, MaxDatagramSize},
+	} {
+		u.SetMaxPacketSize(tc.set)
+		if got := u.maxPacketSize(); got != tc.want {
+			t.Errorf("expected %d for %d got %d", tc.want, tc.set, got)
+		}
+	}
+}
+
+func TestUDPClient_Done(t *testing.T) {
+	u, err := NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+
+	unblocked := make(chan struct{})
+	go func() {
+		<-u.Done()
+		close(unblocked)
+	}()
+
+	select {
+	case <-unblocked:
+		t.Fatal("expected Done to block while the client is open")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	u.Close()
+	u.Close()
+	select {
+	case <-unblocked:
+	case <-time.After(time.Second):
+		t.Fatal("expected Done to unblock after Close")
+	}
+
+	// A client closed before Done was first called is done already
+	u, err = NewUDPClient(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create udp client -", err)
+	}
+	u.Close()
+	select {
+	case <-u.Done():
+	default:
+		t.Error("expected Done to be closed after Close")
+	}
+
+	// Binding again starts a new lifetime
+	if err = u.Bind(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}); err != nil {
+		t.Fatal("failed to bind again -", err)
+	}
+	defer u.Close()
+	select {
+	case <-u.Done():
+		t.Error("expected Done to block after binding again")
+	default:
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// UnixgramAddr returns the address of the Unix datagram socket at path, for
+// clients created with WithNetwork("unixgram"). Paths starting with "@" are
+// in the abstract namespace of Linux.
+func UnixgramAddr(path string) *net.UnixAddr {
+	return &net.UnixAddr{Name: path, Net: "unixgram"}
+}
+
+// errNotUDP is returned by the methods of a unixgramConn dealing in UDP
+// addresses, which a Unix datagram socket has none of.
+var errNotUDP = fmt.Errorf("%w - unixgram socket has no UDP address", ErrAddressFamilyMismatch)
+
+// unixgramConn adapts a Unix datagram socket to the packetConn of a client.
+// The client reads it with readMsg and writes it with WriteTo, which carry
+// the *net.UnixAddr of the peers, while the methods taking or returning a
+// *net.UDPAddr fail with errNotUDP.
+type unixgramConn struct {
+	*net.UnixConn
+
+	path string // removed on Close unless abstract
+}
+
+var _ packetConn = (*unixgramConn)(nil)
+
+// listenUnixgram opens a Unix datagram socket bound to the path of laddr.
+func listenUnixgram(laddr *net.UnixAddr) (*unixgramConn, error) {
+	if laddr == nil || laddr.Name == "" {
+		return nil, fmt.Errorf("parameter error in listenUnixgram - no socket path")
+	}
+	conn, err := net.ListenUnixgram("unixgram", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &unixgramConn{UnixConn: conn, path: laddr.Name}, nil
+}
+
+// readMsg reads a datagram into b, returning its size, the message flags and
+// the sender, nil for an unnamed socket.
+func (c *unixgramConn) readMsg(b []byte) (n, flags int, addr net.Addr, err error) {
+	n, _, flags, ua, err := c.ReadMsgUnix(b, nil)
+	if ua != nil && ua.Name != "" {
+		addr = ua
+	}
+	return n, flags, addr, err
+}
+
+// ReadFromUDP fails with errNotUDP.
+func (c *unixgramConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	return 0, nil, errNotUDP
+}
+
+// ReadMsgUDP fails with errNotUDP.
+func (c *unixgramConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	return 0, 0, 0, nil, errNotUDP
+}
+
+// WriteMsgUDP fails with errNotUDP.
+func (c *unixgramConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	return 0, 0, errNotUDP
+}
+
+// WriteTo sends b to the Unix address addr.
+func (c *unixgramConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	ua, ok := addr.(*net.UnixAddr)
+	if !ok {
+		return 0, fmt.Errorf("%w - %v is not a unixgram address", ErrAddressFamilyMismatch, addr)
+	}
+	return c.WriteToUnix(b, ua)
+}
+
+// Close closes the socket and removes its path.
+func (c *unixgramConn) Close() error {
+	err := c.UnixConn.Close()
+	if err == nil && !strings.HasPrefix(c.path, "@") {
+		if rerr := os.Remove(c.path); rerr != nil && !os.IsNotExist(rerr) {
+			err = rerr
+		}
+	}
+	return err
+}
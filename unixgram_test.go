@@ -0,0 +1,167 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestUnixgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram is not supported on windows")
+	}
+	dir := t.TempDir()
+	newClient := func(name string) *UDPClient {
+		u, err := NewUDPClientWithOptions(
+			WithNetwork("unixgram"),
+			WithLocalUnixAddr(UnixgramAddr(filepath.Join(dir, name))),
+		)
+		if err != nil {
+			t.Fatal("failed to create unixgram client -", err)
+		}
+		return u
+	}
+	a := newClient("a.sock")
+	defer a.Close()
+	b := newClient("b.sock")
+	defer b.Close()
+
+	if got := a.LocalAddr().String(); got != filepath.Join(dir, "a.sock") {
+		t.Errorf("expected local address %q got %q", filepath.Join(dir, "a.sock"), got)
+	}
+
+	message := "Birds of a feather flock together"
+	if _, err := a.WriteTo([]byte(message), b.LocalAddr()); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+	buf := make([]byte, maxBufferSize)
+	n, from, err := b.ReadFrom(buf)
+	if err != nil {
+		t.Fatal("failed to receive -", err)
+	}
+	if ua, ok := from.(*net.UnixAddr); string(buf[:n]) != message || !ok || ua.Name != filepath.Join(dir, "a.sock") {
+		t.Errorf("expected %q from %v got %q from %v", message, a.LocalAddr(), buf[:n], from)
+	}
+
+	// The sender address takes the reply back
+	if _, err = b.WriteTo(buf[:n], from); err != nil {
+		t.Fatal("failed to reply -", err)
+	}
+	if n, err = a.Receive(buf); err != nil || string(buf[:n]) != message {
+		t.Errorf("expected the reply %q got %q and %v", message, buf[:n], err)
+	}
+	if a.RemoteAddr.String() != b.LocalAddr().String() {
+		t.Errorf("expected RemoteAddr %v got %v", b.LocalAddr(), a.RemoteAddr)
+	}
+
+	if err = b.Reconnect(); err != nil {
+		t.Fatal("failed to reconnect -", err)
+	}
+	if _, err = a.WriteTo([]byte(message), b.LocalAddr()); err != nil {
+		t.Fatal("failed to transmit after reconnect -", err)
+	}
+	if n, err = b.Receive(buf); err != nil || string(buf[:n]) != message {
+		t.Errorf("expected %q after reconnect got %q and %v", message, buf[:n], err)
+	}
+
+	if _, err = a.Transmit(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9}, buf[:n]); !errors.Is(err, ErrAddressFamilyMismatch) {
+		t.Errorf("expected ErrAddressFamilyMismatch got %v", err)
+	}
+
+	if err = a.Close(); err != nil {
+		t.Error("failed to close -", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "a.sock")); !os.IsNotExist(err) {
+		t.Errorf("expected the socket path removed on close got %v", err)
+	}
+
+	if _, err = NewUDPClientWithOptions(WithNetwork("unixgram")); err == nil {
+		t.Error("expected Error(no socket path) got nil")
+	}
+	if _, err = NewUDPClientWithOptions(WithLocalUnixAddr(UnixgramAddr(""))); err == nil {
+		t.Error("expected Error(empty socket path) got nil")
+	}
+}
+
+func TestUnixgram_ServeConcurrentOrdered(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram is not supported on windows")
+	}
+	dir := t.TempDir()
+	if senderHash(UnixgramAddr(filepath.Join(dir, "a.sock"))) == senderHash(UnixgramAddr(filepath.Join(dir, "b.sock"))) {
+		t.Errorf("expected the senders hashed by their path")
+	}
+
+	server, err := NewUDPClientWithOptions(
+		WithNetwork("unixgram"),
+		WithLocalUnixAddr(UnixgramAddr(filepath.Join(dir, "server.sock"))),
+		WithOrderedDispatch(),
+	)
+	if err != nil {
+		t.Fatal("failed to create unixgram server -", err)
+	}
+	defer server.Close()
+
+	handled := make(chan string, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeConcurrent(ctx, 4, func(addr *net.UDPAddr, data []byte) ([]byte, error) {
+			handled <- string(data)
+			return data, nil
+		})
+	}()
+
+	// An unnamed socket sends without an address
+	unnamed, err := net.DialUnix("unixgram", nil, server.LocalAddr().(*net.UnixAddr))
+	if err != nil {
+		t.Fatal("failed to dial unnamed socket -", err)
+	}
+	defer unnamed.Close()
+	if _, err = unnamed.Write([]byte("unnamed")); err != nil {
+		t.Fatal("failed to write from unnamed socket -", err)
+	}
+
+	named, err := NewUDPClientWithOptions(
+		WithNetwork("unixgram"),
+		WithLocalUnixAddr(UnixgramAddr(filepath.Join(dir, "named.sock"))),
+		WithReadDeadline(time.Second),
+	)
+	if err != nil {
+		t.Fatal("failed to create unixgram client -", err)
+	}
+	defer named.Close()
+	if _, err = named.WriteTo([]byte("named"), server.LocalAddr()); err != nil {
+		t.Fatal("failed to transmit -", err)
+	}
+
+	got := make(map[string]bool)
+	for len(got) < 2 {
+		select {
+		case data := <-handled:
+			got[data] = true
+		case <-time.After(time.Second):
+			t.Fatalf("expected both datagrams handled got %v", got)
+		}
+	}
+
+	// The reply goes back to the named peer
+	buf := make([]byte, maxBufferSize)
+	if n, err := named.Receive(buf); err != nil || string(buf[:n]) != "named" {
+		t.Errorf("expected the reply %q got %q and %v", "named", buf[:n], err)
+	}
+
+	cancel()
+	if err = <-served; err != nil {
+		t.Errorf("expected nil on cancellation got %v", err)
+	}
+}
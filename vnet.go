@@ -0,0 +1,256 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// vnetFirstPort is the first port assigned to the clients of a
+// VirtualNetwork created without one.
+const vnetFirstPort = 49152
+
+// LinkProfile describes the behaviour of a link of a VirtualNetwork, in each
+// direction.
+type LinkProfile struct {
+	// Latency delays every datagram by this long.
+	Latency time.Duration
+
+	// Jitter adds a random delay in [0, Jitter) to the latency, which may
+	// reorder datagrams.
+	Jitter time.Duration
+
+	// LossRate is the probability in [0, 1] of a datagram being dropped.
+	LossRate float64
+
+	// Bandwidth is the rate of the link in bytes per second, datagrams
+	// queue behind each other once exceeded. Zero is unlimited.
+	Bandwidth int
+}
+
+// VirtualNetwork is an in-memory network of UDPClients, exchanging datagrams
+// over the links added by Connect, to exercise code on a network with
+// given latency, jitter, loss and bandwidth. The impairments are drawn from
+// a generator seeded for each link, so that the same sequence of datagrams
+// is impaired the same way on every run. It is safe for concurrent use.
+type VirtualNetwork struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	hosts    map[string]*vnetConn
+	links    map[[2]string]*vnetLink
+	nextPort int
+	dropped  int
+
+	// Datagrams in flight, sorted by delivery time
+	pending []vnetPacket
+	seq     uint64
+	timer   *time.Timer
+}
+
+// vnetLink is a direction of a link of a VirtualNetwork.
+type vnetLink struct {
+	profile LinkProfile
+	rng     *rand.Rand
+	busy    time.Time // until when the link transmits the queued datagrams
+}
+
+// vnetPacket is a datagram in flight on a VirtualNetwork.
+type vnetPacket struct {
+	due  time.Time
+	seq  uint64 // keeps the order of datagrams due at the same time
+	dg   Datagram
+	dest string
+}
+
+// NewVirtualNetwork creates an empty virtual network with impairments drawn
+// from seed.
+func NewVirtualNetwork(seed uint64) *VirtualNetwork {
+	return &VirtualNetwork{
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		hosts:    make(map[string]*vnetConn),
+		links:    make(map[[2]string]*vnetLink),
+		nextPort: vnetFirstPort,
+	}
+}
+
+// NewUDPClient creates a client on the network bound to laddr, assigning a
+// port when it has none. It fails when the address is in use.
+func (v *VirtualNetwork) NewUDPClient(laddr *net.UDPAddr) (*UDPClient, error) {
+	if laddr == nil || laddr.IP == nil {
+		return nil, fmt.Errorf("parameter error in NewUDPClient - no local address")
+	}
+
+	v.mu.Lock()
+	local := &net.UDPAddr{IP: laddr.IP, Port: laddr.Port, Zone: laddr.Zone}
+	if local.Port == 0 {
+		local.Port = v.nextPort
+		v.nextPort++
+	}
+	if _, ok := v.hosts[local.String()]; ok {
+		v.mu.Unlock()
+		return nil, fmt.Errorf("failed to bind in NewUDPClient - %v in use", local)
+	}
+	c := &vnetConn{MockConn: newMockConn(local), network: v}
+	v.hosts[local.String()] = c
+	v.mu.Unlock()
+
+	return &UDPClient{
+		conn:          c,
+		ReadDeadline:  ReadDeadline,
+		WriteDeadline: WriteDeadline,
+		features:      probeFeatures(c),
+	}, nil
+}
+
+// Connect links the addresses a and b with profile in both directions,
+// replacing any previous link between them. Datagrams between addresses
+// not connected are dropped.
+func (v *VirtualNetwork) Connect(a, b *net.UDPAddr, profile LinkProfile) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range [][2]string{{a.String(), b.String()}, {b.String(), a.String()}} {
+		v.links[key] = &vnetLink{
+			profile: profile,
+			rng:     rand.New(rand.NewPCG(v.rng.Uint64(), v.rng.Uint64())),
+		}
+	}
+}
+
+// Dropped returns the number of datagrams dropped so far, lost on a link or
+// sent to an address not connected.
+func (v *VirtualNetwork) Dropped() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.dropped
+}
+
+// send carries a copy of b from src to dst over their link.
+func (v *VirtualNetwork) send(b []byte, src, dst *net.UDPAddr) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	l, ok := v.links[[2]string{src.String(), dst.String()}]
+	if !ok || l.rng.Float64() < l.profile.LossRate {
+		v.dropped++
+		return
+	}
+
+	now := time.Now()
+	due := now
+	if l.profile.Bandwidth > 0 {
+		if l.busy.After(due) {
+			due = l.busy
+		}
+		due = due.Add(time.Duration(len(b)) * time.Second / time.Duration(l.profile.Bandwidth))
+		l.busy = due
+	}
+	due = due.Add(l.profile.Latency)
+	if l.profile.Jitter > 0 {
+		due = due.Add(time.Duration(l.rng.Int64N(int64(l.profile.Jitter))))
+	}
+
+	v.seq++
+	p := vnetPacket{
+		due:  due,
+		seq:  v.seq,
+		dg:   Datagram{Data: append([]byte(nil), b...), Addr: src},
+		dest: dst.String(),
+	}
+	i, _ := slices.BinarySearchFunc(v.pending, p, func(e, t vnetPacket) int {
+		if c := e.due.Compare(t.due); c != 0 {
+			return c
+		}
+		return cmp.Compare(e.seq, t.seq)
+	})
+	v.pending = slices.Insert(v.pending, i, p)
+	if i == 0 {
+		v.schedule(now)
+	}
+}
+
+// schedule arms the timer for the first datagram in flight, with v.mu held.
+func (v *VirtualNetwork) schedule(now time.Time) {
+	if len(v.pending) == 0 {
+		return
+	}
+	wait := v.pending[0].due.Sub(now)
+	if v.timer == nil {
+		v.timer = time.AfterFunc(wait, v.deliver)
+		return
+	}
+	v.timer.Reset(wait)
+}
+
+// deliver hands the datagrams due to their destinations, in order.
+func (v *VirtualNetwork) deliver() {
+	v.mu.Lock()
+	now := time.Now()
+	i := 0
+	for i < len(v.pending) && !v.pending[i].due.After(now) {
+		i++
+	}
+	due := slices.Clone(v.pending[:i])
+	v.pending = slices.Delete(v.pending, 0, i)
+	dests := make([]*vnetConn, len(due))
+	for j, p := range due {
+		dests[j] = v.hosts[p.dest]
+	}
+	v.schedule(now)
+	v.mu.Unlock()
+
+	for j, p := range due {
+		if dests[j] == nil || !dests[j].offer(p.dg) {
+			v.mu.Lock()
+			v.dropped++
+			v.mu.Unlock()
+		}
+	}
+}
+
+// vnetConn is the connection of a client of a VirtualNetwork, receiving as
+// a MockConn.
+type vnetConn struct {
+	*MockConn
+
+	network *VirtualNetwork
+}
+
+// WriteTo sends b to addr over the network.
+func (c *vnetConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	a, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, syscall.EINVAL
+	}
+	n, _, err := c.WriteMsgUDP(b, nil, a)
+	return n, err
+}
+
+// WriteMsgUDP sends b to addr over the network, oob is ignored.
+func (c *vnetConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	select {
+	case <-c.closed:
+		return 0, 0, net.ErrClosed
+	default:
+	}
+	c.network.send(b, c.local, addr)
+	return len(b), 0, nil
+}
+
+// Close removes the client from the network and closes the connection.
+func (c *vnetConn) Close() error {
+	c.network.mu.Lock()
+	if c.network.hosts[c.local.String()] == c {
+		delete(c.network.hosts, c.local.String())
+	}
+	c.network.mu.Unlock()
+	return c.MockConn.Close()
+}
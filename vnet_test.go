@@ -0,0 +1,122 @@
+// Copyright 2021 Abhijit Bose. All rights reserved.
+// Use of this source code is governed by a Apache 2.0 license that can be found
+// in the LICENSE file.
+
+package udp
+
+import (
+	"encoding/binary"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// exchangeVirtual sends count numbered datagrams between two clients of a
+// network seeded with seed, returning the numbers received by each side.
+func exchangeVirtual(t *testing.T, seed uint64, count int) (got [2][]uint32) {
+	t.Helper()
+	v := NewVirtualNetwork(seed)
+	var clients [2]*UDPClient
+	for i := range clients {
+		u, err := v.NewUDPClient(&net.UDPAddr{IP: net.IPv4(10, 0, 0, byte(i+1))})
+		if err != nil {
+			t.Fatal("failed to create virtual client -", err)
+		}
+		defer u.Close()
+		u.ReadDeadline = 100 * time.Millisecond
+		clients[i] = u
+	}
+	a := clients[0].LocalAddr().(*net.UDPAddr)
+	b := clients[1].LocalAddr().(*net.UDPAddr)
+	v.Connect(a, b, LinkProfile{Latency: 10 * time.Millisecond, LossRate: 0.01})
+
+	start := time.Now()
+	for i := 0; i < count; i++ {
+		var data [4]byte
+		binary.BigEndian.PutUint32(data[:], uint32(i))
+		if _, err := clients[0].Transmit(b, data[:]); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+		if _, err := clients[1].Transmit(a, data[:]); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+
+	buf := make([]byte, maxBufferSize)
+	for i, u := range clients {
+		for {
+			n, _, err := u.ReceiveFrom(buf)
+			if IsTimeout(err) {
+				break
+			}
+			if err != nil {
+				t.Fatal("failed to receive -", err)
+			}
+			if len(got[i]) == 0 && time.Since(start) < 10*time.Millisecond {
+				t.Errorf("expected the latency of the link got %v", time.Since(start))
+			}
+			got[i] = append(got[i], binary.BigEndian.Uint32(buf[:n]))
+		}
+	}
+	if received := len(got[0]) + len(got[1]); received+v.Dropped() != 2*count {
+		t.Errorf("expected %d datagrams accounted for got %d received and %d dropped",
+			2*count, received, v.Dropped())
+	}
+	return got
+}
+
+func TestVirtualNetwork(t *testing.T) {
+	const count = 500
+	first := exchangeVirtual(t, 42, count)
+	second := exchangeVirtual(t, 42, count)
+
+	for i := range first {
+		if len(first[i]) == count {
+			t.Errorf("expected datagrams lost on a lossy link")
+		}
+		if !slices.IsSorted(first[i]) {
+			t.Errorf("expected datagrams in order without jitter")
+		}
+		if !slices.Equal(first[i], second[i]) {
+			t.Errorf("expected identical runs for the same seed got %v and %v", first[i], second[i])
+		}
+	}
+}
+
+func TestVirtualNetwork_Bandwidth(t *testing.T) {
+	v := NewVirtualNetwork(1)
+	a, err := v.NewUDPClient(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1)})
+	if err != nil {
+		t.Fatal("failed to create virtual client -", err)
+	}
+	defer a.Close()
+	b, err := v.NewUDPClient(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 2)})
+	if err != nil {
+		t.Fatal("failed to create virtual client -", err)
+	}
+	defer b.Close()
+	v.Connect(a.LocalAddr().(*net.UDPAddr), b.LocalAddr().(*net.UDPAddr), LinkProfile{Bandwidth: 100000})
+
+	// Ten datagrams of 1000 bytes take 100ms at 100kB/s
+	start := time.Now()
+	data := make([]byte, 1000)
+	for i := 0; i < 10; i++ {
+		if _, err = a.Transmit(b.LocalAddr().(*net.UDPAddr), data); err != nil {
+			t.Fatal("failed to transmit -", err)
+		}
+	}
+	buf := make([]byte, maxBufferSize)
+	for i := 0; i < 10; i++ {
+		if _, err = b.Receive(buf); err != nil {
+			t.Fatal("failed to receive -", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the bandwidth to pace the datagrams got %v", elapsed)
+	}
+
+	if _, err = v.NewUDPClient(a.LocalAddr().(*net.UDPAddr)); err == nil {
+		t.Error("expected Error(address in use) got nil")
+	}
+}